@@ -0,0 +1,299 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/genc-murat/rabbitspy/ui"
+)
+
+// command is a subcommand run instead of the dashboard.
+type command struct {
+	name    string
+	args    []string
+	summary string
+	run     func(out io.Writer, args []string) error
+}
+
+// commands is filled in by init since the completion scripts list it.
+var commands []command
+
+func init() {
+	commands = []command{
+		{"completion", []string{"bash", "zsh", "fish"}, "print a shell completion script", writeCompletion},
+		{"man", nil, "print the rabbitspy(1) man page", func(out io.Writer, _ []string) error {
+			return writeManPage(out)
+		}},
+		{"export", nil, "write the broker's definitions to a timestamped JSON file: export [--cluster name] [--vhost name] [-o dir]", runExport},
+		{"import", nil, "compare a definitions file with the broker and, with --apply, import it: import [--cluster name] [--vhost name] [--apply] file", runImport},
+		{"sample", nil, "check a sample of a queue's messages against the sampling rules: sample [--cluster name] [--vhost /] [-n count] queue", runSample},
+	}
+}
+
+// flagValues lists the values a flag accepts, for completion.
+var flagValues = map[string][]string{
+	"renderer": {"termui", "bubbletea"},
+}
+
+func runSample(out io.Writer, args []string) error {
+	fs := flag.NewFlagSet("sample", flag.ContinueOnError)
+	cluster := fs.String("cluster", "", "cluster to sample, when several are configured")
+	vhost := fs.String("vhost", "/", "vhost of the queue")
+	count := fs.Int("n", 0, "messages to sample (default sampling.count)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: rabbitspy sample [--cluster name] [--vhost vhost] [-n count] queue")
+	}
+	config, err := ui.LoadConfigOverrides(configFile, overrides)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration file: %w", err)
+	}
+	return ui.SampleQueue(config, *cluster, *vhost, fs.Arg(0), *count, out)
+}
+
+func runExport(out io.Writer, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	cluster := fs.String("cluster", "", "cluster to export, when several are configured")
+	vhost := fs.String("vhost", "", "export only this vhost (default all)")
+	dir := fs.String("o", ".", "directory to write the file to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: rabbitspy export [--cluster name] [--vhost vhost] [-o dir]")
+	}
+	config, err := ui.LoadConfigOverrides(configFile, overrides)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration file: %w", err)
+	}
+	return ui.ExportDefinitions(config, *cluster, *vhost, *dir, out)
+}
+
+func runImport(out io.Writer, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	cluster := fs.String("cluster", "", "cluster to import into, when several are configured")
+	vhost := fs.String("vhost", "", "import into this vhost only (default the whole broker)")
+	apply := fs.Bool("apply", false, "import the file; without it only the changes are listed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: rabbitspy import [--cluster name] [--vhost vhost] [--apply] file")
+	}
+	config, err := ui.LoadConfigOverrides(configFile, overrides)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration file: %w", err)
+	}
+	config.ReadOnly = config.ReadOnly || readOnly
+	return ui.ImportDefinitions(config, *cluster, *vhost, fs.Arg(0), *apply, out)
+}
+
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+func commandNames() string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.name
+	}
+	return strings.Join(names, ", ")
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// flags returns the command line flags sorted by name.
+func flags() []*flag.Flag {
+	var all []*flag.Flag
+	flag.VisitAll(func(f *flag.Flag) { all = append(all, f) })
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+func writeCompletion(out io.Writer, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: rabbitspy completion bash|zsh|fish")
+	}
+	switch args[0] {
+	case "bash":
+		writeBashCompletion(out)
+	case "zsh":
+		writeZshCompletion(out)
+	case "fish":
+		writeFishCompletion(out)
+	default:
+		return fmt.Errorf("unknown shell %q (known: bash, zsh, fish)", args[0])
+	}
+	return nil
+}
+
+func writeBashCompletion(out io.Writer) {
+	var words []string
+	for _, c := range commands {
+		words = append(words, c.name)
+	}
+	for _, f := range flags() {
+		words = append(words, "--"+f.Name)
+	}
+
+	fmt.Fprintln(out, "# bash completion for rabbitspy; source it or put it in /etc/bash_completion.d/rabbitspy")
+	fmt.Fprintln(out, "_rabbitspy() {")
+	fmt.Fprintln(out, `    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(out, `    case "$prev" in`)
+	for _, f := range flags() {
+		if values, ok := flagValues[f.Name]; ok {
+			fmt.Fprintf(out, "    --%s|-%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", f.Name, f.Name, strings.Join(values, " "))
+		}
+	}
+	for _, c := range commands {
+		if len(c.args) > 0 {
+			fmt.Fprintf(out, "    %s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", c.name, strings.Join(c.args, " "))
+		}
+	}
+	fmt.Fprintln(out, "    esac")
+	fmt.Fprintf(out, "    COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(words, " "))
+	fmt.Fprintln(out, "}")
+	fmt.Fprintln(out, "complete -F _rabbitspy rabbitspy rabbit-spy")
+}
+
+// zshQuote escapes s for an _arguments or _describe spec.
+func zshQuote(s string) string {
+	return strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
+
+func writeZshCompletion(out io.Writer) {
+	fmt.Fprintln(out, "#compdef rabbitspy rabbit-spy")
+	fmt.Fprintln(out, "# zsh completion for rabbitspy; put it in a directory on $fpath as _rabbitspy")
+	fmt.Fprintln(out, "_rabbitspy() {")
+	fmt.Fprintln(out, "  local -a commands")
+	fmt.Fprint(out, "  commands=(")
+	for _, c := range commands {
+		fmt.Fprintf(out, " '%s:%s'", c.name, zshQuote(c.summary))
+	}
+	fmt.Fprintln(out, " )")
+	fmt.Fprintln(out, "  _arguments \\")
+	for _, f := range flags() {
+		spec := fmt.Sprintf("--%s[%s]", f.Name, zshQuote(f.Usage))
+		if !isBoolFlag(f) {
+			values := "_files"
+			if v, ok := flagValues[f.Name]; ok {
+				values = "(" + strings.Join(v, " ") + ")"
+			}
+			spec = fmt.Sprintf("--%s=[%s]:%s:%s", f.Name, zshQuote(f.Usage), f.Name, values)
+		}
+		fmt.Fprintf(out, "    '%s' \\\n", spec)
+	}
+	fmt.Fprintln(out, "    '1: :->command' \\")
+	fmt.Fprintln(out, "    '2: :->argument'")
+	fmt.Fprintln(out, "  case $state in")
+	fmt.Fprintln(out, "    command) _describe command commands ;;")
+	fmt.Fprintln(out, "    argument)")
+	fmt.Fprintln(out, "      case $words[2] in")
+	for _, c := range commands {
+		if len(c.args) > 0 {
+			fmt.Fprintf(out, "        %s) _values %s %s ;;\n", c.name, c.name, strings.Join(c.args, " "))
+		}
+	}
+	fmt.Fprintln(out, "      esac ;;")
+	fmt.Fprintln(out, "  esac")
+	fmt.Fprintln(out, "}")
+	fmt.Fprintln(out, `_rabbitspy "$@"`)
+}
+
+// fishQuote quotes s for a fish command line.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+func writeFishCompletion(out io.Writer) {
+	fmt.Fprintln(out, "# fish completion for rabbitspy; put it in ~/.config/fish/completions/rabbitspy.fish")
+	fmt.Fprintln(out, "complete -c rabbitspy -f")
+	for _, c := range commands {
+		fmt.Fprintf(out, "complete -c rabbitspy -n __fish_use_subcommand -a %s -d %s\n", c.name, fishQuote(c.summary))
+		if len(c.args) > 0 {
+			fmt.Fprintf(out, "complete -c rabbitspy -n '__fish_seen_subcommand_from %s' -a %s\n", c.name, fishQuote(strings.Join(c.args, " ")))
+		}
+	}
+	for _, f := range flags() {
+		line := fmt.Sprintf("complete -c rabbitspy -l %s -d %s", f.Name, fishQuote(f.Usage))
+		if values, ok := flagValues[f.Name]; ok {
+			line += " -x -a " + fishQuote(strings.Join(values, " "))
+		} else if !isBoolFlag(f) {
+			line += " -r"
+		}
+		fmt.Fprintln(out, line)
+	}
+}
+
+// roff escapes s for a man page line.
+func roff(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+// writeManPage generates rabbitspy(1) from the flag and command definitions,
+// so it can't drift from what the binary accepts.
+func writeManPage(out io.Writer) error {
+	fmt.Fprintln(out, `.TH RABBITSPY 1 "" rabbitspy "User Commands"`)
+	fmt.Fprintln(out, ".SH NAME")
+	fmt.Fprintln(out, `rabbitspy \- terminal dashboard for RabbitMQ queues, nodes and alerts`)
+	fmt.Fprintln(out, ".SH SYNOPSIS")
+	fmt.Fprintln(out, `.B rabbitspy`)
+	fmt.Fprintln(out, `[\fIoptions\fR]`)
+	fmt.Fprintln(out, ".br")
+	for _, c := range commands {
+		fmt.Fprintf(out, `.B rabbitspy %s`+"\n", c.name)
+		if len(c.args) > 0 {
+			fmt.Fprintf(out, `\fI%s\fR`+"\n", strings.Join(c.args, "|"))
+		}
+		fmt.Fprintln(out, ".br")
+	}
+	fmt.Fprintln(out, ".SH DESCRIPTION")
+	fmt.Fprintln(out, "Rabbit Spy polls the RabbitMQ management API and shows queues, nodes, policies, shovels, streams and alerts in the terminal.")
+	fmt.Fprintln(out, "It reads its configuration from the file given with --config, else from")
+	fmt.Fprintln(out, `.I $XDG_CONFIG_HOME/rabbitspy/config.json`)
+	fmt.Fprintln(out, "or, failing that,")
+	fmt.Fprintln(out, ".I config.json")
+	fmt.Fprintln(out, "in the current directory; the connection flags override it.")
+	fmt.Fprintln(out, ".SH OPTIONS")
+	for _, f := range flags() {
+		fmt.Fprintln(out, ".TP")
+		if isBoolFlag(f) {
+			fmt.Fprintf(out, `\fB\-\-%s\fR`+"\n", roff(f.Name))
+		} else {
+			fmt.Fprintf(out, `\fB\-\-%s\fR=\fI%s\fR`+"\n", roff(f.Name), roff(f.Name))
+		}
+		usage := f.Usage
+		if !isBoolFlag(f) && f.DefValue != "" && f.DefValue != "0" {
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		fmt.Fprintln(out, roff(usage))
+	}
+	fmt.Fprintln(out, ".SH COMMANDS")
+	for _, c := range commands {
+		fmt.Fprintln(out, ".TP")
+		fmt.Fprintf(out, `\fB%s\fR`+"\n", roff(c.name))
+		fmt.Fprintln(out, roff(c.summary))
+	}
+	fmt.Fprintln(out, ".SH FILES")
+	fmt.Fprintln(out, ".TP")
+	fmt.Fprintln(out, `.I $XDG_CONFIG_HOME/rabbitspy/config.json\fR, \fI~/.config/rabbitspy/config.json\fR, \fIconfig.json`)
+	fmt.Fprintln(out, "Clusters, alert rules, notifiers and display settings; see the README for every option.")
+	return nil
+}
@@ -0,0 +1,40 @@
+#!/bin/sh
+# Starts rabbitspy in the mode named by RABBITSPY_MODE. The configuration
+# comes from RABBITSPY_CONFIG (the JSON itself), RABBITSPY_CONFIG_FILE (a
+# mounted file or secret) or, for a single broker, RABBITSPY_HOST and the
+# variables that go with it; arguments are passed on to rabbitspy.
+set -e
+
+if [ -z "$RABBITSPY_CONFIG" ] && [ -z "$RABBITSPY_CONFIG_FILE" ] && [ -z "$RABBITSPY_HOST" ] && [ -z "$RABBITMQ_HOST" ]; then
+	echo "rabbitspy: set RABBITSPY_CONFIG to the configuration JSON, RABBITSPY_CONFIG_FILE to a mounted config file or RABBITSPY_HOST to the broker" >&2
+	exit 64
+fi
+
+case "${RABBITSPY_MODE:-headless}" in
+headless)
+	exec rabbitspy --headless --listen "${RABBITSPY_LISTEN:-:9912}" "$@"
+	;;
+web)
+	# -W lets the browser type into the terminal, which can purge and
+	# delete, so it is only served behind basic auth; the dashboard is
+	# started anew for every browser tab.
+	case "$RABBITSPY_WEB_CREDENTIAL" in
+	?*:?*) ;;
+	*)
+		echo "rabbitspy: set RABBITSPY_WEB_CREDENTIAL to user:password for the web terminal" >&2
+		exit 64
+		;;
+	esac
+	exec ttyd -W -c "$RABBITSPY_WEB_CREDENTIAL" -p "${RABBITSPY_WEB_PORT:-7681}" rabbitspy "$@"
+	;;
+tui)
+	exec rabbitspy "$@"
+	;;
+plain)
+	exec rabbitspy --plain "$@"
+	;;
+*)
+	echo "rabbitspy: unknown RABBITSPY_MODE $RABBITSPY_MODE (known: headless, web, tui, plain)" >&2
+	exit 64
+	;;
+esac
@@ -0,0 +1,507 @@
+// Package management holds typed representations of the entities returned
+// by the RabbitMQ management HTTP API.
+//
+// Fields the broker omits depending on version, queue type or whether stats
+// collection is enabled decode to their zero value. Where zero is a
+// meaningful value and "not reported" has to be told apart from it, the
+// field is a pointer.
+package management
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Rate is the *_details companion of a counter: its per-second rate over the
+// management API's sample window.
+type Rate struct {
+	Rate float64 `json:"rate"`
+}
+
+// MessageStats are the cumulative message counters reported for queues,
+// exchanges, channels and the cluster as a whole. Which counters are set
+// depends on the object: exchanges report PublishIn and PublishOut, queues
+// report Publish, Deliver* and Ack, and so on.
+type MessageStats struct {
+	Publish                 int  `json:"publish,omitempty"`
+	PublishDetails          Rate `json:"publish_details"`
+	PublishIn               int  `json:"publish_in,omitempty"`
+	PublishInDetails        Rate `json:"publish_in_details"`
+	PublishOut              int  `json:"publish_out,omitempty"`
+	PublishOutDetails       Rate `json:"publish_out_details"`
+	Confirm                 int  `json:"confirm,omitempty"`
+	ConfirmDetails          Rate `json:"confirm_details"`
+	Deliver                 int  `json:"deliver,omitempty"`
+	DeliverDetails          Rate `json:"deliver_details"`
+	DeliverNoAck            int  `json:"deliver_no_ack,omitempty"`
+	DeliverNoAckDetails     Rate `json:"deliver_no_ack_details"`
+	Get                     int  `json:"get,omitempty"`
+	GetDetails              Rate `json:"get_details"`
+	GetNoAck                int  `json:"get_no_ack,omitempty"`
+	GetNoAckDetails         Rate `json:"get_no_ack_details"`
+	DeliverGet              int  `json:"deliver_get,omitempty"`
+	DeliverGetDetails       Rate `json:"deliver_get_details"`
+	Redeliver               int  `json:"redeliver,omitempty"`
+	RedeliverDetails        Rate `json:"redeliver_details"`
+	Ack                     int  `json:"ack,omitempty"`
+	AckDetails              Rate `json:"ack_details"`
+	ReturnUnroutable        int  `json:"return_unroutable,omitempty"`
+	ReturnUnroutableDetails Rate `json:"return_unroutable_details"`
+	DropUnroutable          int  `json:"drop_unroutable,omitempty"`
+	DropUnroutableDetails   Rate `json:"drop_unroutable_details"`
+	DiskReads               int  `json:"disk_reads,omitempty"`
+	DiskWrites              int  `json:"disk_writes,omitempty"`
+}
+
+// Queue is an entry of /api/queues.
+type Queue struct {
+	Name       string                 `json:"name"`
+	VHost      string                 `json:"vhost"`
+	Type       string                 `json:"type"`
+	Durable    bool                   `json:"durable"`
+	AutoDelete bool                   `json:"auto_delete"`
+	Exclusive  bool                   `json:"exclusive"`
+	Arguments  map[string]interface{} `json:"arguments,omitempty"`
+	// State is running, idle, flow, down, terminated, ...; IdleSince is
+	// set while the queue is idle.
+	State     string `json:"state"`
+	IdleSince string `json:"idle_since,omitempty"`
+	Node      string `json:"node"`
+	// Members, Online and Leader are only reported for quorum queues and
+	// streams.
+	Members []string `json:"members,omitempty"`
+	Online  []string `json:"online,omitempty"`
+	Leader  string   `json:"leader,omitempty"`
+	// SlaveNodes and SynchronisedSlaveNodes are the mirrors of a classic
+	// mirrored queue and those of them that are in sync.
+	SlaveNodes             []string `json:"slave_nodes,omitempty"`
+	SynchronisedSlaveNodes []string `json:"synchronised_slave_nodes,omitempty"`
+	// CommittedOffset and Segments are only reported for streams: the
+	// offset of the last message committed to a quorum of members, and how
+	// many segment files the stream is stored in.
+	CommittedOffset *int64 `json:"committed_offset,omitempty"`
+	Segments        int    `json:"segments,omitempty"`
+
+	Policy                    string                 `json:"policy,omitempty"`
+	OperatorPolicy            string                 `json:"operator_policy,omitempty"`
+	EffectivePolicyDefinition map[string]interface{} `json:"effective_policy_definition,omitempty"`
+
+	Messages             int   `json:"messages"`
+	MessagesDetails      Rate  `json:"messages_details"`
+	MessagesReady        int   `json:"messages_ready"`
+	MessagesReadyDetails Rate  `json:"messages_ready_details"`
+	MessagesUnack        int   `json:"messages_unacknowledged"`
+	MessagesUnackDetails Rate  `json:"messages_unacknowledged_details"`
+	MessageBytes         int64 `json:"message_bytes"`
+	Memory               int64 `json:"memory"`
+	Consumers            int   `json:"consumers"`
+	// ConsumerUtilisation is nil when the broker has nothing to report,
+	// e.g. for queues without consumers.
+	ConsumerUtilisation *float64 `json:"consumer_utilisation,omitempty"`
+	// HeadMessageTimestamp is nil unless the head message carries a
+	// timestamp property.
+	HeadMessageTimestamp *int64 `json:"head_message_timestamp,omitempty"`
+
+	MessageStats MessageStats `json:"message_stats"`
+}
+
+// Key identifies a queue across vhosts.
+func (q Queue) Key() string {
+	return q.VHost + "/" + q.Name
+}
+
+// Exchange is an entry of /api/exchanges.
+type Exchange struct {
+	Name         string                 `json:"name"`
+	VHost        string                 `json:"vhost"`
+	Type         string                 `json:"type"`
+	Durable      bool                   `json:"durable"`
+	AutoDelete   bool                   `json:"auto_delete"`
+	Internal     bool                   `json:"internal"`
+	Arguments    map[string]interface{} `json:"arguments,omitempty"`
+	Policy       string                 `json:"policy,omitempty"`
+	MessageStats MessageStats           `json:"message_stats"`
+}
+
+// Key identifies an exchange across vhosts.
+func (e Exchange) Key() string {
+	return e.VHost + "/" + e.Name
+}
+
+// Binding is an entry of /api/bindings. Source is empty for the default
+// exchange.
+type Binding struct {
+	Source          string                 `json:"source"`
+	VHost           string                 `json:"vhost"`
+	Destination     string                 `json:"destination"`
+	DestinationType string                 `json:"destination_type"`
+	RoutingKey      string                 `json:"routing_key"`
+	Arguments       map[string]interface{} `json:"arguments,omitempty"`
+	PropertiesKey   string                 `json:"properties_key"`
+}
+
+// Connection is an entry of /api/connections.
+type Connection struct {
+	Name             string                 `json:"name"`
+	VHost            string                 `json:"vhost"`
+	User             string                 `json:"user"`
+	Node             string                 `json:"node"`
+	State            string                 `json:"state"`
+	Protocol         string                 `json:"protocol"`
+	AuthMechanism    string                 `json:"auth_mechanism"`
+	SSL              bool                   `json:"ssl"`
+	Host             string                 `json:"host"`
+	Port             int                    `json:"port"`
+	PeerHost         string                 `json:"peer_host"`
+	PeerPort         int                    `json:"peer_port"`
+	Channels         int                    `json:"channels"`
+	ChannelMax       int                    `json:"channel_max"`
+	FrameMax         int                    `json:"frame_max"`
+	Timeout          int                    `json:"timeout"`
+	ConnectedAt      int64                  `json:"connected_at"`
+	ClientProperties map[string]interface{} `json:"client_properties,omitempty"`
+	RecvOct          int64                  `json:"recv_oct"`
+	RecvOctDetails   Rate                   `json:"recv_oct_details"`
+	SendOct          int64                  `json:"send_oct"`
+	SendOctDetails   Rate                   `json:"send_oct_details"`
+}
+
+// ConnectionDetails is the summary of the owning connection embedded in
+// channel entries.
+type ConnectionDetails struct {
+	Name     string `json:"name"`
+	PeerHost string `json:"peer_host"`
+	PeerPort int    `json:"peer_port"`
+}
+
+// Channel is an entry of /api/channels.
+type Channel struct {
+	Name                   string            `json:"name"`
+	Number                 int               `json:"number"`
+	VHost                  string            `json:"vhost"`
+	User                   string            `json:"user"`
+	Node                   string            `json:"node"`
+	State                  string            `json:"state"`
+	Consumers              int               `json:"consumer_count"`
+	MessagesUnacknowledged int               `json:"messages_unacknowledged"`
+	MessagesUnconfirmed    int               `json:"messages_unconfirmed"`
+	MessagesUncommitted    int               `json:"messages_uncommitted"`
+	PrefetchCount          int               `json:"prefetch_count"`
+	GlobalPrefetchCount    int               `json:"global_prefetch_count"`
+	Confirm                bool              `json:"confirm"`
+	Transactional          bool              `json:"transactional"`
+	ConnectionDetails      ConnectionDetails `json:"connection_details"`
+	MessageStats           MessageStats      `json:"message_stats"`
+}
+
+// QueueRef names a queue inside another entity.
+type QueueRef struct {
+	Name  string `json:"name"`
+	VHost string `json:"vhost"`
+}
+
+// ChannelDetails is the summary of the owning channel embedded in consumer
+// entries.
+type ChannelDetails struct {
+	Name           string `json:"name"`
+	Number         int    `json:"number"`
+	User           string `json:"user"`
+	Node           string `json:"node"`
+	PeerHost       string `json:"peer_host"`
+	PeerPort       int    `json:"peer_port"`
+	ConnectionName string `json:"connection_name"`
+}
+
+// Consumer is an entry of /api/consumers.
+type Consumer struct {
+	ConsumerTag    string                 `json:"consumer_tag"`
+	Queue          QueueRef               `json:"queue"`
+	ChannelDetails ChannelDetails         `json:"channel_details"`
+	AckRequired    bool                   `json:"ack_required"`
+	Exclusive      bool                   `json:"exclusive"`
+	PrefetchCount  int                    `json:"prefetch_count"`
+	Active         bool                   `json:"active"`
+	ActivityStatus string                 `json:"activity_status"`
+	Arguments      map[string]interface{} `json:"arguments,omitempty"`
+}
+
+// MessageProperties are a message's AMQP basic properties as the management
+// API reports them; properties the publisher did not set are left out.
+type MessageProperties struct {
+	ContentType     string                 `json:"content_type,omitempty"`
+	ContentEncoding string                 `json:"content_encoding,omitempty"`
+	DeliveryMode    int                    `json:"delivery_mode,omitempty"`
+	Priority        int                    `json:"priority,omitempty"`
+	CorrelationID   string                 `json:"correlation_id,omitempty"`
+	ReplyTo         string                 `json:"reply_to,omitempty"`
+	Expiration      string                 `json:"expiration,omitempty"`
+	MessageID       string                 `json:"message_id,omitempty"`
+	Timestamp       int64                  `json:"timestamp,omitempty"`
+	Type            string                 `json:"type,omitempty"`
+	UserID          string                 `json:"user_id,omitempty"`
+	AppID           string                 `json:"app_id,omitempty"`
+	Headers         map[string]interface{} `json:"headers,omitempty"`
+}
+
+// Message is an entry of the response to POST /api/queues/{vhost}/{name}/get.
+// PayloadEncoding is "string" for UTF-8 payloads and "base64" otherwise.
+type Message struct {
+	PayloadBytes    int               `json:"payload_bytes"`
+	Redelivered     bool              `json:"redelivered"`
+	Exchange        string            `json:"exchange"`
+	RoutingKey      string            `json:"routing_key"`
+	MessageCount    int               `json:"message_count"`
+	Properties      MessageProperties `json:"properties"`
+	Payload         string            `json:"payload"`
+	PayloadEncoding string            `json:"payload_encoding"`
+}
+
+// Policy is an entry of /api/policies.
+type Policy struct {
+	Name       string                 `json:"name"`
+	VHost      string                 `json:"vhost"`
+	Pattern    string                 `json:"pattern"`
+	ApplyTo    string                 `json:"apply-to"`
+	Priority   int                    `json:"priority"`
+	Definition map[string]interface{} `json:"definition"`
+}
+
+// Node is an entry of /api/nodes.
+type Node struct {
+	Name          string   `json:"name"`
+	Type          string   `json:"type"`
+	Running       bool     `json:"running"`
+	Uptime        int64    `json:"uptime"`
+	Processors    int      `json:"processors"`
+	RunQueue      int      `json:"run_queue"`
+	FDUsed        int      `json:"fd_used"`
+	FDTotal       int      `json:"fd_total"`
+	SocketsUsed   int      `json:"sockets_used"`
+	SocketsTotal  int      `json:"sockets_total"`
+	ProcUsed      int      `json:"proc_used"`
+	ProcTotal     int      `json:"proc_total"`
+	MemUsed       int64    `json:"mem_used"`
+	MemLimit      int64    `json:"mem_limit"`
+	MemAlarm      bool     `json:"mem_alarm"`
+	DiskFree      int64    `json:"disk_free"`
+	DiskFreeLimit int64    `json:"disk_free_limit"`
+	DiskFreeAlarm bool     `json:"disk_free_alarm"`
+	Partitions    []string `json:"partitions"`
+	// Applications lists the OTP applications running on the node; the
+	// "rabbit" entry carries the node's RabbitMQ version.
+	Applications []Application `json:"applications,omitempty"`
+}
+
+// Application is an OTP application running on a node.
+type Application struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+// FeatureFlag is an entry of /api/feature-flags. State is "enabled",
+// "disabled" or "unavailable".
+type FeatureFlag struct {
+	Name       string `json:"name"`
+	State      string `json:"state"`
+	Stability  string `json:"stability"`
+	Desc       string `json:"desc"`
+	ProvidedBy string `json:"provided_by"`
+}
+
+// ObjectTotals counts the objects in the cluster.
+type ObjectTotals struct {
+	Connections int `json:"connections"`
+	Channels    int `json:"channels"`
+	Exchanges   int `json:"exchanges"`
+	Queues      int `json:"queues"`
+	Consumers   int `json:"consumers"`
+}
+
+// QueueTotals sums message counts over all queues.
+type QueueTotals struct {
+	Messages               int `json:"messages"`
+	MessagesReady          int `json:"messages_ready"`
+	MessagesUnacknowledged int `json:"messages_unacknowledged"`
+}
+
+// Listener is a protocol port a node accepts connections on.
+type Listener struct {
+	Node      string `json:"node"`
+	Protocol  string `json:"protocol"`
+	IPAddress string `json:"ip_address"`
+	Port      int    `json:"port"`
+}
+
+// Overview is the response of /api/overview.
+type Overview struct {
+	ClusterName       string       `json:"cluster_name"`
+	Node              string       `json:"node"`
+	RabbitMQVersion   string       `json:"rabbitmq_version"`
+	ErlangVersion     string       `json:"erlang_version"`
+	ManagementVersion string       `json:"management_version"`
+	ObjectTotals      ObjectTotals `json:"object_totals"`
+	QueueTotals       QueueTotals  `json:"queue_totals"`
+	MessageStats      MessageStats `json:"message_stats"`
+	Listeners         []Listener   `json:"listeners"`
+}
+
+// Shovel is an entry of /api/shovels, reported by the shovel management
+// plugin. Reason is set when the shovel terminated.
+type Shovel struct {
+	Name            string `json:"name"`
+	VHost           string `json:"vhost"`
+	Type            string `json:"type"`
+	State           string `json:"state"`
+	Node            string `json:"node"`
+	Timestamp       string `json:"timestamp"`
+	Reason          string `json:"reason,omitempty"`
+	BlockedStatus   string `json:"blocked_status,omitempty"`
+	SrcURI          string `json:"src_uri"`
+	SrcProtocol     string `json:"src_protocol"`
+	SrcQueue        string `json:"src_queue,omitempty"`
+	SrcExchange     string `json:"src_exchange,omitempty"`
+	SrcExchangeKey  string `json:"src_exchange_key,omitempty"`
+	DestURI         string `json:"dest_uri"`
+	DestProtocol    string `json:"dest_protocol"`
+	DestQueue       string `json:"dest_queue,omitempty"`
+	DestExchange    string `json:"dest_exchange,omitempty"`
+	DestExchangeKey string `json:"dest_exchange_key,omitempty"`
+}
+
+// FederationLink is an entry of /api/federation-links, reported by the
+// federation management plugin. Error is set while Status is "error".
+type FederationLink struct {
+	Node             string `json:"node"`
+	VHost            string `json:"vhost"`
+	ID               string `json:"id"`
+	Type             string `json:"type"`
+	Upstream         string `json:"upstream"`
+	Exchange         string `json:"exchange,omitempty"`
+	UpstreamExchange string `json:"upstream_exchange,omitempty"`
+	Queue            string `json:"queue,omitempty"`
+	UpstreamQueue    string `json:"upstream_queue,omitempty"`
+	Status           string `json:"status"`
+	URI              string `json:"uri"`
+	LocalConnection  string `json:"local_connection,omitempty"`
+	Timestamp        string `json:"timestamp"`
+	Error            string `json:"error,omitempty"`
+}
+
+// VHost is a virtual host, as listed by /api/vhosts and in definitions.
+// Tracing is set while the firehose publishes its traffic to
+// amq.rabbitmq.trace.
+type VHost struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Tracing     bool   `json:"tracing,omitempty"`
+}
+
+// User is an entry of /api/users. Tags is a comma-separated string before
+// RabbitMQ 3.9 and a list since; both decode to a list.
+type User struct {
+	Name string   `json:"name"`
+	Tags UserTags `json:"tags"`
+}
+
+// UserTags are the tags of a user, such as administrator or monitoring.
+type UserTags []string
+
+func (t *UserTags) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*t = list
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*t = nil
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			*t = append(*t, tag)
+		}
+	}
+	return nil
+}
+
+// Permission is an entry of /api/permissions: the patterns of the resource
+// names a user may configure, write to and read from in a vhost.
+type Permission struct {
+	User      string `json:"user"`
+	VHost     string `json:"vhost"`
+	Configure string `json:"configure"`
+	Write     string `json:"write"`
+	Read      string `json:"read"`
+}
+
+// QueueDefinition is a queue as exported in /api/definitions.
+type QueueDefinition struct {
+	Name       string                 `json:"name"`
+	VHost      string                 `json:"vhost"`
+	Durable    bool                   `json:"durable"`
+	AutoDelete bool                   `json:"auto_delete"`
+	Arguments  map[string]interface{} `json:"arguments"`
+}
+
+// ExchangeDefinition is an exchange as exported in /api/definitions.
+type ExchangeDefinition struct {
+	Name       string                 `json:"name"`
+	VHost      string                 `json:"vhost"`
+	Type       string                 `json:"type"`
+	Durable    bool                   `json:"durable"`
+	AutoDelete bool                   `json:"auto_delete"`
+	Internal   bool                   `json:"internal"`
+	Arguments  map[string]interface{} `json:"arguments"`
+}
+
+// Definitions is the topology part of /api/definitions.
+type Definitions struct {
+	RabbitVersion string               `json:"rabbit_version"`
+	VHosts        []VHost              `json:"vhosts"`
+	Queues        []QueueDefinition    `json:"queues"`
+	Exchanges     []ExchangeDefinition `json:"exchanges"`
+	Bindings      []Binding            `json:"bindings"`
+	Policies      []Policy             `json:"policies"`
+}
+
+// StreamConnectionDetails is the summary of the owning stream protocol
+// connection embedded in stream publisher and consumer entries.
+type StreamConnectionDetails struct {
+	Name     string `json:"name"`
+	Node     string `json:"node"`
+	User     string `json:"user"`
+	PeerHost string `json:"peer_host"`
+	PeerPort int    `json:"peer_port"`
+}
+
+// StreamPublisher is an entry of /api/stream/publishers, reported by the
+// stream management plugin.
+type StreamPublisher struct {
+	PublisherID       int                     `json:"publisher_id"`
+	Reference         string                  `json:"reference"`
+	Queue             QueueRef                `json:"queue"`
+	ConnectionDetails StreamConnectionDetails `json:"connection_details"`
+	Published         int64                   `json:"published"`
+	PublishedDetails  Rate                    `json:"published_details"`
+	Confirmed         int64                   `json:"confirmed"`
+	ConfirmedDetails  Rate                    `json:"confirmed_details"`
+	Errored           int64                   `json:"errored"`
+	ErroredDetails    Rate                    `json:"errored_details"`
+}
+
+// StreamConsumer is an entry of /api/stream/consumers. OffsetLag is how
+// far the consumer's offset is behind the end of the stream.
+type StreamConsumer struct {
+	SubscriptionID    int                     `json:"subscription_id"`
+	Queue             QueueRef                `json:"queue"`
+	ConnectionDetails StreamConnectionDetails `json:"connection_details"`
+	Credits           int                     `json:"credits"`
+	Consumed          int64                   `json:"consumed"`
+	ConsumedDetails   Rate                    `json:"consumed_details"`
+	Offset            int64                   `json:"offset"`
+	OffsetLag         int64                   `json:"offset_lag"`
+	Active            bool                    `json:"active"`
+	Properties        map[string]interface{}  `json:"properties,omitempty"`
+}
@@ -0,0 +1,254 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// noticeDuration is how long an action's status message stays in the
+// status bar after it last changed.
+const noticeDuration = 30 * time.Second
+
+// rebalanceWatch is how long leader distribution is reported after a
+// rebalance was requested.
+const rebalanceWatch = 2 * time.Minute
+
+func syncQueue(config Config, q QueueInfo) error {
+	return apiRequest(config, "POST", queuePath(q)+"/actions", map[string]string{"action": "sync"}, nil)
+}
+
+func purgeQueue(config Config, q QueueInfo) error {
+	return apiRequest(config, "DELETE", queuePath(q)+"/contents", nil, nil)
+}
+
+// deleteQueue deletes q. With ifEmpty or ifUnused the broker refuses when
+// the queue has messages or consumers.
+func deleteQueue(config Config, q QueueInfo, ifEmpty, ifUnused bool) error {
+	query := url.Values{}
+	if ifEmpty {
+		query.Set("if-empty", "true")
+	}
+	if ifUnused {
+		query.Set("if-unused", "true")
+	}
+	path := queuePath(q)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return apiRequest(config, "DELETE", path, nil, nil)
+}
+
+// queueDeclaration is the body of PUT /api/queues/{vhost}/{name}.
+type queueDeclaration struct {
+	Durable    bool                   `json:"durable"`
+	AutoDelete bool                   `json:"auto_delete"`
+	Arguments  map[string]interface{} `json:"arguments"`
+}
+
+func declareQueue(config Config, vhost, name string, q queueDeclaration) error {
+	return apiRequest(config, "PUT", queuePath(QueueInfo{VHost: vhost, Name: name}), q, nil)
+}
+
+func rebalanceQueues(config Config) error {
+	return apiRequest(config, "POST", "/api/rebalance/queues", map[string]string{}, nil)
+}
+
+func findQueue(queues []QueueInfo, key string) (QueueInfo, bool) {
+	for _, q := range queues {
+		if q.Key() == key {
+			return q, true
+		}
+	}
+	return QueueInfo{}, false
+}
+
+// setNotice shows a message about an action in the status bar.
+func (d *dashboard) setNotice(format string, args ...interface{}) {
+	d.notice = fmt.Sprintf(format, args...)
+	d.noticeUntil = time.Now().Add(noticeDuration)
+}
+
+// startSync asks the broker to synchronise the mirrors of a classic
+// mirrored queue. Progress is reported by trackActions after each poll.
+func (d *dashboard) startSync(q QueueInfo) {
+	if q.Type != "classic" || len(q.SlaveNodes) == 0 {
+		d.setNotice("%s has no classic mirrors to synchronise", q.Key())
+		return
+	}
+	if err := syncQueue(d.config, q); err != nil {
+		d.setNotice("[Sync of %s failed: %s](fg:red)", q.Key(), err)
+		return
+	}
+	d.syncing[q.Key()] = true
+	d.setNotice("Synchronising %s: %d/%d mirrors", q.Key(), len(q.SynchronisedSlaveNodes), len(q.SlaveNodes))
+}
+
+// startPurge removes the ready messages of a queue. Unacknowledged messages
+// stay until their consumers settle them, so the outcome is checked against
+// the following polls.
+func (d *dashboard) startPurge(q QueueInfo) {
+	if err := purgeQueue(d.config, q); err != nil {
+		d.setNotice("[Purge of %s failed: %s](fg:red)", q.Key(), err)
+		return
+	}
+	d.verifyAfter(expectPurged(q))
+}
+
+// startDelete deletes a queue and checks the following polls for it to be
+// gone.
+func (d *dashboard) startDelete(q QueueInfo, ifEmpty, ifUnused bool) {
+	if err := deleteQueue(d.config, q, ifEmpty, ifUnused); err != nil {
+		d.setNotice("[Delete of %s failed: %s](fg:red)", q.Key(), err)
+		return
+	}
+	d.verifyAfter(expectDeleted(q))
+}
+
+// newQueueForm asks for a queue to declare, with its type, durability and
+// the x-arguments most often needed for test queues and dead-letter queues.
+// Empty argument fields are left out.
+func (d *dashboard) newQueueForm(vhost string) *form {
+	return d.confirmForm(newForm("New queue", []*formField{
+		{label: "Name"},
+		{label: "VHost", input: lineInput{value: vhost}},
+		{label: "Type", choices: []string{"classic", "quorum", "stream"}},
+		{label: "Durable", choices: []string{"yes", "no"}},
+		{label: "Auto-delete", choices: []string{"no", "yes"}},
+		{label: "Message TTL", hint: "ms, optional"},
+		{label: "Max length", hint: "messages, optional"},
+		{label: "Dead-letter exchange", hint: "optional"},
+		{label: "Dead-letter routing key", hint: "optional"},
+	}, d.createQueue), "declare", func(values map[string]string) (string, string) {
+		return values["Name"], fmt.Sprintf("Declare %s queue %s in %s?", values["Type"], values["Name"], values["VHost"])
+	})
+}
+
+// createQueue declares the queue described by a newQueueForm's values.
+func (d *dashboard) createQueue(values map[string]string) error {
+	name, vhost := values["Name"], values["VHost"]
+	if name == "" {
+		return fmt.Errorf("a name is needed")
+	}
+	if vhost == "" {
+		return fmt.Errorf("a vhost is needed")
+	}
+	q := queueDeclaration{
+		Durable:    values["Durable"] == "yes",
+		AutoDelete: values["Auto-delete"] == "yes",
+		Arguments:  map[string]interface{}{"x-queue-type": values["Type"]},
+	}
+	if q.Arguments["x-queue-type"] != "classic" && (!q.Durable || q.AutoDelete) {
+		return fmt.Errorf("%s queues must be durable and cannot auto-delete", values["Type"])
+	}
+	for label, arg := range map[string]string{"Message TTL": "x-message-ttl", "Max length": "x-max-length"} {
+		if values[label] == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(values[label], 10, 64)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%s must be a whole number", strings.ToLower(label))
+		}
+		q.Arguments[arg] = n
+	}
+	if dlx := values["Dead-letter exchange"]; dlx != "" {
+		q.Arguments["x-dead-letter-exchange"] = dlx
+	}
+	if key := values["Dead-letter routing key"]; key != "" {
+		q.Arguments["x-dead-letter-routing-key"] = key
+	}
+	if err := declareQueue(d.config, vhost, name, q); err != nil {
+		return err
+	}
+	d.verifyAfter(expectCreated(vhost, name))
+	return nil
+}
+
+// rebalanceConfirmation asks before rebalancing as the confirmation policy
+// says, or rebalances at once and returns nil.
+func (d *dashboard) rebalanceConfirmation() *typedConfirmation {
+	return d.confirm("rebalance", &typedConfirmation{
+		action:   "Rebalance",
+		name:     d.name,
+		question: fmt.Sprintf("Move quorum queue leaders of %s to spread them evenly over its nodes?", d.name),
+		run:      d.startRebalance,
+	})
+}
+
+// startRebalance asks the broker to spread quorum queue leaders evenly over
+// the cluster nodes.
+func (d *dashboard) startRebalance() {
+	if err := rebalanceQueues(d.config); err != nil {
+		d.setNotice("[Rebalance failed: %s](fg:red)", err)
+		return
+	}
+	d.rebalanceUntil = time.Now().Add(rebalanceWatch)
+	d.setNotice("Rebalance requested. Leaders: %s", leaderCounts(d.queues))
+}
+
+// closeConsumerForm is asked to stop one consumer. The management API
+// lists consumers but cannot cancel them: basic.cancel is only accepted on
+// the channel that owns the consumer, from the client that opened it, and
+// the API cannot close a single channel either. So rather than pretend, it
+// offers to close the consumer's connection, and the notice says what else
+// goes with it.
+func (d *dashboard) closeConsumerForm(c ConsumerInfo) *form {
+	for _, conn := range d.connections {
+		if conn.Name != c.ChannelDetails.ConnectionName {
+			continue
+		}
+		d.setNotice("[The management API cannot cancel consumer %s alone; closing connection %s also closes its %d channels and their consumers](fg:yellow)",
+			c.ConsumerTag, conn.Name, conn.Channels)
+		return d.closeForm(conn)
+	}
+	d.setNotice("[Connection %s of consumer %s is no longer listed](fg:yellow)", c.ChannelDetails.ConnectionName, c.ConsumerTag)
+	return nil
+}
+
+// trackActions refreshes the notice for actions still in progress.
+func (d *dashboard) trackActions() {
+	for key := range d.syncing {
+		q, ok := findQueue(d.queues, key)
+		if !ok {
+			delete(d.syncing, key)
+			continue
+		}
+		synced, total := len(q.SynchronisedSlaveNodes), len(q.SlaveNodes)
+		if synced >= total {
+			d.setNotice("%s synchronised (%d/%d mirrors)", key, synced, total)
+			delete(d.syncing, key)
+		} else {
+			d.setNotice("Synchronising %s: %d/%d mirrors", key, synced, total)
+		}
+	}
+	if time.Now().Before(d.rebalanceUntil) {
+		d.setNotice("Rebalancing quorum leaders: %s", leaderCounts(d.queues))
+	}
+	d.pruneJobs()
+}
+
+// leaderCounts summarises how many quorum queue leaders each node holds.
+func leaderCounts(queues []QueueInfo) string {
+	counts := make(map[string]int)
+	for _, q := range queues {
+		if q.Type == "quorum" && q.Leader != "" {
+			counts[q.Leader]++
+		}
+	}
+	if len(counts) == 0 {
+		return "no quorum queues"
+	}
+	nodes := make([]string, 0, len(counts))
+	for node := range counts {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	parts := make([]string, len(nodes))
+	for i, node := range nodes {
+		parts[i] = fmt.Sprintf("%s=%d", node, counts[node])
+	}
+	return strings.Join(parts, " ")
+}
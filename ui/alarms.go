@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// nodeAlarms describes each memory and disk alarm raised on a node. While
+// any is raised the broker blocks publishing connections cluster-wide.
+func nodeAlarms(nodes []NodeInfo) []string {
+	var alarms []string
+	for _, n := range nodes {
+		if n.MemAlarm {
+			alarms = append(alarms, fmt.Sprintf("%s memory %s of %s limit", n.Name, formatBytes(n.MemUsed), formatBytes(n.MemLimit)))
+		}
+		if n.DiskFreeAlarm {
+			alarms = append(alarms, fmt.Sprintf("%s disk %s free, limit %s", n.Name, formatBytes(n.DiskFree), formatBytes(n.DiskFreeLimit)))
+		}
+	}
+	return alarms
+}
+
+func alarmText(alarms []string) string {
+	return "RESOURCE ALARM, publishers are blocked: " + strings.Join(alarms, " · ")
+}
+
+// nodePartitions describes each node that reports being cut off from
+// others, e.g. "rabbit@a cannot see rabbit@b, rabbit@c".
+func nodePartitions(nodes []NodeInfo) []string {
+	var partitions []string
+	for _, n := range nodes {
+		if len(n.Partitions) > 0 {
+			partitions = append(partitions, fmt.Sprintf("%s cannot see %s", n.Name, strings.Join(n.Partitions, ", ")))
+		}
+	}
+	return partitions
+}
+
+func partitionText(partitions []string) string {
+	return "NETWORK PARTITION, queue figures may be wrong: " + strings.Join(partitions, " · ")
+}
+
+// partitionAlert is raised for as long as any node reports a partition.
+// Nodes keep reporting one until they are restarted, even once the network
+// has healed.
+func partitionAlert(nodes []NodeInfo) (Alert, bool) {
+	partitions := nodePartitions(nodes)
+	if len(partitions) == 0 {
+		return Alert{}, false
+	}
+	return Alert{
+		Key:      "network-partition",
+		Severity: SeverityCritical,
+		Message:  "Network partition: " + strings.Join(partitions, "; "),
+	}, true
+}
+
+// warningBanner is a full-width banner drawn over the top of whatever page
+// is shown while the cluster is partitioned or a node has a resource alarm.
+type warningBanner struct {
+	paragraph *widgets.Paragraph
+}
+
+func newWarningBanner() *warningBanner {
+	p := widgets.NewParagraph()
+	p.BorderStyle = termui.NewStyle(termui.ColorRed, termui.ColorRed)
+	p.WrapText = false
+	return &warningBanner{paragraph: p}
+}
+
+// Render draws the banner when d has partitions, alarms or blocked
+// connections and reports whether it did.
+func (b *warningBanner) Render(d *dashboard) bool {
+	width, _ := termui.TerminalDimensions()
+	// Pad each line so its background spans the whole banner.
+	line := func(text, style string) string {
+		if pad := width - 2 - len([]rune(text)); pad > 0 {
+			text += strings.Repeat(" ", pad)
+		}
+		return fmt.Sprintf("[%s](%s)", text, style)
+	}
+	var lines []string
+	if partitions := nodePartitions(d.nodes); len(partitions) > 0 {
+		lines = append(lines, line(partitionText(partitions), "fg:black,bg:yellow,mod:bold"))
+	}
+	if alarms := nodeAlarms(d.nodes); len(alarms) > 0 {
+		lines = append(lines, line(alarmText(alarms), "fg:white,bg:red,mod:bold"))
+	}
+	if text := blockedText(d); text != "" {
+		lines = append(lines, line(text, "fg:white,bg:red,mod:bold"))
+	}
+	if len(lines) == 0 {
+		return false
+	}
+	b.paragraph.Text = strings.Join(lines, "\n")
+	b.paragraph.SetRect(0, 0, width, 2+len(lines))
+	drawWidgets(b.paragraph)
+	return true
+}
@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+type AlertSeverity string
+
+const (
+	SeverityWarning  AlertSeverity = "warning"
+	SeverityCritical AlertSeverity = "critical"
+)
+
+type Alert struct {
+	Key      string
+	Severity AlertSeverity
+	Message  string
+	Since    time.Time
+	// VHost, Queue, Value and Threshold are set for alerts about one
+	// queue's metric, and Runbook when the rule that raised it links one.
+	VHost     string
+	Queue     string
+	Value     float64
+	Threshold float64
+	Runbook   string
+	// Downstream lists the queues whose alerts were folded into this one
+	// because they depend on its queue.
+	Downstream []string
+}
+
+// Notifier delivers alerts somewhere outside the table: a sound, a chat
+// message, a pager.
+type Notifier interface {
+	Notify(alert Alert) error
+}
+
+type soundNotifier struct{}
+
+func (soundNotifier) Notify(alert Alert) error {
+	go playAlertSound()
+	return nil
+}
+
+// notifyBacklog is how many alerts may wait for a slow notifier before
+// newer ones are dropped.
+const notifyBacklog = 64
+
+// queuedNotifier hands alerts to a notifier on its own goroutine, so a
+// webhook that is slow or unreachable holds up neither the poll that raised
+// them, which runs on the UI goroutine, nor the other notifiers.
+type queuedNotifier struct {
+	notifier Notifier
+	alerts   chan Alert
+}
+
+func newQueuedNotifier(n Notifier) *queuedNotifier {
+	q := &queuedNotifier{notifier: n, alerts: make(chan Alert, notifyBacklog)}
+	go func() {
+		for alert := range q.alerts {
+			if err := q.notifier.Notify(alert); err != nil {
+				log.Printf("Notifier failed for %s: %s", alert.Key, err)
+			}
+		}
+	}()
+	return q
+}
+
+// alertManager tracks which alerts are active between polls and routes them
+// through the configured notifiers. An alert is dispatched when it is first
+// raised and again every alertCooldown while it stays active.
+type alertManager struct {
+	notifiers    []*queuedNotifier
+	active       map[string]Alert
+	lastNotified map[string]time.Time
+}
+
+func newAlertManager(notifiers ...Notifier) *alertManager {
+	m := &alertManager{
+		active:       make(map[string]Alert),
+		lastNotified: make(map[string]time.Time),
+	}
+	for _, n := range notifiers {
+		m.notifiers = append(m.notifiers, newQueuedNotifier(n))
+	}
+	return m
+}
+
+// Update replaces the active set with current and returns it ordered by
+// severity, critical first.
+func (m *alertManager) Update(current []Alert) []Alert {
+	now := time.Now()
+	next := make(map[string]Alert, len(current))
+	for _, alert := range current {
+		if prev, ok := m.active[alert.Key]; ok {
+			alert.Since = prev.Since
+		} else if alert.Since.IsZero() {
+			alert.Since = now
+		}
+		next[alert.Key] = alert
+
+		if now.Sub(m.lastNotified[alert.Key]) >= alertCooldown {
+			m.dispatch(alert)
+			m.lastNotified[alert.Key] = now
+		}
+	}
+	for key := range m.lastNotified {
+		if _, ok := next[key]; !ok {
+			delete(m.lastNotified, key)
+		}
+	}
+	m.active = next
+
+	active := make([]Alert, 0, len(next))
+	for _, alert := range next {
+		active = append(active, alert)
+	}
+	sort.Slice(active, func(i, j int) bool {
+		if active[i].Severity != active[j].Severity {
+			return active[i].Severity == SeverityCritical
+		}
+		return active[i].Key < active[j].Key
+	})
+	return active
+}
+
+// Close stops the notifiers' goroutines once they have delivered what is
+// queued. Alerts raised after it are not dispatched.
+func (m *alertManager) Close() {
+	for _, n := range m.notifiers {
+		close(n.alerts)
+	}
+	m.notifiers = nil
+}
+
+// dispatch queues alert for every notifier without waiting for any.
+func (m *alertManager) dispatch(alert Alert) {
+	for _, n := range m.notifiers {
+		select {
+		case n.alerts <- alert:
+		default:
+			log.Printf("Notifier backlog full, dropping %s", alert.Key)
+		}
+	}
+}
+
+func renderAlerts(widget *widgets.Paragraph, alerts []Alert) {
+	if len(alerts) == 0 {
+		widget.Text = "No error queues detected."
+		widget.TextStyle = termui.NewStyle(termui.ColorGreen)
+		return
+	}
+
+	top := alerts[0]
+	label := "ALERT"
+	if top.Severity == SeverityCritical {
+		label = "CRITICAL"
+	}
+	widget.Text = fmt.Sprintf("%s: %s", label, top.Message)
+	if len(alerts) > 1 {
+		widget.Text += fmt.Sprintf(" (+%d more)", len(alerts)-1)
+	}
+	widget.TextStyle = termui.NewStyle(termui.ColorRed, termui.ColorClear, termui.ModifierBold)
+}
@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func amqpURI(config Config) string {
+	return fmt.Sprintf("amqp://%s:%s@%s:%s/", config.RabbitMQ.Username, config.RabbitMQ.Password, config.RabbitMQ.Host, config.RabbitMQ.Port)
+}
+
+// amqpConnector owns the AMQP connection to the broker and, when asked to,
+// redials it whenever the broker drops it.
+type amqpConnector struct {
+	uri string
+
+	mu   sync.Mutex
+	conn *amqp.Connection
+	// closed is closed by Close, which stops KeepConnected redialling.
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newAMQPConnector(config Config) *amqpConnector {
+	return &amqpConnector{uri: amqpURI(config), closed: make(chan struct{})}
+}
+
+func (c *amqpConnector) Dial() error {
+	conn, err := amqp.Dial(c.uri)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.closed:
+		conn.Close()
+		return errors.New("AMQP connector is closed")
+	default:
+	}
+	c.conn = conn
+	return nil
+}
+
+// Connection returns the current connection, or nil while disconnected.
+func (c *amqpConnector) Connection() *amqp.Connection {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil || c.conn.IsClosed() {
+		return nil
+	}
+	return c.conn
+}
+
+// KeepConnected dials until it succeeds and redials after every disconnect,
+// until ctx is done or the connector is closed.
+func (c *amqpConnector) KeepConnected(ctx context.Context, retry time.Duration) {
+	wait := func() bool {
+		timer := time.NewTimer(retry)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return true
+		case <-ctx.Done():
+		case <-c.closed:
+		}
+		return false
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.closed:
+			return
+		default:
+		}
+		conn := c.Connection()
+		if conn == nil {
+			if err := c.Dial(); err != nil {
+				log.Printf("AMQP connection failed, retrying in %s: %s", retry, err)
+				if !wait() {
+					return
+				}
+			}
+			continue
+		}
+		select {
+		case err := <-conn.NotifyClose(make(chan *amqp.Error, 1)):
+			if err != nil {
+				log.Printf("AMQP connection closed: %s", err)
+			}
+		case <-ctx.Done():
+			return
+		case <-c.closed:
+			return
+		}
+		if !wait() {
+			return
+		}
+	}
+}
+
+// Close closes the connection and stops KeepConnected from dialling again.
+func (c *amqpConnector) Close() {
+	c.closeOnce.Do(func() { close(c.closed) })
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil && !c.conn.IsClosed() {
+		c.conn.Close()
+	}
+}
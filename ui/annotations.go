@@ -0,0 +1,184 @@
+package ui
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"image"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// annotationLimit caps how many events are kept per cluster.
+const annotationLimit = 200
+
+// annotation marks an event such as a deployment or an incident, so that
+// metric shifts can be matched to what changed.
+type annotation struct {
+	at     time.Time
+	text   string
+	source string
+}
+
+// annotationLog holds a cluster's annotations, oldest first.
+type annotationLog struct {
+	events []annotation
+}
+
+func (l *annotationLog) Add(a annotation) {
+	i := sort.Search(len(l.events), func(i int) bool { return l.events[i].at.After(a.at) })
+	l.events = append(l.events, annotation{})
+	copy(l.events[i+1:], l.events[i:])
+	l.events[i] = a
+	if len(l.events) > annotationLimit {
+		l.events = l.events[len(l.events)-annotationLimit:]
+	}
+}
+
+// Between returns the annotations made from from up to and including to.
+func (l *annotationLog) Between(from, to time.Time) []annotation {
+	var events []annotation
+	for _, a := range l.events {
+		if !a.at.Before(from) && !a.at.After(to) {
+			events = append(events, a)
+		}
+	}
+	return events
+}
+
+func (d *dashboard) annotate(text, source string) {
+	d.annotations.Add(annotation{at: time.Now(), text: text, source: source})
+	d.setNotice("Annotated: %s", escapeMarkup(text))
+}
+
+// escapeMarkup keeps text typed or posted by anyone from being read as
+// termui's [text](style) markup, which has no escape of its own, by turning
+// its square brackets into parentheses.
+func escapeMarkup(text string) string {
+	return strings.NewReplacer("[", "(", "]", ")").Replace(text)
+}
+
+// clusterAnnotation is an annotation received by the webhook. An empty
+// cluster applies it to every cluster.
+type clusterAnnotation struct {
+	cluster string
+	annotation
+}
+
+type annotationRequest struct {
+	Cluster string    `json:"cluster"`
+	Text    string    `json:"text"`
+	Source  string    `json:"source"`
+	At      time.Time `json:"at"`
+}
+
+// annotationHandler accepts POSTed annotations and hands them to the main
+// loop on out, which owns the dashboards. When token is set, requests have
+// to send it as a bearer token.
+func annotationHandler(clusters []string, token string, out chan<- clusterAnnotation) http.Handler {
+	known := make(map[string]bool, len(clusters))
+	for _, name := range clusters {
+		known[name] = true
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "use POST", http.StatusMethodNotAllowed)
+			return
+		}
+		if token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "a valid bearer token is required", http.StatusUnauthorized)
+			return
+		}
+		var req annotationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Text = strings.TrimSpace(req.Text)
+		if req.Text == "" {
+			http.Error(w, "text is required", http.StatusBadRequest)
+			return
+		}
+		if req.Cluster != "" && !known[req.Cluster] {
+			http.Error(w, fmt.Sprintf("unknown cluster %q", req.Cluster), http.StatusNotFound)
+			return
+		}
+		if req.Source == "" {
+			req.Source = "webhook"
+		}
+		if req.At.IsZero() {
+			req.At = time.Now()
+		}
+		out <- clusterAnnotation{cluster: req.Cluster, annotation: annotation{at: req.At, text: req.Text, source: req.Source}}
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// annotationLabel is the marker drawn at the top of the n-th (zero-based)
+// annotation line and next to it in the timeline.
+func annotationLabel(n int) string {
+	if n < 9 {
+		return fmt.Sprint(n + 1)
+	}
+	return "+"
+}
+
+// annotationColumns places each annotation at the first plotted sample
+// taken at or after it. Samples are the ones plotted, oldest first.
+func annotationColumns(events []annotation, samples []queueSample) []int {
+	columns := make([]int, len(events))
+	for i, a := range events {
+		columns[i] = sort.Search(len(samples), func(j int) bool { return !samples[j].at.Before(a.at) })
+		if columns[i] == len(samples) {
+			columns[i] = len(samples) - 1
+		}
+	}
+	return columns
+}
+
+// annotatedPlot is a line plot with a vertical line at each of marks, the
+// data point offsets of annotations. The lines only fill blank cells so the
+// series stay readable.
+type annotatedPlot struct {
+	*widgets.Plot
+	marks []int
+}
+
+// plotDataOffset is where termui starts drawing plot data inside the border,
+// after the y axis labels and the axis itself.
+const plotDataOffset = 5
+
+func (p *annotatedPlot) Draw(buf *termui.Buffer) {
+	p.Plot.Draw(buf)
+	line := termui.NewStyle(termui.ColorMagenta)
+	label := termui.NewStyle(termui.ColorMagenta, termui.ColorClear, termui.ModifierBold)
+	top, bottom := p.Inner.Min.Y, p.Inner.Max.Y-2
+	for i, offset := range p.marks {
+		x := p.Inner.Min.X + plotDataOffset + offset
+		if x >= p.Inner.Max.X {
+			continue
+		}
+		buf.SetString(annotationLabel(i), label, image.Pt(x, top))
+		for y := top + 1; y < bottom; y++ {
+			if buf.GetCell(image.Pt(x, y)).Rune == ' ' {
+				buf.SetCell(termui.NewCell('┆', line), image.Pt(x, y))
+			}
+		}
+	}
+}
+
+// annotationTimeline lists events under a graph, newest last.
+func annotationTimeline(events []annotation) string {
+	lines := make([]string, len(events))
+	for i, a := range events {
+		lines[i] = fmt.Sprintf("[%s](fg:magenta,mod:bold) %s %s [(%s)](fg:white)", annotationLabel(i), a.at.Format("15:04:05"), escapeMarkup(a.text), escapeMarkup(a.source))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,327 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/genc-murat/rabbitspy/management"
+)
+
+// The management API types live in the management package so other tools
+// can decode the same payloads; these names are what the rest of this
+// package uses.
+type (
+	Rate            = management.Rate
+	QueueInfo       = management.Queue
+	ExchangeInfo    = management.Exchange
+	BindingInfo     = management.Binding
+	ConnectionInfo  = management.Connection
+	ChannelInfo     = management.Channel
+	ConsumerInfo    = management.Consumer
+	MessageInfo     = management.Message
+	PolicyInfo      = management.Policy
+	NodeInfo        = management.Node
+	Overview        = management.Overview
+	ShovelInfo      = management.Shovel
+	FederationLink  = management.FederationLink
+	FeatureFlag     = management.FeatureFlag
+	Definitions     = management.Definitions
+	VHostInfo       = management.VHost
+	UserInfo        = management.User
+	PermissionInfo  = management.Permission
+	StreamPublisher = management.StreamPublisher
+	StreamConsumer  = management.StreamConsumer
+)
+
+// apiTimeout bounds every management API request, so one endpoint that
+// hangs can't hold up a poll indefinitely.
+const apiTimeout = 10 * time.Second
+
+// errNotFound matches errors for endpoints the broker does not serve,
+// usually because the plugin providing them is not enabled.
+var errNotFound = errors.New("not found")
+
+// apiError is a non-2xx response from the management API, with the error
+// and reason fields of its JSON body when there is one.
+type apiError struct {
+	Status int
+	Code   string
+	Reason string
+}
+
+func (e *apiError) Error() string {
+	msg := fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status))
+	switch {
+	case e.Status == http.StatusUnauthorized:
+		msg += " — check username/password or user tags"
+	case e.Status == http.StatusForbidden:
+		msg += " — the user needs the monitoring or management tag and access to the vhost"
+	case e.Status >= 500:
+		msg += " — the management API is failing on the broker side"
+	}
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+func (e *apiError) Is(target error) bool {
+	return target == errNotFound && e.Status == http.StatusNotFound
+}
+
+// isAuthError reports whether err means the configured credentials were
+// rejected, which retrying will not fix.
+func isAuthError(err error) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && (apiErr.Status == http.StatusUnauthorized || apiErr.Status == http.StatusForbidden)
+}
+
+func getJSON(config Config, path string, v interface{}) error {
+	return apiRequest(config, "GET", path, nil, v)
+}
+
+// apiRequest sends body, if not nil, as JSON and decodes the response into
+// v, if not nil.
+func apiRequest(config Config, method, path string, body, v interface{}) error {
+	return apiRequestHeader(config, method, path, nil, body, v)
+}
+
+// apiRequestHeader is apiRequest with extra request headers, such as the
+// X-Reason of a connection close. Requests that change the broker are
+// refused while read-only, and written to the audit log.
+func apiRequestHeader(config Config, method, path string, header http.Header, body, v interface{}) error {
+	if !changesBroker(method, path) {
+		return sendAPIRequest(config, method, path, header, body, v)
+	}
+	if config.ReadOnly {
+		return errReadOnly
+	}
+	action, target, detail := auditAction(method, path)
+	if reason := header.Get("X-Reason"); reason != "" {
+		detail = "reason: " + reason
+	}
+	return audited(config, action, target, detail, func() error {
+		return sendAPIRequest(config, method, path, header, body, v)
+	})
+}
+
+func sendAPIRequest(config Config, method, path string, header http.Header, body, v interface{}) error {
+	endpoint := fmt.Sprintf("http://%s:%s%s", config.RabbitMQ.Host, config.RabbitMQ.ManagementPort, path)
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, endpoint, reqBody)
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.SetBasicAuth(config.RabbitMQ.Username, config.RabbitMQ.Password)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := &http.Client{Timeout: apiTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &apiError{Status: resp.StatusCode}
+		var detail struct {
+			Error  string `json:"error"`
+			Reason string `json:"reason"`
+		}
+		if json.Unmarshal(respBody, &detail) == nil {
+			apiErr.Code, apiErr.Reason = detail.Error, detail.Reason
+		}
+		return apiErr
+	}
+
+	if v == nil || len(respBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, v)
+}
+
+// queuePath is the API path of a queue, with the vhost and name escaped.
+func queuePath(q QueueInfo) string {
+	return "/api/queues/" + url.PathEscape(q.VHost) + "/" + url.PathEscape(q.Name)
+}
+
+// inVHost narrows a listing such as /api/queues to the configured vhost,
+// if any.
+func inVHost(config Config, path string) string {
+	if config.RabbitMQ.VHost == "" {
+		return path
+	}
+	return path + "/" + url.PathEscape(config.RabbitMQ.VHost)
+}
+
+// vhostScoped is the path of the connections or channels of the configured
+// vhost, if any, which the API lists under the vhost rather than after it.
+func vhostScoped(config Config, listing string) string {
+	if config.RabbitMQ.VHost == "" {
+		return "/api/" + listing
+	}
+	return "/api/vhosts/" + url.PathEscape(config.RabbitMQ.VHost) + "/" + listing
+}
+
+func getQueues(config Config) ([]QueueInfo, error) {
+	var queues []QueueInfo
+	if err := getJSON(config, inVHost(config, "/api/queues"), &queues); err != nil {
+		return nil, err
+	}
+	return queues, nil
+}
+
+func getOverview(config Config) (Overview, error) {
+	var overview Overview
+	err := getJSON(config, "/api/overview", &overview)
+	return overview, err
+}
+
+func getVHosts(config Config) ([]VHostInfo, error) {
+	var vhosts []VHostInfo
+	if err := getJSON(config, "/api/vhosts", &vhosts); err != nil {
+		return nil, err
+	}
+	return vhosts, nil
+}
+
+func getExchanges(config Config) ([]ExchangeInfo, error) {
+	var exchanges []ExchangeInfo
+	if err := getJSON(config, inVHost(config, "/api/exchanges"), &exchanges); err != nil {
+		return nil, err
+	}
+	return exchanges, nil
+}
+
+func getBindings(config Config) ([]BindingInfo, error) {
+	var bindings []BindingInfo
+	if err := getJSON(config, inVHost(config, "/api/bindings"), &bindings); err != nil {
+		return nil, err
+	}
+	return bindings, nil
+}
+
+func getConnections(config Config) ([]ConnectionInfo, error) {
+	var connections []ConnectionInfo
+	if err := getJSON(config, vhostScoped(config, "connections"), &connections); err != nil {
+		return nil, err
+	}
+	return connections, nil
+}
+
+func getChannels(config Config) ([]ChannelInfo, error) {
+	var channels []ChannelInfo
+	if err := getJSON(config, vhostScoped(config, "channels"), &channels); err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+func getConsumers(config Config) ([]ConsumerInfo, error) {
+	var consumers []ConsumerInfo
+	if err := getJSON(config, inVHost(config, "/api/consumers"), &consumers); err != nil {
+		return nil, err
+	}
+	return consumers, nil
+}
+
+func getPolicies(config Config) ([]PolicyInfo, error) {
+	var policies []PolicyInfo
+	if err := getJSON(config, inVHost(config, "/api/policies"), &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+func getShovels(config Config) ([]ShovelInfo, error) {
+	var shovels []ShovelInfo
+	if err := getJSON(config, inVHost(config, "/api/shovels"), &shovels); err != nil {
+		return nil, err
+	}
+	return shovels, nil
+}
+
+func getFederationLinks(config Config) ([]FederationLink, error) {
+	var links []FederationLink
+	if err := getJSON(config, inVHost(config, "/api/federation-links"), &links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func getFeatureFlags(config Config) ([]FeatureFlag, error) {
+	var flags []FeatureFlag
+	if err := getJSON(config, "/api/feature-flags", &flags); err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+func getDefinitions(config Config) (Definitions, error) {
+	var defs Definitions
+	err := getJSON(config, "/api/definitions", &defs)
+	return defs, err
+}
+
+func getStreamPublishers(config Config) ([]StreamPublisher, error) {
+	var publishers []StreamPublisher
+	if err := getJSON(config, "/api/stream/publishers", &publishers); err != nil {
+		return nil, err
+	}
+	return publishers, nil
+}
+
+func getStreamConsumers(config Config) ([]StreamConsumer, error) {
+	var consumers []StreamConsumer
+	if err := getJSON(config, "/api/stream/consumers", &consumers); err != nil {
+		return nil, err
+	}
+	return consumers, nil
+}
+
+func getNodes(config Config) ([]NodeInfo, error) {
+	var nodes []NodeInfo
+	if err := getJSON(config, "/api/nodes", &nodes); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+func getUsers(config Config) ([]UserInfo, error) {
+	var users []UserInfo
+	if err := getJSON(config, "/api/users", &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func getPermissions(config Config) ([]PermissionInfo, error) {
+	var permissions []PermissionInfo
+	if err := getJSON(config, "/api/permissions", &permissions); err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
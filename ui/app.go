@@ -0,0 +1,729 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/speaker"
+)
+
+type RabbitMQConfig struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// PasswordFile, when set, is read for the password instead.
+	PasswordFile   string `json:"password_file"`
+	Host           string `json:"host"`
+	Port           string `json:"port"`
+	ManagementPort string `json:"management_port"`
+	// PrometheusPort is the rabbitmq_prometheus plugin's port, for the
+	// global counters page; that page is off when it is empty.
+	PrometheusPort string `json:"prometheus_port"`
+	// VHost, when set, limits what is polled to one vhost.
+	VHost string `json:"vhost"`
+}
+
+type ClusterConfig struct {
+	Name      string            `json:"name"`
+	Variables map[string]string `json:"variables"`
+	// Confirm, when set, replaces the top-level confirmation policy for
+	// this cluster, such as a strict one for production.
+	Confirm *ConfirmConfig `json:"confirm"`
+	RabbitMQConfig
+}
+
+type WallboardSlide struct {
+	Cluster string `json:"cluster"`
+	Page    string `json:"page"`
+	Seconds int    `json:"seconds"`
+}
+
+type Config struct {
+	RabbitMQ RabbitMQConfig  `json:"rabbitmq"`
+	Clusters []ClusterConfig `json:"clusters"`
+	Alerts   struct {
+		APIDownSeconds int               `json:"api_down_seconds"`
+		RulesFile      string            `json:"rules_file"`
+		Variables      map[string]string `json:"variables"`
+		Baselines      BaselineConfig    `json:"baselines"`
+		Dependencies   []QueueDependency `json:"dependencies"`
+	} `json:"alerts"`
+	Bindings struct {
+		UnusedWindowSeconds int `json:"unused_window_seconds"`
+	} `json:"bindings"`
+	UI struct {
+		TopN           int      `json:"top_n"`
+		RefreshSeconds int      `json:"refresh_seconds"`
+		Columns        []string `json:"columns"`
+		FrozenColumns  int      `json:"frozen_columns"`
+		Sort           []string `json:"sort"`
+	} `json:"ui"`
+	Nodes struct {
+		TrendWindowSeconds       int `json:"trend_window_seconds"`
+		ExhaustionHorizonSeconds int `json:"exhaustion_horizon_seconds"`
+	} `json:"nodes"`
+	SlowConsumers SlowConsumerConfig `json:"slow_consumers"`
+	Sampling      SamplingConfig     `json:"sampling"`
+	Browser       BrowserConfig      `json:"browser"`
+	Requeue       RequeueConfig      `json:"requeue"`
+	Reprocess     ReprocessConfig    `json:"reprocess"`
+	Snooze        SnoozeConfig       `json:"snooze"`
+	Quotas        QuotaConfig        `json:"quotas"`
+	Tiers         []TierConfig       `json:"tiers"`
+	Privacy       struct {
+		MaskPaths []string `json:"mask_paths"`
+	} `json:"privacy"`
+	Wallboard struct {
+		PageSeconds int              `json:"page_seconds"`
+		Rotation    []WallboardSlide `json:"rotation"`
+	} `json:"wallboard"`
+	Annotations struct {
+		Listen string `json:"listen"`
+		// Token, when set, has to be sent as a bearer token with every
+		// annotation posted.
+		Token string `json:"token"`
+	} `json:"annotations"`
+	Notifiers []WebhookConfig `json:"notifiers"`
+	Links     struct {
+		Grafana string `json:"grafana"`
+	} `json:"links"`
+	Clipboard ClipboardConfig `json:"clipboard"`
+	// ReadOnly disables every action that changes the broker, such as
+	// purges, deletes, closing connections and publishing.
+	ReadOnly bool          `json:"read_only"`
+	Confirm  ConfirmConfig `json:"confirm"`
+	Audit    AuditConfig   `json:"audit"`
+
+	// cluster is the name of the cluster forCluster narrowed the config
+	// to, for the audit log.
+	cluster string
+}
+
+// ClusterConfigs lists the configured clusters, falling back to the
+// top-level rabbitmq section when no clusters are given.
+func (c Config) ClusterConfigs() []ClusterConfig {
+	if len(c.Clusters) == 0 {
+		return []ClusterConfig{{Name: c.RabbitMQ.Host, RabbitMQConfig: c.RabbitMQ}}
+	}
+	clusters := make([]ClusterConfig, len(c.Clusters))
+	for i, cluster := range c.Clusters {
+		if cluster.Name == "" {
+			cluster.Name = cluster.Host
+		}
+		clusters[i] = cluster
+	}
+	return clusters
+}
+
+// queueSort is the configured queue order. LoadConfig has already rejected
+// unknown fields.
+func (c Config) queueSort() []sortKey {
+	keys, _ := parseSortKeys(c.UI.Sort)
+	return keys
+}
+
+// forCluster returns a copy of the config that talks to the given cluster.
+func (c Config) forCluster(cluster ClusterConfig) Config {
+	c.RabbitMQ = cluster.RabbitMQConfig
+	c.cluster = cluster.Name
+	if cluster.Confirm != nil {
+		c.Confirm = *cluster.Confirm
+	}
+	return c
+}
+
+// pickCluster narrows config to the named cluster, or to the only one when
+// no name is given, for commands that act on a single cluster.
+func pickCluster(config Config, name string) (Config, error) {
+	clusters := config.ClusterConfigs()
+	for _, c := range clusters {
+		if name == "" && len(clusters) == 1 || c.Name == name {
+			return config.forCluster(c), nil
+		}
+	}
+	names := make([]string, len(clusters))
+	for i, c := range clusters {
+		names[i] = c.Name
+	}
+	return config, fmt.Errorf("pick a cluster with --cluster (known: %s)", strings.Join(names, ", "))
+}
+
+var (
+	lastAlertTime time.Time
+	alertCooldown = 1 * time.Minute
+
+	defaultAPIDownSeconds      = 30
+	defaultUnusedWindowSeconds = 600
+	defaultWallboardPageSecs   = 10
+	defaultTopN                = 10
+	defaultRefreshSeconds      = 5
+	defaultFrozenColumns       = 1
+	defaultTrendWindowSeconds  = 900
+	defaultExhaustionHorizon   = 7200
+
+	refreshSteps = []time.Duration{
+		1 * time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second,
+		15 * time.Second, 30 * time.Second, 60 * time.Second,
+	}
+)
+
+// LoadConfig reads a configuration file, fills in defaults and validates
+// it. Files ending in .yaml, .yml or .toml are read as YAML or TOML, others
+// as JSON.
+func LoadConfig(filename string) (Config, error) {
+	configFile, err := os.ReadFile(filename)
+	if err != nil {
+		return Config{}, err
+	}
+	data, err := configJSON(filename, configFile)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", filename, err)
+	}
+	return parseConfig(data)
+}
+
+// LoadConfigEnv loads the configuration the way the rabbitspy command does:
+// from the JSON in $RABBITSPY_CONFIG when set, so a container can be
+// configured through its environment alone, else from the file named by
+// $RABBITSPY_CONFIG_FILE, such as a mounted secret, else from filename, or
+// when that is empty from the first file configPaths finds.
+func LoadConfigEnv(filename string) (Config, error) {
+	if text := os.Getenv("RABBITSPY_CONFIG"); text != "" {
+		config, err := parseConfig([]byte(text))
+		if err != nil {
+			return config, fmt.Errorf("RABBITSPY_CONFIG: %w", err)
+		}
+		return config, nil
+	}
+	if file := os.Getenv("RABBITSPY_CONFIG_FILE"); file != "" {
+		filename = file
+	}
+	if filename != "" {
+		return LoadConfig(filename)
+	}
+	paths := configPaths()
+	for _, path := range paths {
+		config, err := LoadConfig(path)
+		if !errors.Is(err, fs.ErrNotExist) {
+			return config, err
+		}
+	}
+	return Config{}, fmt.Errorf("no configuration in %s: %w", strings.Join(paths, ", "), fs.ErrNotExist)
+}
+
+// configPaths are where the configuration is looked for when no file is
+// named, in order: rabbitspy/config.json in $XDG_CONFIG_HOME, or ~/.config
+// when it is not set, then config.json in the current directory. In each,
+// config.yaml, config.yml and config.toml are looked for after config.json.
+func configPaths() []string {
+	var dirs []string
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, ".config")
+		}
+	}
+	if dir != "" {
+		dirs = append(dirs, filepath.Join(dir, "rabbitspy"))
+	}
+	var paths []string
+	for _, dir := range append(dirs, "") {
+		for _, ext := range configExtensions {
+			paths = append(paths, filepath.Join(dir, "config"+ext))
+		}
+	}
+	return paths
+}
+
+// Overrides are settings given on the command line or in the environment,
+// which take precedence over the configuration. Empty fields leave it as it
+// is.
+type Overrides struct {
+	// Host replaces the configured clusters with the one broker.
+	Host           string
+	Port           string
+	ManagementPort string
+	Username       string
+	Password       string
+	VHost          string
+	RefreshSeconds int
+}
+
+// The ports a broker listens on out of the box, used when none is
+// configured or given.
+const (
+	defaultAMQPPort       = "5672"
+	defaultManagementPort = "15672"
+)
+
+// envOverrides are the settings given as environment variables, so
+// credentials can be injected by CI, Kubernetes or direnv. RABBITSPY_*
+// variables come first, then the RABBITMQ_* ones other RabbitMQ tools and
+// the broker's Docker image use.
+func envOverrides() (Overrides, error) {
+	lookup := func(names ...string) string {
+		for _, name := range names {
+			if value := os.Getenv(name); value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+	o := Overrides{
+		Host:           lookup("RABBITSPY_HOST", "RABBITMQ_HOST"),
+		Port:           lookup("RABBITSPY_PORT", "RABBITMQ_PORT", "RABBITMQ_NODE_PORT"),
+		ManagementPort: lookup("RABBITSPY_MANAGEMENT_PORT", "RABBITMQ_MANAGEMENT_PORT"),
+		Username:       lookup("RABBITSPY_USERNAME", "RABBITMQ_USERNAME", "RABBITMQ_DEFAULT_USER"),
+		Password:       lookup("RABBITSPY_PASSWORD", "RABBITMQ_PASSWORD", "RABBITMQ_DEFAULT_PASS"),
+		VHost:          lookup("RABBITSPY_VHOST", "RABBITMQ_VHOST", "RABBITMQ_DEFAULT_VHOST"),
+	}
+	if refresh := os.Getenv("RABBITSPY_REFRESH_SECONDS"); refresh != "" {
+		seconds, err := strconv.Atoi(refresh)
+		if err != nil || seconds <= 0 {
+			return o, fmt.Errorf("RABBITSPY_REFRESH_SECONDS must be a positive number of seconds, got %q", refresh)
+		}
+		o.RefreshSeconds = seconds
+	}
+	return o, nil
+}
+
+// or fills in the settings o leaves empty from fallback.
+func (o Overrides) or(fallback Overrides) Overrides {
+	if o.Host == "" {
+		o.Host = fallback.Host
+	}
+	if o.Port == "" {
+		o.Port = fallback.Port
+	}
+	if o.ManagementPort == "" {
+		o.ManagementPort = fallback.ManagementPort
+	}
+	if o.Username == "" {
+		o.Username = fallback.Username
+	}
+	if o.Password == "" {
+		o.Password = fallback.Password
+	}
+	if o.VHost == "" {
+		o.VHost = fallback.VHost
+	}
+	if o.RefreshSeconds <= 0 {
+		o.RefreshSeconds = fallback.RefreshSeconds
+	}
+	return o
+}
+
+// LoadConfigOverrides loads the configuration as LoadConfigEnv does and
+// applies o to it, then the settings o leaves empty that the environment
+// gives. When either names a host, a missing configuration file is not an
+// error and the defaults are used, so rabbitspy can be pointed at a broker
+// without writing one. A host given replaces the configured clusters, and
+// keeps the other settings of a single configured one.
+func LoadConfigOverrides(filename string, o Overrides) (Config, error) {
+	env, err := envOverrides()
+	if err != nil {
+		return Config{}, err
+	}
+	o = o.or(env)
+	config, err := LoadConfigEnv(filename)
+	if errors.Is(err, fs.ErrNotExist) && o.Host != "" {
+		config, err = parseConfig([]byte("{}"))
+	}
+	if err != nil {
+		return config, err
+	}
+	if o.Host != "" {
+		if len(config.Clusters) == 1 {
+			config.RabbitMQ = config.Clusters[0].RabbitMQConfig
+		}
+		config.RabbitMQ.Host = o.Host
+		config.Clusters = nil
+	}
+	o.apply(&config.RabbitMQ)
+	config.RabbitMQ.defaultPorts()
+	for i := range config.Clusters {
+		o.apply(&config.Clusters[i].RabbitMQConfig)
+		config.Clusters[i].defaultPorts()
+	}
+	if o.RefreshSeconds > 0 {
+		config.UI.RefreshSeconds = o.RefreshSeconds
+	}
+	return config, nil
+}
+
+func (o Overrides) apply(c *RabbitMQConfig) {
+	if o.Port != "" {
+		c.Port = o.Port
+	}
+	if o.ManagementPort != "" {
+		c.ManagementPort = o.ManagementPort
+	}
+	if o.Username != "" {
+		c.Username = o.Username
+	}
+	if o.Password != "" {
+		c.Password = o.Password
+	}
+	if o.VHost != "" {
+		c.VHost = o.VHost
+	}
+}
+
+// defaultPorts fills in the ports a broker listens on out of the box for
+// those left empty.
+func (c *RabbitMQConfig) defaultPorts() {
+	if c.Port == "" {
+		c.Port = defaultAMQPPort
+	}
+	if c.ManagementPort == "" {
+		c.ManagementPort = defaultManagementPort
+	}
+}
+
+// readPasswordFile fills in the password from password_file, which is how
+// container secrets are usually mounted. A trailing newline is dropped.
+func (c *RabbitMQConfig) readPasswordFile() error {
+	if c.PasswordFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.PasswordFile)
+	if err != nil {
+		return fmt.Errorf("password_file: %w", err)
+	}
+	c.Password = strings.TrimRight(string(data), "\r\n")
+	return nil
+}
+
+func parseConfig(data []byte) (Config, error) {
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, err
+	}
+	if err := config.RabbitMQ.readPasswordFile(); err != nil {
+		return config, err
+	}
+	for i := range config.Clusters {
+		if err := config.Clusters[i].readPasswordFile(); err != nil {
+			return config, fmt.Errorf("cluster %s: %w", config.Clusters[i].Name, err)
+		}
+	}
+	if _, err := newPayloadMasker(config.Privacy.MaskPaths); err != nil {
+		return config, fmt.Errorf("privacy.mask_paths: %w", err)
+	}
+	if config.Sampling.Count <= 0 {
+		config.Sampling.Count = defaultSampleCount
+	}
+	if config.Requeue.RatePerSecond <= 0 {
+		config.Requeue.RatePerSecond = defaultRequeueRate
+	}
+	if config.Reprocess.BatchSize <= 0 {
+		config.Reprocess.BatchSize = defaultReprocessBatch
+	}
+	if config.Reprocess.Retries < 0 {
+		return config, fmt.Errorf("reprocess.retries must not be negative")
+	}
+	if config.Reprocess.Retries == 0 {
+		config.Reprocess.Retries = defaultReprocessRetries
+	}
+	if config.Reprocess.BackoffSeconds <= 0 {
+		config.Reprocess.BackoffSeconds = defaultReprocessBackoff
+	}
+	if config.Snooze.DefaultMinutes <= 0 {
+		config.Snooze.DefaultMinutes = defaultSnoozeMinutes
+	}
+	if err := validateBrowser(&config.Browser); err != nil {
+		return config, fmt.Errorf("browser: %w", err)
+	}
+	if err := validateConfirm(&config.Confirm); err != nil {
+		return config, fmt.Errorf("confirm: %w", err)
+	}
+	for _, cluster := range config.Clusters {
+		if cluster.Confirm != nil {
+			if err := validateConfirm(cluster.Confirm); err != nil {
+				return config, fmt.Errorf("cluster %s: confirm: %w", cluster.Name, err)
+			}
+		}
+	}
+	if config.Alerts.APIDownSeconds <= 0 {
+		config.Alerts.APIDownSeconds = defaultAPIDownSeconds
+	}
+	if b := &config.Alerts.Baselines; b.File != "" {
+		if b.LearnDays <= 0 {
+			b.LearnDays = defaultBaselineLearnDays
+		}
+		if b.MinHours <= 0 {
+			b.MinHours = defaultBaselineMinHours
+		}
+		if b.Tolerance <= 0 {
+			b.Tolerance = defaultBaselineTolerance
+		}
+		if b.MinDeviation <= 0 {
+			b.MinDeviation = defaultBaselineMinDeviation
+		}
+	}
+	if config.Bindings.UnusedWindowSeconds <= 0 {
+		config.Bindings.UnusedWindowSeconds = defaultUnusedWindowSeconds
+	}
+	if config.Nodes.TrendWindowSeconds <= 0 {
+		config.Nodes.TrendWindowSeconds = defaultTrendWindowSeconds
+	}
+	if config.Nodes.ExhaustionHorizonSeconds <= 0 {
+		config.Nodes.ExhaustionHorizonSeconds = defaultExhaustionHorizon
+	}
+	if config.SlowConsumers.WindowSeconds <= 0 {
+		config.SlowConsumers.WindowSeconds = defaultSlowConsumerWindow
+	}
+	if config.SlowConsumers.Ratio <= 0 {
+		config.SlowConsumers.Ratio = defaultSlowConsumerRatio
+	}
+	if config.SlowConsumers.Ratio > 1 {
+		return config, fmt.Errorf("slow_consumers.ratio must be between 0 and 1, got %g", config.SlowConsumers.Ratio)
+	}
+	if config.UI.TopN <= 0 {
+		config.UI.TopN = defaultTopN
+	}
+	if config.UI.RefreshSeconds <= 0 {
+		config.UI.RefreshSeconds = defaultRefreshSeconds
+	}
+	if len(config.UI.Columns) == 0 {
+		config.UI.Columns = defaultQueueColumns
+	}
+	if _, err := resolveQueueColumns(config.UI.Columns); err != nil {
+		return config, fmt.Errorf("ui.columns: %w", err)
+	}
+	if _, err := parseSortKeys(config.UI.Sort); err != nil {
+		return config, fmt.Errorf("ui.sort: %w", err)
+	}
+	if err := validateTiers(config.Tiers); err != nil {
+		return config, fmt.Errorf("tiers: %w", err)
+	}
+	if config.UI.FrozenColumns <= 0 {
+		config.UI.FrozenColumns = defaultFrozenColumns
+	}
+	if config.Wallboard.PageSeconds <= 0 {
+		config.Wallboard.PageSeconds = defaultWallboardPageSecs
+	}
+	if err := validateWebhooks(&config); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+func colorizeNumber(n int) string {
+	if n == 0 {
+		return fmt.Sprintf("[%d](fg:green)", n)
+	} else if n < 100 {
+		return fmt.Sprintf("[%d](fg:yellow)", n)
+	} else {
+		return fmt.Sprintf("[%d](fg:red)", n)
+	}
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func truncateString(s string, maxLength int) string {
+	if s == "" {
+		return strings.Repeat(" ", maxLength)
+	}
+	if len(s) <= maxLength {
+		return s + strings.Repeat(" ", maxLength-len(s))
+	}
+	return s[:maxLength-3] + "..."
+}
+
+func safeGetFirstChar(s string) string {
+	if len(s) > 0 {
+		return string(s[0])
+	}
+	return "-"
+}
+
+// queueState normalizes a queue's state, reporting quorum queues that have
+// lost their majority of online members as "minority", those that still
+// have it but with members offline as "reduced", and classic queues with no
+// activity as "idle".
+func queueState(q QueueInfo) string {
+	state := strings.ToLower(q.State)
+	if q.Type == "quorum" && len(q.Members) > 0 && len(q.Online)*2 <= len(q.Members) {
+		return "minority"
+	}
+	if q.Type == "quorum" && len(q.Online) < len(q.Members) {
+		return "reduced"
+	}
+	if state == "running" && q.IdleSince != "" {
+		return "idle"
+	}
+	return state
+}
+
+func getStateIndicator(state string) string {
+	switch state {
+	case "running":
+		return "[✓](fg:green)"
+	case "idle":
+		return "[◦](fg:cyan)"
+	case "flow":
+		return "[≈](fg:yellow)"
+	case "minority":
+		return "[!](fg:magenta,mod:bold)"
+	case "reduced":
+		return "[!](fg:yellow)"
+	case "down", "crashed", "stopped":
+		return "[✗](fg:red)"
+	case "terminated":
+		return "[■](fg:red)"
+	default:
+		return "?"
+	}
+}
+
+func clusterSummary(queues []QueueInfo, overview *Overview) string {
+	var ready, unacked, consumers int
+	var publishRate, deliverRate float64
+	for _, queue := range queues {
+		// Streams keep consumed messages, so they add to neither count.
+		if queue.Type != "stream" {
+			ready += queue.MessagesReady
+			unacked += queue.MessagesUnack
+		}
+		consumers += queue.Consumers
+		publishRate += queue.MessageStats.PublishDetails.Rate
+		deliverRate += queue.MessageStats.DeliverGetDetails.Rate
+	}
+
+	connections := "-"
+	if overview != nil {
+		connections = fmt.Sprintf("%d", overview.ObjectTotals.Connections)
+	}
+
+	return fmt.Sprintf("Queues: %d | Ready: %s | Unacked: %s | Consumers: %d | Publish: %.1f/s | Deliver: %.1f/s | Connections: %s",
+		len(queues), colorizeNumber(ready), colorizeNumber(unacked), consumers, publishRate, deliverRate, connections)
+}
+
+func isErrorQueue(queueName string) bool {
+	return strings.HasPrefix(strings.ToLower(queueName), "error") || strings.HasSuffix(strings.ToLower(queueName), "error")
+}
+
+// Beep sesi üreteci
+type beepStreamer struct {
+	freq float64
+	t    float64
+}
+
+func (bs *beepStreamer) Stream(samples [][2]float64) (n int, ok bool) {
+	for i := range samples {
+		v := math.Sin(2 * math.Pi * bs.freq * bs.t)
+		samples[i][0] = v
+		samples[i][1] = v
+		bs.t += 1.0 / 44100
+	}
+	return len(samples), true
+}
+
+func (bs *beepStreamer) Err() error {
+	return nil
+}
+
+func playAlertSound() {
+	if time.Since(lastAlertTime) < alertCooldown {
+		return
+	}
+	sr := beep.SampleRate(44100)
+	if err := speaker.Init(sr, sr.N(time.Second/10)); err != nil {
+		// No sound device, or no driver for it: fall back to the system's
+		// own beep.
+		systemBeep()
+		lastAlertTime = time.Now()
+		return
+	}
+
+	beeper := &beepStreamer{freq: 440} // 440 Hz (A4 nota)
+	done := make(chan bool)
+	speaker.Play(beep.Seq(beep.Take(sr.N(time.Second), beeper), beep.Callback(func() {
+		done <- true
+	})))
+	<-done
+	lastAlertTime = time.Now()
+}
+
+// uiState is the interactive state shared by every view.
+type uiState struct {
+	paused   bool
+	interval time.Duration
+}
+
+// stepRefresh moves to the next faster (dir < 0) or slower (dir > 0)
+// refresh interval in refreshSteps.
+func stepRefresh(current time.Duration, dir int) time.Duration {
+	if dir > 0 {
+		for _, step := range refreshSteps {
+			if step > current {
+				return step
+			}
+		}
+		return current
+	}
+	for i := len(refreshSteps) - 1; i >= 0; i-- {
+		if refreshSteps[i] < current {
+			return refreshSteps[i]
+		}
+	}
+	return current
+}
+
+type view interface {
+	Render(d *dashboard, ui uiState)
+	HandleKey(d *dashboard, id string) bool
+}
+
+// lazyView builds its view when it is first shown, so pages that are never
+// visited cost nothing at startup.
+type lazyView struct {
+	build func() view
+	v     view
+}
+
+func lazy[V view](build func() V) *lazyView {
+	return &lazyView{build: func() view { return build() }}
+}
+
+func (l *lazyView) get() view {
+	if l.v == nil {
+		l.v = l.build()
+	}
+	return l.v
+}
+
+func (l *lazyView) Render(d *dashboard, ui uiState) {
+	l.get().Render(d, ui)
+}
+
+func (l *lazyView) HandleKey(d *dashboard, id string) bool {
+	return l.get().HandleKey(d, id)
+}
+
+// Capturing forwards to the view once it exists; keys can't be captured by
+// a page that was never shown.
+func (l *lazyView) Capturing() bool {
+	c, ok := l.v.(inputCapturer)
+	return ok && c.Capturing()
+}
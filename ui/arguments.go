@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// queueSetting looks up a queue feature set either by x-argument or by the
+// policy in effect, e.g. "message-ttl" as x-message-ttl. Arguments win, as
+// they do on the broker.
+func queueSetting(q QueueInfo, name string) (interface{}, bool) {
+	if v, ok := q.Arguments["x-"+name]; ok {
+		return v, true
+	}
+	v, ok := q.EffectivePolicyDefinition[name]
+	return v, ok
+}
+
+func settingNumber(v interface{}) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+// queueBadge is a compact label for one queue setting. warning is set when
+// the setting looks like a misconfiguration.
+type queueBadge struct {
+	text    string
+	warning string
+}
+
+// queueBadges summarises the settings that most change how a queue behaves:
+// its declared type, TTL, dead-lettering and length limits. Expiry and
+// overflow without a dead-letter exchange are flagged, since those messages
+// are then dropped silently.
+func queueBadges(q QueueInfo) []queueBadge {
+	var badges []queueBadge
+	dlx, hasDLX := queueSetting(q, "dead-letter-exchange")
+
+	if t, ok := q.Arguments["x-queue-type"].(string); ok && t != "classic" {
+		badges = append(badges, queueBadge{text: t})
+	}
+	if v, ok := queueSetting(q, "message-ttl"); ok {
+		badge := queueBadge{text: "TTL " + fmt.Sprint(v)}
+		if ms, ok := settingNumber(v); ok {
+			badge.text = "TTL " + formatUptime(int64(ms))
+		}
+		if !hasDLX && q.Type != "stream" {
+			badge.warning = "expired messages are dropped: no dead-letter exchange"
+		}
+		badges = append(badges, badge)
+	}
+	if hasDLX {
+		target := fmt.Sprint(dlx)
+		if target == "" {
+			target = "(default)"
+		}
+		if key, ok := queueSetting(q, "dead-letter-routing-key"); ok {
+			target += "/" + fmt.Sprint(key)
+		}
+		badges = append(badges, queueBadge{text: "DLX " + target})
+	}
+
+	if v, ok := queueSetting(q, "max-age"); ok {
+		badges = append(badges, queueBadge{text: "AGE " + fmt.Sprint(v)})
+	}
+
+	var limits []string
+	if v, ok := queueSetting(q, "max-length"); ok {
+		if n, ok := settingNumber(v); ok {
+			limits = append(limits, compactNumber(n))
+		}
+	}
+	if v, ok := queueSetting(q, "max-length-bytes"); ok {
+		if n, ok := settingNumber(v); ok {
+			limits = append(limits, formatBytes(int64(n)))
+		}
+	}
+	if len(limits) > 0 {
+		badge := queueBadge{text: "MAX " + strings.Join(limits, "/")}
+		// drop-head is the default overflow behaviour.
+		overflow, _ := queueSetting(q, "overflow")
+		if overflow == nil || overflow == "drop-head" {
+			// Streams are truncated by design and cannot dead-letter.
+			if !hasDLX && q.Type != "stream" {
+				badge.warning = "overflowing messages are dropped: no dead-letter exchange"
+			}
+		} else {
+			badge.text += " " + fmt.Sprint(overflow)
+		}
+		badges = append(badges, badge)
+	}
+	return badges
+}
+
+func (b queueBadge) styled() string {
+	if b.warning != "" {
+		return fmt.Sprintf("[%s](fg:yellow,mod:bold)", b.text)
+	}
+	return fmt.Sprintf("[%s](fg:cyan)", b.text)
+}
+
+// badgesCell fits as many badges as width allows and counts the rest.
+func badgesCell(badges []queueBadge, width int) string {
+	var cells []string
+	used := 0
+	for i, b := range badges {
+		more := ""
+		if i < len(badges)-1 {
+			more = fmt.Sprintf(" +%d", len(badges)-1-i)
+		}
+		if used+len(b.text)+len(more) > width {
+			cells = append(cells, fmt.Sprintf("+%d", len(badges)-i))
+			break
+		}
+		cells = append(cells, b.styled())
+		used += len(b.text) + 1
+	}
+	return strings.Join(cells, " ")
+}
@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditConfig names the file every action that changes the broker is
+// appended to, one JSON line each, for reconstructing afterwards what was
+// done. An empty File keeps no log.
+type AuditConfig struct {
+	File string `json:"file"`
+}
+
+// auditEntry is one line of the audit log.
+type auditEntry struct {
+	Time    time.Time `json:"time"`
+	Cluster string    `json:"cluster"`
+	// Operator is the local account rabbitspy runs as, and User the
+	// broker user it acts as.
+	Operator string `json:"operator,omitempty"`
+	User     string `json:"user"`
+	Action   string `json:"action"`
+	Target   string `json:"target"`
+	Detail   string `json:"detail,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// auditMu keeps the lines written by concurrent jobs whole.
+var auditMu sync.Mutex
+
+var auditOperator = sync.OnceValue(func() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+})
+
+// auditRoutes name the API requests that change the broker, matched by
+// method and path; what is left of the path between prefix and suffix is
+// the target.
+var auditRoutes = []struct {
+	method, prefix, suffix, action string
+}{
+	{"DELETE", "/api/queues/", "/contents", "purge queue"},
+	{"POST", "/api/queues/", "/actions", "sync queue"},
+	{"DELETE", "/api/queues/", "", "delete queue"},
+	{"PUT", "/api/queues/", "", "declare queue"},
+	{"POST", "/api/exchanges/", "/publish", "publish"},
+	{"DELETE", "/api/exchanges/", "", "delete exchange"},
+	{"POST", "/api/bindings/", "", "bind"},
+	{"DELETE", "/api/bindings/", "", "unbind"},
+	{"DELETE", "/api/connections/", "", "close connection"},
+	{"PUT", "/api/policies/", "", "set policy"},
+	{"DELETE", "/api/policies/", "", "delete policy"},
+	{"PUT", "/api/parameters/shovel/", "", "create shovel"},
+	{"DELETE", "/api/parameters/shovel/", "", "delete shovel"},
+	{"PUT", "/api/vhosts/", "", "update vhost"},
+	{"POST", "/api/definitions", "", "import definitions"},
+	{"POST", "/api/rebalance/queues", "", "rebalance queues"},
+}
+
+// auditAction names an API request for the audit log, e.g. "purge queue"
+// on "//orders" for DELETE /api/queues/%2F/orders/contents. A query, such
+// as delete's if-empty, is the detail.
+func auditAction(method, path string) (action, target, detail string) {
+	path, detail, _ = strings.Cut(path, "?")
+	action, rest := method, path
+	for _, r := range auditRoutes {
+		if r.method == method && strings.HasPrefix(path, r.prefix) && strings.HasSuffix(path, r.suffix) {
+			action, rest = r.action, strings.TrimSuffix(strings.TrimPrefix(path, r.prefix), r.suffix)
+			break
+		}
+	}
+	segments := strings.Split(strings.TrimPrefix(rest, "/"), "/")
+	for i, s := range segments {
+		if unescaped, err := url.PathUnescape(s); err == nil {
+			segments[i] = unescaped
+		}
+	}
+	return action, strings.Join(segments, "/"), detail
+}
+
+// audited runs an action that changes the broker and appends it, and its
+// outcome, to the audit log. The log is opened first, so that an action
+// which could not be logged is not run.
+func audited(config Config, action, target, detail string, run func() error) error {
+	if config.Audit.File == "" {
+		return run()
+	}
+	file, err := os.OpenFile(config.Audit.File, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("audit log: %w", err)
+	}
+	defer file.Close()
+
+	runErr := run()
+	entry := auditEntry{
+		Time:     time.Now(),
+		Cluster:  config.cluster,
+		Operator: auditOperator(),
+		User:     config.RabbitMQ.Username,
+		Action:   action,
+		Target:   target,
+		Detail:   detail,
+	}
+	if runErr != nil {
+		entry.Error = runErr.Error()
+	}
+	line, err := json.Marshal(entry)
+	if err == nil {
+		auditMu.Lock()
+		_, err = file.Write(append(line, '\n'))
+		auditMu.Unlock()
+	}
+	if runErr != nil {
+		return runErr
+	}
+	if err != nil {
+		return fmt.Errorf("%s %s was done, but not logged: %w", action, target, err)
+	}
+	return nil
+}
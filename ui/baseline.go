@@ -0,0 +1,363 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// BaselineConfig enables auto-baselining: each queue's usual depth range is
+// learned from hourly history kept in File, and alerts fire when a queue
+// leaves its own range rather than a global threshold.
+type BaselineConfig struct {
+	File string `json:"file"`
+	// LearnDays is how much history a baseline is learned from, and
+	// MinHours how many complete hours are needed before it alerts.
+	LearnDays int `json:"learn_days"`
+	MinHours  int `json:"min_hours"`
+	// Tolerance is how far above the learned high a queue may go, as a
+	// fraction of it, and MinDeviation the smallest excess in messages that
+	// alerts, so tiny queues don't alert on every burst.
+	Tolerance    float64 `json:"tolerance"`
+	MinDeviation int     `json:"min_deviation"`
+}
+
+const (
+	defaultBaselineLearnDays    = 3
+	defaultBaselineMinHours     = 24
+	defaultBaselineTolerance    = 0.5
+	defaultBaselineMinDeviation = 100
+)
+
+// depthBucket is the lowest and highest total depth seen during one hour,
+// identified by its start as a Unix timestamp.
+type depthBucket struct {
+	Hour int64 `json:"hour"`
+	Min  int   `json:"min"`
+	Max  int   `json:"max"`
+}
+
+// queueBaseline is a queue's usual depth range. Pinned baselines were set by
+// hand, in the file or from the baselines page, and are no longer relearned.
+type queueBaseline struct {
+	Low     int           `json:"low"`
+	High    int           `json:"high"`
+	Pinned  bool          `json:"pinned,omitempty"`
+	Buckets []depthBucket `json:"buckets"`
+}
+
+// baselineStore holds the baselines of every cluster and persists them, so
+// learning carries on across restarts.
+type baselineStore struct {
+	config   BaselineConfig
+	Clusters map[string]map[string]*queueBaseline `json:"clusters"`
+}
+
+// loadBaselines reads the baseline file, starting empty when it does not
+// exist yet.
+func loadBaselines(config BaselineConfig) (*baselineStore, error) {
+	s := &baselineStore{config: config, Clusters: make(map[string]map[string]*queueBaseline)}
+	data, err := os.ReadFile(config.File)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", config.File, err)
+	}
+	if s.Clusters == nil {
+		s.Clusters = make(map[string]map[string]*queueBaseline)
+	}
+	return s, nil
+}
+
+// Save writes the store through a temporary file, so a crash mid-write
+// cannot lose what was learned.
+func (s *baselineStore) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.config.File + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.config.File)
+}
+
+func (s *baselineStore) cluster(name string) map[string]*queueBaseline {
+	queues, ok := s.Clusters[name]
+	if !ok {
+		queues = make(map[string]*queueBaseline)
+		s.Clusters[name] = queues
+	}
+	return queues
+}
+
+// Record folds the current depths into each queue's hourly bucket. When an
+// hour is complete, old buckets are dropped, baselines are relearned and the
+// store is saved.
+func (s *baselineStore) Record(cluster string, at time.Time, queues []QueueInfo) error {
+	hour := at.Truncate(time.Hour).Unix()
+	oldest := at.Add(-time.Duration(s.config.LearnDays) * 24 * time.Hour).Unix()
+	baselines := s.cluster(cluster)
+	rolled := false
+	for _, q := range queues {
+		b, ok := baselines[q.Key()]
+		if !ok {
+			b = &queueBaseline{}
+			baselines[q.Key()] = b
+		}
+		n := len(b.Buckets)
+		if n > 0 && b.Buckets[n-1].Hour == hour {
+			last := &b.Buckets[n-1]
+			if q.Messages < last.Min {
+				last.Min = q.Messages
+			}
+			if q.Messages > last.Max {
+				last.Max = q.Messages
+			}
+			continue
+		}
+		rolled = rolled || n > 0
+		b.Buckets = append(b.Buckets, depthBucket{Hour: hour, Min: q.Messages, Max: q.Messages})
+		for len(b.Buckets) > 0 && b.Buckets[0].Hour < oldest {
+			b.Buckets = b.Buckets[1:]
+		}
+		if !b.Pinned {
+			b.learn()
+		}
+	}
+	// Forget queues that have been gone for the whole learning window.
+	for key, b := range baselines {
+		if n := len(b.Buckets); !b.Pinned && n > 0 && b.Buckets[n-1].Hour < oldest {
+			delete(baselines, key)
+		}
+	}
+	if !rolled {
+		return nil
+	}
+	return s.Save()
+}
+
+// learn sets the usual range from the complete hours: from the 5th
+// percentile of hourly minimums to the 95th percentile of hourly maximums,
+// so one unusual hour doesn't widen it.
+func (b *queueBaseline) learn() {
+	complete := b.Buckets[:len(b.Buckets)-1]
+	if len(complete) == 0 {
+		return
+	}
+	mins := make([]int, len(complete))
+	maxes := make([]int, len(complete))
+	for i, bucket := range complete {
+		mins[i], maxes[i] = bucket.Min, bucket.Max
+	}
+	sort.Ints(mins)
+	sort.Ints(maxes)
+	b.Low = mins[len(mins)*5/100]
+	b.High = maxes[(len(maxes)*95+99)/100-1]
+}
+
+// hoursLearned is how many complete hours the baseline is based on.
+func (b *queueBaseline) hoursLearned() int {
+	if len(b.Buckets) == 0 {
+		return 0
+	}
+	return len(b.Buckets) - 1
+}
+
+func (s *baselineStore) ready(b *queueBaseline) bool {
+	return b.Pinned || b.hoursLearned() >= s.config.MinHours
+}
+
+// limit is the depth above which a queue deviates from its baseline.
+func (s *baselineStore) limit(b *queueBaseline) int {
+	tolerated := int(float64(b.High) * s.config.Tolerance)
+	if tolerated < s.config.MinDeviation {
+		tolerated = s.config.MinDeviation
+	}
+	return b.High + tolerated
+}
+
+// Alerts raises a warning for each queue that is well above its usual
+// range, or empty while it usually never is.
+func (s *baselineStore) Alerts(cluster string, queues []QueueInfo) []Alert {
+	baselines := s.cluster(cluster)
+	var alerts []Alert
+	for _, q := range queues {
+		b, ok := baselines[q.Key()]
+		if !ok || !s.ready(b) {
+			continue
+		}
+		switch {
+		case q.Messages > s.limit(b):
+			alerts = append(alerts, Alert{
+				Key:       "baseline:" + q.Key(),
+				Severity:  SeverityWarning,
+				Message:   fmt.Sprintf("%s has %d messages, above its usual %d-%d", q.Key(), q.Messages, b.Low, b.High),
+				VHost:     q.VHost,
+				Queue:     q.Name,
+				Value:     float64(q.Messages),
+				Threshold: float64(s.limit(b)),
+			})
+		case b.Low >= s.config.MinDeviation && q.Messages == 0:
+			alerts = append(alerts, Alert{
+				Key:       "baseline:" + q.Key(),
+				Severity:  SeverityWarning,
+				Message:   fmt.Sprintf("%s is empty, though it usually holds %d-%d messages", q.Key(), b.Low, b.High),
+				VHost:     q.VHost,
+				Queue:     q.Name,
+				Threshold: float64(b.Low),
+			})
+		}
+	}
+	return alerts
+}
+
+// Pin sets a queue's range by hand and stops relearning it.
+func (s *baselineStore) Pin(cluster, key string, low, high int) error {
+	b, ok := s.cluster(cluster)[key]
+	if !ok {
+		b = &queueBaseline{}
+		s.cluster(cluster)[key] = b
+	}
+	b.Low, b.High, b.Pinned = low, high, true
+	return s.Save()
+}
+
+// Unpin goes back to the learned range.
+func (s *baselineStore) Unpin(cluster, key string) error {
+	b, ok := s.cluster(cluster)[key]
+	if !ok {
+		return nil
+	}
+	b.Pinned = false
+	if len(b.Buckets) > 0 {
+		b.learn()
+	}
+	return s.Save()
+}
+
+// baselineView lists the learned baselines next to current depths. e sets
+// the selected queue's range by hand, x returns it to the learned one.
+type baselineView struct {
+	*listView
+	edit    *lineInput
+	editing string
+}
+
+func newBaselineView() *baselineView {
+	v := &baselineView{}
+	v.listView = newListView("Baselines",
+		[]string{"Queue", "Usual range", "Alerts above", "Messages", "Learned", "Status"},
+		func(width int) []int { return spreadWidths(width, 0, 16, 14, 10, 10, 16) },
+		v.rows)
+	return v
+}
+
+func (v *baselineView) rows(d *dashboard) ([]listRow, string) {
+	if d.baselines == nil {
+		return nil, "Auto-baselining is off; set alerts.baselines.file to enable it."
+	}
+	s := d.baselines
+	baselines := s.cluster(d.name)
+	depths := make(map[string]int, len(d.queues))
+	for _, q := range d.queues {
+		depths[q.Key()] = q.Messages
+	}
+	keys := make([]string, 0, len(baselines))
+	for key := range baselines {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var rows []listRow
+	for _, key := range keys {
+		b := baselines[key]
+		depth, present := depths[key]
+		status := "ok"
+		switch {
+		case !s.ready(b):
+			status = fmt.Sprintf("learning %d/%dh", b.hoursLearned(), s.config.MinHours)
+		case !present:
+			status = "gone"
+		case depth > s.limit(b):
+			status = "above usual"
+		case b.Pinned:
+			status = "pinned"
+		}
+		current := "-"
+		if present {
+			current = fmt.Sprint(depth)
+		}
+		detail := fmt.Sprintf("Learned from %d complete hours; alerts above high + max(%.0f%%, %d messages).",
+			b.hoursLearned(), s.config.Tolerance*100, s.config.MinDeviation)
+		if b.Pinned {
+			detail = "Range set by hand; x returns to the learned range."
+		}
+		if n := len(b.Buckets); n > 0 {
+			detail += fmt.Sprintf("\nThis hour: %d-%d messages.", b.Buckets[n-1].Min, b.Buckets[n-1].Max)
+		}
+		detail += "\ne sets the range by hand (low-high)."
+		rows = append(rows, listRow{
+			cells:  []string{key, fmt.Sprintf("%d-%d", b.Low, b.High), fmt.Sprint(s.limit(b)), current, fmt.Sprintf("%dh", b.hoursLearned()), status},
+			broken: status == "above usual",
+			detail: detail,
+			key:    key,
+		})
+	}
+	return rows, "No queues seen yet."
+}
+
+func (v *baselineView) Render(d *dashboard, ui uiState) {
+	v.prompt = ""
+	if v.edit != nil {
+		v.prompt = fmt.Sprintf("Usual range for %s (low-high): %s_  [Enter to save, Esc to cancel](fg:white)", v.editing, v.edit.value)
+	}
+	v.listView.Render(d, ui)
+}
+
+func (v *baselineView) HandleKey(d *dashboard, id string) bool {
+	if v.edit != nil {
+		done, cancelled := v.edit.Feed(id)
+		if done && !cancelled {
+			var low, high int
+			if _, err := fmt.Sscanf(v.edit.value, "%d-%d", &low, &high); err != nil || low < 0 || high < low {
+				d.setNotice("[Enter the range as low-high, e.g. 0-500](fg:red)")
+			} else if err := d.baselines.Pin(d.name, v.editing, low, high); err != nil {
+				d.setNotice("[Saving baselines failed: %s](fg:red)", err)
+			} else {
+				d.setNotice("Baseline of %s set to %d-%d", v.editing, low, high)
+			}
+		}
+		if done {
+			v.edit = nil
+		}
+		return true
+	}
+	row, ok := v.selectedRow(d)
+	switch {
+	case id == "e" && ok:
+		b := d.baselines.cluster(d.name)[row.key]
+		v.edit = &lineInput{value: fmt.Sprintf("%d-%d", b.Low, b.High)}
+		v.editing = row.key
+		return true
+	case id == "x" && ok:
+		if err := d.baselines.Unpin(d.name, row.key); err != nil {
+			d.setNotice("[Saving baselines failed: %s](fg:red)", err)
+		}
+		return true
+	}
+	return v.listView.HandleKey(d, id)
+}
+
+// Capturing reports whether a range is being typed.
+func (v *baselineView) Capturing() bool {
+	return v.edit != nil
+}
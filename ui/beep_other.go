@@ -0,0 +1,10 @@
+//go:build !windows
+
+package ui
+
+import "os"
+
+// systemBeep rings the terminal bell.
+func systemBeep() {
+	os.Stdout.WriteString("\a")
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package ui
+
+import "syscall"
+
+var procBeep = syscall.NewLazyDLL("kernel32.dll").NewProc("Beep")
+
+// systemBeep sounds a 440 Hz tone through kernel32's Beep, which works in
+// the Windows console without an audio device driver for oto.
+func systemBeep() {
+	if procBeep.Find() != nil {
+		return
+	}
+	procBeep.Call(440, 300)
+}
@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// bindingPath is the API path of the bindings from an exchange to a queue
+// or exchange, with every part escaped.
+func bindingPath(vhost, source, destinationType, destination string) string {
+	kind := "q"
+	if destinationType == "exchange" {
+		kind = "e"
+	}
+	return "/api/bindings/" + url.PathEscape(vhost) + "/e/" + url.PathEscape(source) + "/" + kind + "/" + url.PathEscape(destination)
+}
+
+// bindingDeclaration is the body of POST /api/bindings/{vhost}/e/{source}/...
+type bindingDeclaration struct {
+	RoutingKey string                 `json:"routing_key"`
+	Arguments  map[string]interface{} `json:"arguments"`
+}
+
+func createBinding(config Config, b BindingInfo) error {
+	body := bindingDeclaration{RoutingKey: b.RoutingKey, Arguments: b.Arguments}
+	return apiRequest(config, "POST", bindingPath(b.VHost, b.Source, b.DestinationType, b.Destination), body, nil)
+}
+
+// deleteBinding removes b, which the API names by its properties key, a
+// digest of its routing key and arguments.
+func deleteBinding(config Config, b BindingInfo) error {
+	return apiRequest(config, "DELETE", bindingPath(b.VHost, b.Source, b.DestinationType, b.Destination)+"/"+url.PathEscape(b.PropertiesKey), nil, nil)
+}
+
+// bindingName describes a binding as source → destination (routing key).
+func bindingName(b BindingInfo) string {
+	return fmt.Sprintf("%s/%s → %s %s (%q)", b.VHost, b.Source, b.DestinationType, b.Destination, b.RoutingKey)
+}
+
+// sameBinding reports whether a and b connect the same source and
+// destination with the same routing key; arguments are not compared.
+func sameBinding(a, b BindingInfo) bool {
+	return a.VHost == b.VHost && a.Source == b.Source && a.DestinationType == b.DestinationType &&
+		a.Destination == b.Destination && a.RoutingKey == b.RoutingKey
+}
+
+// expectBound checks that a new binding shows up. Bindings are not part of
+// the queue listing, so it reads them from the dashboard.
+func expectBound(d *dashboard, b BindingInfo) verification {
+	return verification{check: func([]QueueInfo) (string, bool) {
+		for _, x := range d.bindings {
+			if sameBinding(x, b) {
+				return "bound " + bindingName(b), true
+			}
+		}
+		return "waiting for " + bindingName(b) + " to show up", false
+	}}
+}
+
+// expectUnbound checks that a removed binding is gone.
+func expectUnbound(d *dashboard, b BindingInfo) verification {
+	return verification{check: func([]QueueInfo) (string, bool) {
+		for _, x := range d.bindings {
+			if sameBinding(x, b) && x.PropertiesKey == b.PropertiesKey {
+				return bindingName(b) + " still exists", false
+			}
+		}
+		return "unbound " + bindingName(b), true
+	}}
+}
+
+// bindingFields are the fields shared by the bind and unbind forms, filled
+// in with what is known from the selected exchange or queue.
+func bindingFields(vhost, source, destinationType, destination string) []*formField {
+	kind := 0
+	if destinationType == "exchange" {
+		kind = 1
+	}
+	return []*formField{
+		{label: "VHost", input: lineInput{value: vhost}},
+		{label: "Source exchange", input: lineInput{value: source}},
+		{label: "Destination type", choices: []string{"queue", "exchange"}, choice: kind},
+		{label: "Destination", input: lineInput{value: destination}},
+		{label: "Routing key", hint: "empty for fanout and headers exchanges"},
+	}
+}
+
+// formBinding reads the binding typed into a bind or unbind form.
+func formBinding(values map[string]string) (BindingInfo, error) {
+	b := BindingInfo{
+		VHost:           values["VHost"],
+		Source:          values["Source exchange"],
+		DestinationType: values["Destination type"],
+		Destination:     values["Destination"],
+		RoutingKey:      values["Routing key"],
+	}
+	switch {
+	case b.VHost == "":
+		return b, fmt.Errorf("a vhost is needed")
+	case b.Source == "":
+		return b, fmt.Errorf("a source exchange is needed; nothing can be bound to the default exchange")
+	case b.Destination == "":
+		return b, fmt.Errorf("a destination is needed")
+	}
+	return b, nil
+}
+
+// bindForm asks for a binding to create from an exchange to a queue or
+// another exchange. Arguments are typed as name=value pairs, as for headers
+// exchanges' x-match.
+func (d *dashboard) bindForm(vhost, source, destinationType, destination string) *form {
+	fields := append(bindingFields(vhost, source, destinationType, destination),
+		&formField{label: "Arguments", hint: "name=value, optional"})
+	return d.confirmForm(newForm("New binding", fields, func(values map[string]string) error {
+		b, err := formBinding(values)
+		if err != nil {
+			return err
+		}
+		if b.Arguments, err = parseHeaders(values["Arguments"]); err != nil {
+			return fmt.Errorf("arguments must be name=value, separated by commas")
+		}
+		if err := createBinding(d.config, b); err != nil {
+			return err
+		}
+		d.verifyAfter(expectBound(d, b))
+		return nil
+	}), "bind", func(values map[string]string) (string, string) {
+		return values["Destination"], fmt.Sprintf("Bind %s %s to %s with %q?", values["Destination type"], values["Destination"], values["Source exchange"], values["Routing key"])
+	})
+}
+
+// unbindForm asks for a binding to remove. It is looked up among the polled
+// bindings; when several differ only by their arguments, their properties
+// keys are listed to pick one.
+func (d *dashboard) unbindForm(vhost, source, destinationType, destination string) *form {
+	fields := append(bindingFields(vhost, source, destinationType, destination),
+		&formField{label: "Properties key", hint: "only when several bindings match"})
+	return d.confirmForm(newForm("Remove binding", fields, func(values map[string]string) error {
+		typed, err := formBinding(values)
+		if err != nil {
+			return err
+		}
+		var matches []BindingInfo
+		for _, b := range d.bindings {
+			if sameBinding(b, typed) && (values["Properties key"] == "" || b.PropertiesKey == values["Properties key"]) {
+				matches = append(matches, b)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return fmt.Errorf("no binding %s", bindingName(typed))
+		case 1:
+		default:
+			keys := make([]string, len(matches))
+			for i, b := range matches {
+				keys[i] = b.PropertiesKey
+			}
+			return fmt.Errorf("%d bindings match with different arguments; set the properties key to one of %s", len(matches), strings.Join(keys, ", "))
+		}
+		if err := deleteBinding(d.config, matches[0]); err != nil {
+			return err
+		}
+		d.verifyAfter(expectUnbound(d, matches[0]))
+		return nil
+	}), "unbind", func(values map[string]string) (string, string) {
+		return values["Destination"], fmt.Sprintf("Unbind %s %s from %s with %q?", values["Destination type"], values["Destination"], values["Source exchange"], values["Routing key"])
+	})
+}
@@ -0,0 +1,139 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type bindingSample struct {
+	at        time.Time
+	exchanges map[string]int
+	queues    map[string]int
+}
+
+// bindingTracker keeps enough exchange and queue counter samples to cover
+// the configured window. A binding is reported as unused when its source
+// exchange received messages during the window but nothing at all was routed
+// into the destination queue.
+type bindingTracker struct {
+	window  time.Duration
+	samples []bindingSample
+}
+
+type unusedBinding struct {
+	Binding  BindingInfo
+	SourceIn int
+}
+
+func newBindingTracker(window time.Duration) *bindingTracker {
+	return &bindingTracker{window: window}
+}
+
+func (t *bindingTracker) Add(exchanges []ExchangeInfo, queues []QueueInfo) {
+	sample := bindingSample{
+		at:        time.Now(),
+		exchanges: make(map[string]int, len(exchanges)),
+		queues:    make(map[string]int, len(queues)),
+	}
+	for _, e := range exchanges {
+		sample.exchanges[e.Key()] = e.MessageStats.PublishIn
+	}
+	for _, q := range queues {
+		sample.queues[q.Key()] = q.MessageStats.Publish
+	}
+	t.samples = append(t.samples, sample)
+
+	// Keep exactly one sample older than the window as the baseline.
+	for len(t.samples) > 2 && sample.at.Sub(t.samples[1].at) >= t.window {
+		t.samples = t.samples[1:]
+	}
+}
+
+// Covered reports how much of the window the collected samples span.
+func (t *bindingTracker) Covered() time.Duration {
+	if len(t.samples) < 2 {
+		return 0
+	}
+	return t.samples[len(t.samples)-1].at.Sub(t.samples[0].at)
+}
+
+func (t *bindingTracker) Ready() bool {
+	return t.Covered() >= t.window
+}
+
+func (t *bindingTracker) Unused(bindings []BindingInfo) []unusedBinding {
+	if !t.Ready() {
+		return nil
+	}
+	first, last := t.samples[0], t.samples[len(t.samples)-1]
+
+	var unused []unusedBinding
+	for _, b := range bindings {
+		if b.Source == "" || b.DestinationType != "queue" {
+			continue
+		}
+		exchangeKey := b.VHost + "/" + b.Source
+		queueKey := b.VHost + "/" + b.Destination
+
+		startIn, ok1 := first.exchanges[exchangeKey]
+		endIn, ok2 := last.exchanges[exchangeKey]
+		startQ, ok3 := first.queues[queueKey]
+		endQ, ok4 := last.queues[queueKey]
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			continue
+		}
+		if endIn-startIn > 0 && endQ-startQ == 0 {
+			unused = append(unused, unusedBinding{
+				Binding:  b,
+				SourceIn: endIn - startIn,
+			})
+		}
+	}
+
+	sort.Slice(unused, func(i, j int) bool {
+		return unused[i].SourceIn > unused[j].SourceIn
+	})
+	return unused
+}
+
+// bindingCounts counts bindings per destination queue, leaving out the
+// implicit default-exchange binding every queue has.
+func bindingCounts(bindings []BindingInfo) map[string]int {
+	counts := make(map[string]int)
+	for _, b := range bindings {
+		if b.Source == "" || b.DestinationType != "queue" {
+			continue
+		}
+		counts[b.VHost+"/"+b.Destination]++
+	}
+	return counts
+}
+
+func unusedBindingRows(tracker *bindingTracker, bindings []BindingInfo, nameWidth int) [][]string {
+	rows := [][]string{
+		{"Exchange", "Queue", "Routing Key", "Exchange In"},
+	}
+	if !tracker.Ready() {
+		rows = append(rows, []string{
+			fmt.Sprintf("Collecting samples (%s of %s)...", tracker.Covered().Round(time.Second), tracker.window),
+			"", "", "",
+		})
+		return rows
+	}
+
+	unused := tracker.Unused(bindings)
+	if len(unused) == 0 {
+		rows = append(rows, []string{"[No unused bindings detected.](fg:green)", "", "", ""})
+		return rows
+	}
+	for _, u := range unused {
+		rows = append(rows, []string{
+			truncateString(u.Binding.VHost+"/"+u.Binding.Source, nameWidth),
+			truncateString(u.Binding.Destination, nameWidth),
+			u.Binding.RoutingKey,
+			fmt.Sprintf("%d", u.SourceIn),
+		})
+	}
+	return rows
+}
@@ -0,0 +1,128 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// isBlocked reports a connection throttled by a resource alarm. A blocked
+// connection tried to publish and is held; a blocking one has not
+// published since the alarm and will be held as soon as it does.
+func isBlocked(c ConnectionInfo) bool {
+	return c.State == "blocked" || c.State == "blocking"
+}
+
+// blockedTracker remembers when each connection was first seen blocked,
+// since the management API only reports the state.
+type blockedTracker struct {
+	since map[string]time.Time
+}
+
+func newBlockedTracker() *blockedTracker {
+	return &blockedTracker{since: make(map[string]time.Time)}
+}
+
+// Update records the connections blocked at now and forgets the others.
+func (t *blockedTracker) Update(connections []ConnectionInfo, now time.Time) {
+	seen := make(map[string]bool)
+	for _, c := range connections {
+		if !isBlocked(c) {
+			continue
+		}
+		seen[c.Name] = true
+		if _, ok := t.since[c.Name]; !ok {
+			t.since[c.Name] = now
+		}
+	}
+	for name := range t.since {
+		if !seen[name] {
+			delete(t.since, name)
+		}
+	}
+}
+
+// Blocked lists the blocked connections, blocked before blocking and then
+// longest blocked first.
+func (t *blockedTracker) Blocked(connections []ConnectionInfo) []ConnectionInfo {
+	var blocked []ConnectionInfo
+	for _, c := range connections {
+		if isBlocked(c) {
+			blocked = append(blocked, c)
+		}
+	}
+	sort.SliceStable(blocked, func(i, j int) bool {
+		if blocked[i].State != blocked[j].State {
+			return blocked[i].State == "blocked"
+		}
+		return t.since[blocked[i].Name].Before(t.since[blocked[j].Name])
+	})
+	return blocked
+}
+
+// For is how long c has been seen blocked.
+func (t *blockedTracker) For(c ConnectionInfo) time.Duration {
+	since, ok := t.since[c.Name]
+	if !ok {
+		return 0
+	}
+	return time.Since(since)
+}
+
+// blockedText is the banner line for blocked connections, or "" when there
+// are none.
+func blockedText(d *dashboard) string {
+	blocked := d.blocked.Blocked(d.connections)
+	if len(blocked) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d CONNECTIONS BLOCKED, publishers are throttled; longest for %s (%s)",
+		len(blocked), d.blocked.For(blocked[0]).Round(time.Second), blocked[0].Name)
+}
+
+// blockedView lists the connections blocked by resource alarms, with why
+// and for how long.
+type blockedView struct {
+	*listView
+}
+
+func newBlockedView() *blockedView {
+	v := &blockedView{}
+	v.listView = newListView("Blocked connections",
+		[]string{"Connection", "User", "VHost", "State", "Blocked for", "Reason"},
+		func(width int) []int { return spreadWidths(width, 0, 14, 10, 9, 12, 0) },
+		v.rows)
+	return v
+}
+
+func (v *blockedView) rows(d *dashboard) ([]listRow, string) {
+	alarms := nodeAlarms(d.nodes)
+	reason := "no alarm raised now; unblocked shortly"
+	if len(alarms) > 0 {
+		reason = strings.Join(alarms, " · ")
+	}
+
+	var rows []listRow
+	for _, c := range d.blocked.Blocked(d.connections) {
+		detail := fmt.Sprintf("%s from %s:%d, user %s on %s, seen %s for %s.",
+			c.Name, c.PeerHost, c.PeerPort, c.User, c.Node, c.State, d.blocked.For(c).Round(time.Second))
+		if c.State == "blocked" {
+			detail += "\nIt published while a resource alarm was raised and the broker stopped reading from it."
+		} else {
+			detail += "\nIt has not published since the alarm was raised and will be blocked as soon as it does."
+		}
+		if len(alarms) > 0 {
+			detail += "\n[" + alarmText(alarms) + "](fg:red)"
+		} else {
+			detail += "\nNo alarm is raised now; the connection is unblocked once the broker catches up."
+		}
+		rows = append(rows, listRow{
+			cells:  []string{c.Name, c.User, c.VHost, c.State, d.blocked.For(c).Round(time.Second).String(), reason},
+			broken: c.State == "blocked",
+			detail: detail,
+			key:    c.Name,
+		})
+	}
+	return rows, "[No blocked connections.](fg:green)"
+}
@@ -0,0 +1,239 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// BrowserConfig sets how many messages the message browser fetches, how
+// they are fetched and how much of each payload it shows. AckMode is one of
+// the management API's get modes, of which only the requeue ones leave the
+// messages in the queue, or "manual" to fetch them over AMQP and hold them
+// unacknowledged until each is acked, rejected or the browser is closed.
+type BrowserConfig struct {
+	Count        int    `json:"count"`
+	AckMode      string `json:"ack_mode"`
+	PreviewBytes int    `json:"preview_bytes"`
+}
+
+const (
+	defaultBrowseCount        = 10
+	defaultBrowseAckMode      = "ack_requeue_true"
+	defaultBrowsePreviewBytes = 4096
+)
+
+var ackModes = []string{"ack_requeue_true", "reject_requeue_true", "ack_requeue_false", "reject_requeue_false", "manual"}
+
+func validateBrowser(b *BrowserConfig) error {
+	if b.Count <= 0 {
+		b.Count = defaultBrowseCount
+	}
+	if b.AckMode == "" {
+		b.AckMode = defaultBrowseAckMode
+	}
+	if b.PreviewBytes <= 0 {
+		b.PreviewBytes = defaultBrowsePreviewBytes
+	}
+	for _, mode := range ackModes {
+		if b.AckMode == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown ack_mode %q (known: %s)", b.AckMode, strings.Join(ackModes, ", "))
+}
+
+// requeues reports whether messages fetched in the ack mode are put back.
+func requeues(ackMode string) bool {
+	return strings.HasSuffix(ackMode, "_requeue_true") || ackMode == "manual"
+}
+
+// messageBrowser shows the messages at the head of a queue, drawn over the
+// page: a list of them and an inspector of the selected one.
+type messageBrowser struct {
+	queue    QueueInfo
+	config   BrowserConfig
+	messages []MessageInfo
+	err      error
+	cursor   int
+	// held are the messages fetched in manual mode. settling is the key
+	// pressed once to ack or reject the selected one, waiting to be
+	// pressed again.
+	held     *heldMessages
+	settling string
+
+	list      *widgets.Table
+	inspector *messageInspector
+}
+
+func newMessageBrowser(d *dashboard, q QueueInfo) *messageBrowser {
+	list := widgets.NewTable()
+	list.TextStyle = termui.NewStyle(termui.ColorWhite)
+	list.BorderStyle = termui.NewStyle(termui.ColorYellow)
+	list.RowSeparator = false
+	list.FillRow = true
+
+	masker, _ := newPayloadMasker(d.config.Privacy.MaskPaths)
+	b := &messageBrowser{queue: q, config: d.config.Browser, list: list, inspector: newMessageInspector(d.config.Browser, masker)}
+	b.fetch(d)
+	return b
+}
+
+func (b *messageBrowser) fetch(d *dashboard) {
+	if b.config.AckMode == "manual" {
+		b.close()
+		b.held, b.messages, b.err = holdMessages(d.config, b.queue, b.config.Count)
+	} else {
+		b.messages, b.err = getMessages(d.config, b.queue, b.config.Count, b.config.AckMode)
+	}
+	if b.cursor >= len(b.messages) {
+		b.cursor = 0
+	}
+	if b.err == nil && !requeues(b.config.AckMode) {
+		d.setNotice("[%d messages were removed from %s by browsing with %s](fg:yellow)", len(b.messages), b.queue.Key(), b.config.AckMode)
+	}
+}
+
+// close puts messages still held back in the queue.
+func (b *messageBrowser) close() {
+	if b.held != nil {
+		b.held.release()
+		b.held = nil
+	}
+}
+
+// Feed applies one key event and reports whether the browser was closed.
+// r fetches the messages again, unless that would take more of them off the
+// queue. In manual mode A acks and R rejects the selected message, each
+// pressed twice.
+func (b *messageBrowser) Feed(d *dashboard, id string) (done bool) {
+	settling := b.settling
+	b.settling = ""
+	if b.inspector.Feed(id) {
+		return false
+	}
+	switch id {
+	case "<Escape>", "B":
+		b.close()
+		return true
+	case "A", "R":
+		if b.held == nil {
+			d.setNotice("[Single messages can only be acked or rejected with browser.ack_mode \"manual\"](fg:yellow)")
+			break
+		}
+		if b.cursor >= len(b.messages) || b.held.settled[b.cursor] != "" || d.readOnly("settling messages") {
+			break
+		}
+		if settling != id {
+			b.settling = id
+			break
+		}
+		if err := b.held.settle(b.cursor, id == "A"); err != nil {
+			d.setNotice("[Settling message %d of %s failed: %s](fg:red)", b.cursor+1, b.queue.Key(), err)
+			break
+		}
+		d.setNotice("Message %d of %s %s", b.cursor+1, b.queue.Key(), b.held.settled[b.cursor])
+	case "j", "<Down>":
+		if b.cursor < len(b.messages)-1 {
+			b.cursor++
+			b.inspector.reset()
+		}
+	case "k", "<Up>":
+		if b.cursor > 0 {
+			b.cursor--
+			b.inspector.reset()
+		}
+	case "y":
+		if b.inspector.text != nil {
+			d.copyText(fmt.Sprintf("the payload of message %d", b.cursor+1), b.inspector.text)
+		}
+	case "r":
+		if requeues(b.config.AckMode) {
+			b.fetch(d)
+		}
+	}
+	return false
+}
+
+func (b *messageBrowser) Render() {
+	width, height := termui.TerminalDimensions()
+	height -= statusBarHeight
+	listHeight := height / 3
+	if listHeight < 5 {
+		listHeight = 5
+	}
+
+	b.list.Title = fmt.Sprintf(" %s · first %d messages (%s) ", b.queue.Key(), b.config.Count, b.config.AckMode)
+	b.list.ColumnWidths = spreadWidths(width, 4, 0, 0, 10, 6, 9)
+	rows := [][]string{{"[#](fg:black,bg:yellow)", "[Exchange](fg:black,bg:yellow)", "[Routing key](fg:black,bg:yellow)", "[Size](fg:black,bg:yellow)", "[Redel](fg:black,bg:yellow)", "[Settled](fg:black,bg:yellow)"}}
+	b.list.RowStyles = map[int]termui.Style{}
+	switch {
+	case b.err != nil:
+		rows = append(rows, []string{"", "[Fetching messages failed](fg:red)", "", "", "", ""})
+	case len(b.messages) == 0:
+		rows = append(rows, []string{"", "The queue has no ready messages.", "", "", "", ""})
+	}
+	start := 0
+	if pageRows := listHeight - 3; b.cursor >= pageRows {
+		start = b.cursor - pageRows + 1
+	}
+	for i, m := range b.messages[start:] {
+		if start+i == b.cursor {
+			b.list.RowStyles[i+1] = termui.NewStyle(termui.ColorWhite, termui.ColorBlue, termui.ModifierBold)
+		}
+		exchange := m.Exchange
+		if exchange == "" {
+			exchange = "(AMQP default)"
+		}
+		redelivered := "no"
+		if m.Redelivered {
+			redelivered = "yes"
+		}
+		settled := ""
+		if b.held != nil {
+			settled = b.held.settled[start+i]
+		}
+		rows = append(rows, []string{fmt.Sprint(start + i + 1), exchange, m.RoutingKey, formatBytes(int64(m.PayloadBytes)), redelivered, settled})
+	}
+	b.list.Rows = rows
+
+	keys := "j/k select · y copy · r refetch"
+	if b.held != nil {
+		keys = "j/k select · y copy · A ack · R reject · r refetch"
+	}
+	b.list.Title += "· " + keys + " · Esc to close "
+	if b.settling != "" {
+		action := map[string]string{"A": "ack (remove)", "R": "reject (dead-letter or drop)"}[b.settling]
+		b.list.Title = fmt.Sprintf(" Press %s again to %s message %d ", b.settling, action, b.cursor+1)
+	}
+	switch {
+	case b.err != nil:
+		b.inspector.error(b.err)
+	case b.cursor < len(b.messages):
+		b.inspector.show("", b.messages[b.cursor])
+	default:
+		b.inspector.clear()
+	}
+
+	b.list.SetRect(0, 3, width, 3+listHeight)
+	b.inspector.SetRect(0, 3+listHeight, width, height)
+	drawWidgets(b.list, b.inspector.properties, b.inspector.payload)
+}
+
+// isText reports whether a payload is UTF-8 without control characters
+// other than line breaks and tabs, which would garble the screen.
+func isText(payload []byte) bool {
+	if !utf8.Valid(payload) {
+		return false
+	}
+	for _, r := range string(payload) {
+		if unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,362 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// bubbleteaFrontend is the alternative interactive UI built on bubbletea
+// and lipgloss. It covers the queue table, the warning banner, the status
+// line and alerts; the other pages are termui-only for now.
+type bubbleteaFrontend struct{}
+
+func (bubbleteaFrontend) Run(ctx context.Context, s *session) error {
+	program := tea.NewProgram(newTeaModel(s), tea.WithAltScreen(), tea.WithContext(ctx))
+	if _, err := program.Run(); err != nil && !(errors.Is(err, tea.ErrProgramKilled) && ctx.Err() != nil) {
+		return fmt.Errorf("bubbletea renderer failed: %w", err)
+	}
+	return nil
+}
+
+// tickMsg is a refresh tick. Ticks from before the last interval change
+// carry an old generation and are dropped.
+type tickMsg struct{ generation int }
+
+// flashOffMsg redraws once changed cells should stop being highlighted.
+type flashOffMsg struct{}
+
+type annotationMsg clusterAnnotation
+
+// fetchedMsg is one dashboard's first poll.
+type fetchedMsg fetched
+
+var (
+	teaHeader   = lipgloss.NewStyle().Foreground(lipgloss.Color("0")).Background(lipgloss.Color("3"))
+	teaSelected = lipgloss.NewStyle().Foreground(lipgloss.Color("7")).Background(lipgloss.Color("4")).Bold(true)
+	teaTitle    = lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Bold(true)
+	teaAlarm    = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true)
+	teaWarning  = lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Bold(true)
+	teaOK       = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+)
+
+type teaModel struct {
+	session *session
+	ui      uiState
+	focused int
+
+	columns []queueColumn
+	frozen  int
+	tiers   []TierConfig
+	sort    []sortKey
+	top     topMode
+	topN    int
+
+	// selected is the key of the highlighted queue; cursor and offset are
+	// its row index and the first visible row after the last view.
+	selected string
+	cursor   int
+	offset   int
+
+	width, height int
+	generation    int
+	// pending counts the first polls still being fetched.
+	pending int
+}
+
+func newTeaModel(s *session) *teaModel {
+	// LoadConfig has already rejected unknown column names.
+	columns, _ := resolveQueueColumns(s.config.UI.Columns)
+	return &teaModel{
+		session: s,
+		ui:      uiState{interval: time.Duration(s.config.UI.RefreshSeconds) * time.Second},
+		columns: columns,
+		frozen:  s.config.UI.FrozenColumns,
+		tiers:   s.config.Tiers,
+		sort:    s.config.queueSort(),
+		topN:    s.config.UI.TopN,
+		pending: len(s.dashboards),
+		width:   80,
+		height:  24,
+	}
+}
+
+func (m *teaModel) Init() tea.Cmd {
+	cmds := []tea.Cmd{m.tick(), m.waitAnnotation(), flashOff()}
+	// Each cluster is drawn as soon as its first poll is in.
+	for _, d := range m.session.dashboards {
+		cmds = append(cmds, func() tea.Msg { return fetchedMsg{d, d.fetch()} })
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m *teaModel) tick() tea.Cmd {
+	generation := m.generation
+	return tea.Tick(m.ui.interval, func(time.Time) tea.Msg { return tickMsg{generation} })
+}
+
+func (m *teaModel) waitAnnotation() tea.Cmd {
+	return func() tea.Msg { return annotationMsg(<-m.session.annotated) }
+}
+
+func flashOff() tea.Cmd {
+	return tea.Tick(flashDuration, func(time.Time) tea.Msg { return flashOffMsg{} })
+}
+
+func (m *teaModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tickMsg:
+		if msg.generation != m.generation {
+			return m, nil
+		}
+		if m.ui.paused || m.pending > 0 {
+			return m, m.tick()
+		}
+		m.session.pollAll()
+		return m, tea.Batch(m.tick(), flashOff())
+	case fetchedMsg:
+		msg.d.apply(msg.r)
+		m.pending--
+	case annotationMsg:
+		m.session.annotate(clusterAnnotation(msg))
+		return m, m.waitAnnotation()
+	case tea.KeyMsg:
+		return m, m.handleKey(msg.String())
+	}
+	return m, nil
+}
+
+func (m *teaModel) handleKey(key string) tea.Cmd {
+	switch key {
+	case "q", "ctrl+c":
+		return tea.Quit
+	case "p":
+		m.ui.paused = !m.ui.paused
+	case "r":
+		if m.pending > 0 {
+			return nil
+		}
+		m.session.pollAll()
+		return flashOff()
+	case "+", "-":
+		dir := 1
+		if key == "-" {
+			dir = -1
+		}
+		m.ui.interval = stepRefresh(m.ui.interval, dir)
+		m.generation++
+		return m.tick()
+	case "c":
+		m.focused = (m.focused + 1) % len(m.session.dashboards)
+		m.selected = ""
+	case "t":
+		m.top = (m.top + 1) % 3
+	case "j", "down":
+		m.cursor++
+		m.selected = ""
+	case "k", "up":
+		m.cursor--
+		m.selected = ""
+	}
+	return nil
+}
+
+// selectQueue keeps the selection on the same queue across polls, as
+// queueView.selectQueue does, and scrolls it into view.
+func (m *teaModel) selectQueue(queues []QueueInfo, pageRows int) {
+	if m.selected != "" {
+		for i, q := range queues {
+			if q.Key() == m.selected {
+				m.offset += i - m.cursor
+				m.cursor = i
+				break
+			}
+		}
+	}
+	if m.cursor >= len(queues) {
+		m.cursor = len(queues) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	if len(queues) > 0 {
+		m.selected = queues[m.cursor].Key()
+	}
+
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+pageRows {
+		m.offset = m.cursor - pageRows + 1
+	}
+	if m.offset > len(queues)-pageRows {
+		m.offset = len(queues) - pageRows
+	}
+	if m.offset < 0 {
+		m.offset = 0
+	}
+}
+
+func (m *teaModel) View() string {
+	d := m.session.dashboards[m.focused]
+	var lines []string
+	lines = append(lines, teaTitle.Render(" "+d.name+" ")+" "+ansiMarkup(clusterSummary(d.queues, d.overview), true))
+	if partitions := nodePartitions(d.nodes); len(partitions) > 0 {
+		lines = append(lines, teaWarning.Render(partitionText(partitions)))
+	}
+	if alarms := nodeAlarms(d.nodes); len(alarms) > 0 {
+		lines = append(lines, teaAlarm.Render(alarmText(alarms)))
+	}
+	if text := blockedText(d); text != "" {
+		lines = append(lines, teaAlarm.Render(text))
+	}
+
+	queues := sortByTier(m.tiers, topQueues(m.tiers, sortQueues(m.sort, d.queues), m.top, m.topN))
+	// The summary, banner, table title, header, status and alert lines.
+	pageRows := m.height - len(lines) - 5
+	if pageRows < 1 {
+		pageRows = 1
+	}
+	m.selectQueue(queues, pageRows)
+	end := m.offset + pageRows
+	if end > len(queues) {
+		end = len(queues)
+	}
+
+	title := ""
+	switch m.top {
+	case topBacklog:
+		title = fmt.Sprintf(" Top %d by backlog ", m.topN)
+	case topImbalance:
+		title = fmt.Sprintf(" Top %d by publish/deliver imbalance ", m.topN)
+	}
+	if m.top == topOff && len(m.sort) > 0 {
+		title = fmt.Sprintf(" Sorted by %s ", sortDescription(m.sort))
+	}
+	if len(queues) > pageRows {
+		title += fmt.Sprintf(" %d-%d of %d ", m.offset+1, end, len(queues))
+	}
+	lines = append(lines, teaTitle.Render(title))
+
+	visible, widths := layoutColumns(m.columns, m.frozen, 0, m.width)
+	header := make([]string, len(visible))
+	for k, i := range visible {
+		header[k] = padCell(truncateString(m.columns[i].header, widths[k]), widths[k])
+	}
+	lines = append(lines, teaHeader.Render(strings.Join(header, " ")))
+
+	counts := bindingCounts(d.bindings)
+	lags := streamLags(d.streamConsumers, d.queues)
+	flash := d.flashing()
+	for i, queue := range queues[m.offset:end] {
+		prev, seen := d.previous[queue.Key()]
+		cell := queueCell{queue: queue, prev: prev, flash: flash && seen, bindings: counts[queue.Key()], lag: lags[queue.Key()]}
+		_, cell.behind = d.behind[queue.Key()]
+		cells := queueRow(m.columns, visible, widths, cell)
+		if m.offset+i == m.cursor {
+			for k := range cells {
+				cells[k] = padCell(ansiMarkup(cells[k], false), widths[k])
+			}
+			lines = append(lines, teaSelected.Render(strings.Join(cells, " ")))
+			continue
+		}
+		style, styled := lipgloss.Style{}, false
+		if t := queueTier(m.tiers, queue); t < len(m.tiers) {
+			style, styled = m.tiers[t].lipglossStyle()
+		}
+		for k := range cells {
+			if styled && !styleMarkup.MatchString(cells[k]) {
+				cells[k] = style.Render(cells[k])
+			} else {
+				cells[k] = ansiMarkup(cells[k], true)
+			}
+			cells[k] = padCell(cells[k], widths[k])
+		}
+		lines = append(lines, strings.Join(cells, " "))
+	}
+	for i := end - m.offset; i < pageRows; i++ {
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, m.statusLine(d), alertLine(d.activeAlerts))
+	return strings.Join(lines, "\n")
+}
+
+// statusLine is the bubbletea counterpart of statusBar's last-updated line.
+func (m *teaModel) statusLine(d *dashboard) string {
+	status := "Last updated: N/A"
+	if d.lastUpdate.IsZero() && d.lastErr == nil {
+		status = "Loading..."
+	}
+	if !d.lastUpdate.IsZero() {
+		status = fmt.Sprintf("Last updated: %s", d.lastUpdate.Format("2006-01-02 15:04:05"))
+	}
+	status += fmt.Sprintf(" (every %s)", m.ui.interval)
+	if m.ui.paused {
+		status += "  [ PAUSED ](fg:black,bg:red,mod:bold)"
+	}
+	if readOnly := readOnlySummary(d); readOnly != "" {
+		status += "  " + readOnly
+	}
+	if flow := flowSummary(d); flow != "" {
+		status += "  " + flow
+	}
+	if firehose := tracingSummary(d); firehose != "" {
+		status += "  " + firehose
+	}
+	if jobs := jobSummary(d); jobs != "" {
+		status += "  " + jobs
+	}
+	if d.notice != "" && time.Now().Before(d.noticeUntil) {
+		status += "  " + d.notice
+	}
+	if d.lastErr != nil {
+		status += fmt.Sprintf("  [%s](fg:red)", d.lastErr)
+	}
+	return ansiMarkup(status, true)
+}
+
+// alertLine mirrors renderAlerts: the top alert and how many more there are.
+func alertLine(alerts []Alert) string {
+	if len(alerts) == 0 {
+		return teaOK.Render("No error queues detected.")
+	}
+	top := alerts[0]
+	label := "ALERT"
+	if top.Severity == SeverityCritical {
+		label = "CRITICAL"
+	}
+	text := fmt.Sprintf("%s: %s", label, top.Message)
+	if len(alerts) > 1 {
+		text += fmt.Sprintf(" (+%d more)", len(alerts)-1)
+	}
+	return teaAlarm.Render(text)
+}
+
+// padCell pads s with spaces to width w, ignoring escape codes.
+func padCell(s string, w int) string {
+	if n := lipgloss.Width(s); n < w {
+		return s + strings.Repeat(" ", w-n)
+	}
+	return s
+}
+
+// lipglossStyle is style for the bubbletea renderer.
+func (t TierConfig) lipglossStyle() (lipgloss.Style, bool) {
+	if t.Color == "" && !t.Bold {
+		return lipgloss.Style{}, false
+	}
+	style := lipgloss.NewStyle().Bold(t.Bold)
+	if t.Color != "" {
+		style = style.Foreground(lipgloss.Color(strconv.Itoa(int(tierColors[t.Color]))))
+	}
+	return style, true
+}
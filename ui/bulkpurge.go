@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// bulkPurgeName is typed to confirm a purge of the error queues, which
+// have no single name. A purge by pattern is confirmed by typing the
+// pattern.
+const bulkPurgeName = "error queues"
+
+// bulkPurgeTargets are the queues a bulk purge empties: those whose name
+// matches pattern, or the error queues when it is empty, that have ready
+// messages.
+func bulkPurgeTargets(queues []QueueInfo, pattern string) ([]QueueInfo, error) {
+	match := isErrorQueue
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		match = re.MatchString
+	}
+	var targets []QueueInfo
+	for _, q := range queues {
+		if match(q.Name) && q.MessagesReady > 0 {
+			targets = append(targets, q)
+		}
+	}
+	return targets, nil
+}
+
+// bulkPurgeSummary lists the queues a bulk purge would empty and how many
+// messages it would remove.
+func bulkPurgeSummary(targets []QueueInfo) string {
+	total := 0
+	names := make([]string, len(targets))
+	for i, q := range targets {
+		total += q.MessagesReady
+		names[i] = fmt.Sprintf("%s (%s)", q.Key(), groupDigits(q.MessagesReady))
+	}
+	if len(targets) == 0 {
+		return "No matching queue has ready messages."
+	}
+	return fmt.Sprintf("Would purge %s ready messages from %d queues: %s", groupDigits(total), len(targets), truncateList(names, 8))
+}
+
+// expectPurgedAll checks every queue of a bulk purge as expectPurged does,
+// and passes once all of them do.
+func expectPurgedAll(before []QueueInfo) verification {
+	return verification{check: func(queues []QueueInfo) (string, bool) {
+		purged := 0
+		for _, q := range before {
+			report, ok := expectPurged(q).check(queues)
+			if !ok {
+				return report, false
+			}
+			if after, found := findQueue(queues, q.Key()); found {
+				purged += max(q.MessagesReady-after.MessagesReady, 0)
+			}
+		}
+		return fmt.Sprintf("purged %s messages from %d queues", groupDigits(purged), len(before)), true
+	}}
+}
+
+// bulkPurgeForm asks which queues to purge at once, the error queues by
+// default, listing them and their messages as the pattern is typed. purge
+// is given the queues to confirm.
+func (d *dashboard) bulkPurgeForm(purge func(pattern string, targets []QueueInfo)) *form {
+	f := newForm("Purge matching queues", []*formField{
+		{label: "Pattern", hint: "regexp on queue names; empty for error queues"},
+	}, func(values map[string]string) error {
+		targets, err := bulkPurgeTargets(d.queues, values["Pattern"])
+		if err != nil {
+			return err
+		}
+		if len(targets) == 0 {
+			return fmt.Errorf("no matching queue has ready messages")
+		}
+		purge(values["Pattern"], targets)
+		return nil
+	})
+	f.preview = func(values map[string]string) string {
+		targets, err := bulkPurgeTargets(d.queues, values["Pattern"])
+		if err != nil {
+			return "[Pattern: " + err.Error() + "](fg:red)"
+		}
+		return bulkPurgeSummary(targets)
+	}
+	return f
+}
+
+// startBulkPurge purges queues one after the other as a job, going on past
+// the ones that fail, and checks the polls after it for all to be empty.
+func (d *dashboard) startBulkPurge(targets []QueueInfo) {
+	config := d.config
+	j := d.startJob(fmt.Sprintf("Purge %d queues", len(targets)), len(targets), func(ctx context.Context, j *job) error {
+		var failed []QueueInfo
+		for _, q := range targets {
+			if ctx.Err() != nil {
+				return nil
+			}
+			err := purgeQueue(config, q)
+			if err != nil {
+				failed = append(failed, q)
+			}
+			j.update(func() {
+				j.done++
+				if err != nil {
+					j.failed++
+				}
+			})
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("purging %s failed", failed[0].Key())
+		}
+		return nil
+	})
+	v := expectPurgedAll(targets)
+	j.verify = &v
+}
@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+)
+
+// channelsView lists channels with their consumers, prefetch and the
+// messages they hold unacknowledged, most first. form is the close dialog,
+// if open.
+type channelsView struct {
+	*listView
+	form *form
+}
+
+func newChannelsView() *channelsView {
+	v := &channelsView{}
+	v.listView = newListView("Channels",
+		[]string{"Channel", "User", "VHost", "State", "Consumers", "Prefetch", "Unacked", "Deliver/s", "Ack/s"},
+		func(width int) []int { return spreadWidths(width, 0, 12, 10, 9, 10, 9, 9, 10, 8) },
+		v.rows)
+	return v
+}
+
+func (v *channelsView) rows(d *dashboard) ([]listRow, string) {
+	channels := append([]ChannelInfo(nil), d.channels...)
+	sort.SliceStable(channels, func(i, j int) bool {
+		if a, b := channels[i].MessagesUnacknowledged, channels[j].MessagesUnacknowledged; a != b {
+			return a > b
+		}
+		return channels[i].Name < channels[j].Name
+	})
+
+	var rows []listRow
+	for _, ch := range channels {
+		stats := ch.MessageStats
+		prefetch := fmt.Sprint(ch.PrefetchCount)
+		if ch.PrefetchCount == 0 {
+			prefetch = "∞"
+		}
+		// A consumer with unacked messages that acks nothing is stuck, or
+		// holding them on purpose.
+		stuck := ch.MessagesUnacknowledged > 0 && stats.AckDetails.Rate == 0 && stats.DeliverDetails.Rate == 0
+		detail := fmt.Sprintf("Channel %d of %s, user %s on %s; %d consumers, prefetch %s.\n%s unacknowledged, %s unconfirmed, %s uncommitted; delivering %.1f msg/s, acking %.1f msg/s.",
+			ch.Number, ch.ConnectionDetails.Name, ch.User, ch.Node, ch.Consumers, prefetch,
+			groupDigits(ch.MessagesUnacknowledged), groupDigits(ch.MessagesUnconfirmed), groupDigits(ch.MessagesUncommitted),
+			stats.DeliverDetails.Rate, stats.AckDetails.Rate)
+		if stuck {
+			detail += "\n[Holds unacked messages and acks none.](fg:red)"
+		}
+		detail += "\nD closes it; the broker can only do that by closing its connection."
+		rows = append(rows, listRow{
+			cells: []string{
+				ch.Name, ch.User, ch.VHost, ch.State, fmt.Sprint(ch.Consumers), prefetch, groupDigits(ch.MessagesUnacknowledged),
+				rateCell(stats.DeliverDetails.Rate), rateCell(stats.AckDetails.Rate),
+			},
+			broken: stuck,
+			detail: detail,
+			key:    ch.Name,
+		})
+	}
+	return rows, "No channels."
+}
+
+func (v *channelsView) Render(d *dashboard, ui uiState) {
+	v.listView.Render(d, ui)
+	if v.form != nil {
+		v.form.Render()
+	}
+}
+
+func (v *channelsView) HandleKey(d *dashboard, id string) bool {
+	if v.form != nil {
+		if v.form.Feed(id) {
+			v.form = nil
+		}
+		return true
+	}
+	if id == "D" {
+		if d.readOnly("closing connections") {
+			return true
+		}
+		if row, ok := v.selectedRow(d); ok {
+			for _, ch := range d.channels {
+				if ch.Name == row.key {
+					v.form = d.closeChannelForm(ch)
+				}
+			}
+		}
+		return true
+	}
+	return v.listView.HandleKey(d, id)
+}
+
+// Capturing reports whether the close dialog is open.
+func (v *channelsView) Capturing() bool {
+	return v.form != nil
+}
+
+// closeChannelForm is asked to close one channel. The management API, like
+// rabbitmqctl, can only close whole connections: channel.close is only
+// sent by the client or by the broker on a channel error. So rather than
+// pretend, it offers to close the channel's connection, and the notice says
+// how many other channels go with it.
+func (d *dashboard) closeChannelForm(ch ChannelInfo) *form {
+	for _, c := range d.connections {
+		if c.Name != ch.ConnectionDetails.Name {
+			continue
+		}
+		d.setNotice("[The management API cannot close channel %d alone; closing connection %s also closes its other %d channels](fg:yellow)",
+			ch.Number, c.Name, max(c.Channels-1, 0))
+		return d.closeForm(c)
+	}
+	d.setNotice("[Connection %s of channel %d is no longer listed](fg:yellow)", ch.ConnectionDetails.Name, ch.Number)
+	return nil
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ClipboardConfig sets how y copies. Command, when set, is run with the
+// text on its standard input, e.g. ["xclip", "-selection", "clipboard"].
+// Otherwise the platform's clipboard tool is used when there is one, and
+// the terminal is asked to copy with an OSC 52 sequence when there is not,
+// which also works over SSH.
+type ClipboardConfig struct {
+	Command []string `json:"command"`
+}
+
+// clipboardCommands are tried in order, each when its condition holds.
+var clipboardCommands = []struct {
+	when func() bool
+	args []string
+}{
+	{func() bool { return runtime.GOOS == "darwin" }, []string{"pbcopy"}},
+	{func() bool { return runtime.GOOS == "windows" }, []string{"clip.exe"}},
+	{func() bool { return os.Getenv("WAYLAND_DISPLAY") != "" }, []string{"wl-copy"}},
+	{func() bool { return os.Getenv("DISPLAY") != "" }, []string{"xclip", "-selection", "clipboard"}},
+	{func() bool { return os.Getenv("DISPLAY") != "" }, []string{"xsel", "--clipboard", "--input"}},
+}
+
+// copyToClipboard copies text and tells how.
+func copyToClipboard(config ClipboardConfig, text []byte) (string, error) {
+	if len(config.Command) > 0 {
+		return config.Command[0], runClipboard(config.Command, text)
+	}
+	for _, c := range clipboardCommands {
+		if !c.when() {
+			continue
+		}
+		if _, err := exec.LookPath(c.args[0]); err == nil {
+			return c.args[0], runClipboard(c.args, text)
+		}
+	}
+	return "OSC 52", osc52(text)
+}
+
+// runClipboard pipes text to a clipboard tool. Its output is not read:
+// xclip and wl-copy leave a process behind holding the selection, which
+// would keep the pipe open.
+func runClipboard(args []string, text []byte) error {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(text)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	return nil
+}
+
+// osc52 has the terminal put text on the clipboard. tmux only passes the
+// sequence on to the terminal wrapped in its own.
+func osc52(text []byte) error {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString(text) + "\a"
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	_, err := os.Stdout.WriteString(seq)
+	return err
+}
+
+// copyText copies text, what it is for the notice, to the clipboard.
+func (d *dashboard) copyText(what string, text []byte) {
+	how, err := copyToClipboard(d.config.Clipboard, text)
+	if err != nil {
+		d.setNotice("[Copying %s failed: %s](fg:red)", what, err)
+		return
+	}
+	d.setNotice("Copied %s (%s, %s)", what, formatBytes(int64(len(text))), how)
+}
@@ -0,0 +1,240 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// queueCell is what a column needs to draw one queue's value.
+type queueCell struct {
+	queue    QueueInfo
+	prev     QueueInfo
+	flash    bool
+	bindings int
+	// lag is the stream's largest consumer group lag.
+	lag int64
+	// behind is set while the queue's consumers are falling behind.
+	behind bool
+}
+
+// queueColumn is one column of the queue table. Columns never shrink below
+// minWidth; those marked grow share whatever width is left over, and a wide
+// column takes a third of the table.
+type queueColumn struct {
+	id       string
+	header   string
+	minWidth int
+	grow     bool
+	wide     bool
+	value    func(c queueCell, width int) string
+}
+
+func countCell(c queueCell, styled string, now, before int) string {
+	return flashCell(styled, fmt.Sprintf("%d", now), int64(now), int64(before), c.flash)
+}
+
+func rateCell(rate float64) string {
+	return fmt.Sprintf("%.1f", rate)
+}
+
+var queueColumns = []queueColumn{
+	{"name", "Queue Name", 20, false, true, func(c queueCell, width int) string {
+		if c.behind {
+			return "[⇣](fg:red,mod:bold)" + truncateString(c.queue.Key(), width-1)
+		}
+		return truncateString(c.queue.Key(), width)
+	}},
+	{"type", "T", 2, false, false, func(c queueCell, _ int) string {
+		return safeGetFirstChar(c.queue.Type)
+	}},
+	{"state", "S", 2, false, false, func(c queueCell, _ int) string {
+		return getStateIndicator(queueState(c.queue))
+	}},
+	{"ready", "Ready", 7, true, false, func(c queueCell, _ int) string {
+		if c.queue.Type == "stream" {
+			return "-"
+		}
+		return countCell(c, colorizeNumber(c.queue.MessagesReady), c.queue.MessagesReady, c.prev.MessagesReady)
+	}},
+	{"unacked", "Unacked", 7, true, false, func(c queueCell, _ int) string {
+		if c.queue.Type == "stream" {
+			return "-"
+		}
+		return countCell(c, colorizeNumber(c.queue.MessagesUnack), c.queue.MessagesUnack, c.prev.MessagesUnack)
+	}},
+	{"total", "Total", 7, true, false, func(c queueCell, _ int) string {
+		return countCell(c, colorizeNumber(c.queue.Messages), c.queue.Messages, c.prev.Messages)
+	}},
+	{"in", "In", 7, true, false, func(c queueCell, _ int) string {
+		s := c.queue.MessageStats.Publish
+		return countCell(c, fmt.Sprintf("%d", s), s, c.prev.MessageStats.Publish)
+	}},
+	{"deliver", "D/G", 7, true, false, func(c queueCell, _ int) string {
+		s := c.queue.MessageStats.DeliverGet
+		return countCell(c, fmt.Sprintf("%d", s), s, c.prev.MessageStats.DeliverGet)
+	}},
+	{"ack", "Ack", 7, true, false, func(c queueCell, _ int) string {
+		s := c.queue.MessageStats.Ack
+		return countCell(c, fmt.Sprintf("%d", s), s, c.prev.MessageStats.Ack)
+	}},
+	{"memory", "Mem", 8, true, false, func(c queueCell, _ int) string {
+		m := formatBytes(c.queue.Memory)
+		return flashCell(m, m, c.queue.Memory, c.prev.Memory, c.flash)
+	}},
+	{"bindings", "Bnd", 4, true, false, func(c queueCell, _ int) string {
+		return fmt.Sprintf("%d", c.bindings)
+	}},
+	{"consumers", "Cons", 5, true, false, func(c queueCell, _ int) string {
+		return countCell(c, fmt.Sprintf("%d", c.queue.Consumers), c.queue.Consumers, c.prev.Consumers)
+	}},
+	{"publish_rate", "Pub/s", 7, true, false, func(c queueCell, _ int) string {
+		return rateCell(c.queue.MessageStats.PublishDetails.Rate)
+	}},
+	{"deliver_rate", "Del/s", 7, true, false, func(c queueCell, _ int) string {
+		return rateCell(c.queue.MessageStats.DeliverGetDetails.Rate)
+	}},
+	{"ack_rate", "Ack/s", 7, true, false, func(c queueCell, _ int) string {
+		return rateCell(c.queue.MessageStats.AckDetails.Rate)
+	}},
+	{"node", "Node", 12, true, false, func(c queueCell, _ int) string {
+		return c.queue.Node
+	}},
+	{"offset", "Offset", 10, true, false, func(c queueCell, _ int) string {
+		if c.queue.Type != "stream" {
+			return ""
+		}
+		return streamOffset(c.queue)
+	}},
+	{"segments", "Segs", 5, true, false, func(c queueCell, _ int) string {
+		if c.queue.Type != "stream" {
+			return ""
+		}
+		return fmt.Sprintf("%d", c.queue.Segments)
+	}},
+	{"lag", "Lag", 7, true, false, func(c queueCell, _ int) string {
+		if c.queue.Type != "stream" {
+			return ""
+		}
+		return colorizeNumber(int(c.lag))
+	}},
+	{"args", "Args", 18, true, false, func(c queueCell, width int) string {
+		return badgesCell(queueBadges(c.queue), width)
+	}},
+}
+
+// queueColumnHelp explains each column and the management API field it is
+// read from, for the ? legend. Counters are totals since the queue started.
+var queueColumnHelp = map[string]string{
+	"name":         "The queue's vhost and name; ⇣ marks consumers falling behind the publish rate. Source: vhost, name",
+	"type":         "Queue type by its first letter: c classic, q quorum, s stream. Source: type",
+	"state":        "✓ running, ◦ idle, ≈ flow control, ! members offline, ✗ down. Source: state, idle_since, online",
+	"ready":        "Messages waiting to be delivered; - for streams, which keep them once read. Source: messages_ready",
+	"unacked":      "Messages delivered to consumers and not yet acknowledged. Source: messages_unacknowledged",
+	"total":        "Ready plus unacked messages. Source: messages",
+	"in":           "Messages published to the queue. Source: message_stats.publish",
+	"deliver":      "Messages delivered to consumers or fetched with basic.get. Source: message_stats.deliver_get",
+	"ack":          "Messages acknowledged by consumers. Source: message_stats.ack",
+	"memory":       "Memory used by the queue, including messages held in RAM. Source: memory",
+	"bindings":     "Bindings to the queue, leaving out the default exchange's. Source: /api/bindings",
+	"consumers":    "Consumers subscribed to the queue. Source: consumers",
+	"publish_rate": "Messages published per second. Source: message_stats.publish_details.rate",
+	"deliver_rate": "Messages delivered or fetched per second. Source: message_stats.deliver_get_details.rate",
+	"ack_rate":     "Messages acknowledged per second. Source: message_stats.ack_details.rate",
+	"node":         "The node the queue, or its leader, runs on. Source: node",
+	"offset":       "Streams: the last offset committed to a quorum of members. Source: committed_offset",
+	"segments":     "Streams: how many segment files the stream is stored in. Source: segments",
+	"lag":          "Streams: how far the most lagging consumer group is behind. Source: stream consumers' offset_lag",
+	"args":         "TTL, length limits, dead-lettering and other settings. Source: arguments, effective_policy_definition",
+}
+
+var defaultQueueColumns = []string{
+	"name", "type", "state", "ready", "unacked", "total", "in", "deliver", "ack", "memory", "bindings",
+}
+
+func queueColumnIDs() []string {
+	ids := make([]string, len(queueColumns))
+	for i, column := range queueColumns {
+		ids[i] = column.id
+	}
+	return ids
+}
+
+func resolveQueueColumns(ids []string) ([]queueColumn, error) {
+	columns := make([]queueColumn, 0, len(ids))
+	for _, id := range ids {
+		found := false
+		for _, column := range queueColumns {
+			if column.id == id {
+				columns = append(columns, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q (known: %s)", id, strings.Join(queueColumnIDs(), ", "))
+		}
+	}
+	return columns, nil
+}
+
+// queueRow draws one queue's cells for the visible columns, as returned by
+// layoutColumns.
+func queueRow(columns []queueColumn, visible, widths []int, cell queueCell) []string {
+	row := make([]string, len(visible))
+	for k, col := range visible {
+		row[k] = columns[col].value(cell, widths[k])
+	}
+	return row
+}
+
+// layoutColumns picks the columns that fit in a table of the given outer
+// width. The first frozen columns are always shown; the others start at
+// offset and stop at the first one that does not fit. It returns the indexes
+// of the visible columns and their widths.
+func layoutColumns(columns []queueColumn, frozen, offset, width int) ([]int, []int) {
+	inner := width - 2
+	var visible, widths []int
+	used := 0
+	add := func(i int) bool {
+		w := columns[i].minWidth
+		if columns[i].wide && width/3 > w {
+			w = width / 3
+		}
+		if len(visible) > 0 && used+w+1 > inner {
+			return false
+		}
+		visible = append(visible, i)
+		widths = append(widths, w)
+		used += w + 1
+		return true
+	}
+
+	for i := 0; i < frozen && i < len(columns); i++ {
+		add(i)
+	}
+	for i := frozen + offset; i < len(columns); i++ {
+		if !add(i) {
+			break
+		}
+	}
+
+	growing := 0
+	for _, i := range visible {
+		if columns[i].grow {
+			growing++
+		}
+	}
+	if spare := inner - used; spare > 0 && growing > 0 {
+		n := 0
+		for k, i := range visible {
+			if columns[i].grow {
+				widths[k] += spare / growing
+				if n < spare%growing {
+					widths[k]++
+				}
+				n++
+			}
+		}
+	}
+	return visible, widths
+}
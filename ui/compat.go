@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/gizak/termui/v3"
+)
+
+// asciiMode draws everything with ASCII replacements for box-drawing,
+// block, braille and arrow characters, which the legacy Windows console
+// shows as question marks or draws at two columns, breaking the layout.
+// termui draws to the one terminal there is, so this follows the session
+// running the termui frontend, from its Options.ASCII, while it runs.
+var asciiMode bool
+
+// resizeCheckInterval is how often the Windows console window is measured,
+// since it doesn't report being resized.
+const resizeCheckInterval = 500 * time.Millisecond
+
+// LegacyConsole reports whether rabbitspy is running in the classic Windows
+// console host rather than Windows Terminal or a terminal emulator, where
+// ASCII mode is the default.
+func LegacyConsole() bool {
+	return runtime.GOOS == "windows" && os.Getenv("WT_SESSION") == "" && os.Getenv("TERM_PROGRAM") == "" && os.Getenv("TERM") == ""
+}
+
+// asciiRunes are the replacements for the characters rabbitspy draws itself;
+// asciiRune covers the rest of each block by range.
+var asciiRunes = map[rune]rune{
+	'─': '-', '━': '-', '│': '|', '┃': '|', '▕': '|', '▏': '|',
+	'█': '#', '▓': '#', '▒': '=', '░': '.',
+	'▲': '^', '▼': 'v', '↑': '^', '↓': 'v', '←': '<', '→': '>', '⇄': '=',
+	'‹': '<', '›': '>', '«': '<', '»': '>',
+	'•': '*', '·': '-', '…': '~', '≈': '~', '−': '-',
+	'✗': 'x', '✓': 'v', '⚠': '!',
+}
+
+func asciiRune(r rune) rune {
+	if r < 0x80 {
+		return r
+	}
+	if a, ok := asciiRunes[r]; ok {
+		return a
+	}
+	switch {
+	case r >= 0x2500 && r <= 0x257F: // box drawing
+		return '+'
+	case r >= 0x2581 && r <= 0x2583: // low blocks, as in sparklines
+		return '_'
+	case r >= 0x2584 && r <= 0x2587:
+		return '='
+	case r >= 0x2580 && r <= 0x259F: // other blocks and shades
+		return '#'
+	case r >= 0x2800 && r <= 0x28FF: // braille, as in line plots
+		if r == 0x2800 {
+			return ' '
+		}
+		return '.'
+	case r >= 0x2190 && r <= 0x21FF: // arrows
+		return '>'
+	}
+	return r
+}
+
+// asciiDrawable draws a widget and then swaps its characters for ASCII.
+type asciiDrawable struct {
+	termui.Drawable
+}
+
+func (d asciiDrawable) Draw(buf *termui.Buffer) {
+	d.Drawable.Draw(buf)
+	for p, cell := range buf.CellMap {
+		if r := asciiRune(cell.Rune); r != cell.Rune {
+			cell.Rune = r
+			buf.CellMap[p] = cell
+		}
+	}
+}
+
+// drawWidgets draws items with termui, in ASCII when asciiMode is set.
+// Views use it instead of termui.Render.
+func drawWidgets(items ...termui.Drawable) {
+	if asciiMode {
+		wrapped := make([]termui.Drawable, len(items))
+		for i, item := range items {
+			wrapped[i] = asciiDrawable{item}
+		}
+		items = wrapped
+	}
+	termui.Render(items...)
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configExtensions are the configuration file names looked for in each
+// directory, in order.
+var configExtensions = []string{".json", ".yaml", ".yml", ".toml"}
+
+// configJSON turns a YAML or TOML configuration, chosen by the file's
+// extension, into the JSON the Config struct is decoded from, so the field
+// names and defaults are the same whatever the format. Other files are
+// taken as JSON.
+func configJSON(filename string, data []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	default:
+		return data, nil
+	}
+	return json.Marshal(quotePorts(doc))
+}
+
+// quotePorts turns the numbers given for ports into strings, as the JSON
+// configuration writes them. YAML and TOML files rarely quote them.
+func quotePorts(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			switch value.(type) {
+			case int, int64, uint64, float64:
+				if key == "port" || strings.HasSuffix(key, "_port") {
+					v[key] = fmt.Sprint(value)
+				}
+			default:
+				v[key] = quotePorts(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = quotePorts(value)
+		}
+	case []map[string]interface{}:
+		for _, value := range v {
+			quotePorts(value)
+		}
+	}
+	return v
+}
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ConfirmConfig sets which actions ask for confirmation, and how. Actions
+// maps an action to "typed", where the name of its target has to be typed,
+// "yes", where typing y is enough, or "none", where it runs straight away;
+// actions left out keep their usual confirmation. Strict has every action
+// typed, whatever Actions says, for production clusters.
+type ConfirmConfig struct {
+	Actions map[string]string `json:"actions"`
+	Strict  bool              `json:"strict"`
+}
+
+const (
+	confirmTyped = "typed"
+	confirmYes   = "yes"
+	confirmNone  = "none"
+)
+
+// confirmActions are the actions whose confirmation can be configured,
+// with how each is confirmed by default: the ones that lose messages or
+// objects are typed, those with no form of their own that act on a whole
+// queue or cluster at one key press need y, and the others run once their
+// form is submitted.
+var confirmActions = map[string]string{
+	"purge":           confirmTyped,
+	"bulk_purge":      confirmTyped,
+	"delete_queue":    confirmTyped,
+	"delete_exchange": confirmTyped,
+	"delete_policy":   confirmTyped,
+	"browse":          confirmTyped,
+	"requeue":         confirmTyped,
+	"reprocess":       confirmTyped,
+	"drain":           confirmTyped,
+	"import":          confirmTyped,
+	"firehose":        confirmTyped,
+	"publish":         confirmNone,
+	"replay":          confirmNone,
+	"move":            confirmNone,
+	"close":           confirmNone,
+	"declare":         confirmNone,
+	"bind":            confirmNone,
+	"unbind":          confirmNone,
+	"policy":          confirmNone,
+	"shovel":          confirmNone,
+	"sync":            confirmYes,
+	"rebalance":       confirmYes,
+}
+
+func validateConfirm(c *ConfirmConfig) error {
+	for action, mode := range c.Actions {
+		if _, ok := confirmActions[action]; !ok {
+			known := make([]string, 0, len(confirmActions))
+			for a := range confirmActions {
+				known = append(known, a)
+			}
+			sort.Strings(known)
+			return fmt.Errorf("unknown action %q (known: %s)", action, strings.Join(known, ", "))
+		}
+		if mode != confirmTyped && mode != confirmYes && mode != confirmNone {
+			return fmt.Errorf("%s: unknown confirmation %q (known: typed, yes, none)", action, mode)
+		}
+	}
+	return nil
+}
+
+// mode is how action is confirmed.
+func (c ConfirmConfig) mode(action string) string {
+	if c.Strict {
+		return confirmTyped
+	}
+	if mode, ok := c.Actions[action]; ok {
+		return mode
+	}
+	return confirmActions[action]
+}
+
+// confirm applies the confirmation policy of action to c: it is returned
+// to be typed as it is, or to be answered with y, or run at once with its
+// toggles off, returning nil.
+func (d *dashboard) confirm(action string, c *typedConfirmation) *typedConfirmation {
+	switch d.config.Confirm.mode(action) {
+	case confirmNone:
+		c.run()
+		return nil
+	case confirmYes:
+		c.name = "y"
+	}
+	return c
+}
+
+// confirmForm has the submission of f confirmed as the policy of action
+// says, the target's name and the question read from the values typed.
+func (d *dashboard) confirmForm(f *form, action string, describe func(values map[string]string) (name, question string)) *form {
+	if d.config.Confirm.mode(action) == confirmNone {
+		return f
+	}
+	f.confirm = func(values map[string]string) *typedConfirmation {
+		name, question := describe(values)
+		return d.confirm(action, &typedConfirmation{name: name, question: question, run: func() {}})
+	}
+	return f
+}
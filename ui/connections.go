@@ -0,0 +1,160 @@
+package ui
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// defaultCloseReason is sent to the client when a connection is closed
+// without a reason of its own, so its logs say where the close came from.
+const defaultCloseReason = "Closed from rabbitspy"
+
+// closeConnection has the broker close a client connection. The reason is
+// sent to the client in the connection.close frame; the messages its
+// channels held unacknowledged are requeued.
+func closeConnection(config Config, name, reason string) error {
+	header := http.Header{}
+	if reason != "" {
+		header.Set("X-Reason", reason)
+	}
+	return apiRequestHeader(config, "DELETE", "/api/connections/"+url.PathEscape(name), header, nil, nil)
+}
+
+// clientName is the name a client gave its connection, or its product when
+// it gave none.
+func clientName(c ConnectionInfo) string {
+	for _, property := range []string{"connection_name", "product"} {
+		if s, ok := c.ClientProperties[property].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// connectionUnacked counts the unacknowledged messages held by each
+// connection's channels, by connection name.
+func connectionUnacked(channels []ChannelInfo) map[string]int {
+	unacked := make(map[string]int)
+	for _, ch := range channels {
+		unacked[ch.ConnectionDetails.Name] += ch.MessagesUnacknowledged
+	}
+	return unacked
+}
+
+// expectClosed checks that a closed connection is gone. Connections are not
+// part of the queue listing, so it reads them from the dashboard.
+func expectClosed(d *dashboard, name string, unacked int) verification {
+	return verification{check: func([]QueueInfo) (string, bool) {
+		for _, c := range d.connections {
+			if c.Name == name {
+				return fmt.Sprintf("%s is still open (%s)", name, c.State), false
+			}
+		}
+		return fmt.Sprintf("closed %s; its %s unacknowledged messages were requeued", name, groupDigits(unacked)), true
+	}}
+}
+
+// closeForm asks for the reason to give the client before closing c.
+func (d *dashboard) closeForm(c ConnectionInfo) *form {
+	unacked := connectionUnacked(d.channels)[c.Name]
+	title := fmt.Sprintf("Close connection %s (%s unacked)", c.Name, groupDigits(unacked))
+	return d.confirmForm(newForm(title, []*formField{
+		{label: "Reason", hint: "sent to the client", input: lineInput{value: defaultCloseReason}},
+	}, func(values map[string]string) error {
+		if err := closeConnection(d.config, c.Name, values["Reason"]); err != nil {
+			return err
+		}
+		d.verifyAfter(expectClosed(d, c.Name, unacked))
+		return nil
+	}), "close", func(map[string]string) (string, string) {
+		return c.Name, fmt.Sprintf("Close %s of %s, requeueing %s unacked messages?", c.Name, c.User, groupDigits(unacked))
+	})
+}
+
+// connectionsView lists client connections with the unacknowledged messages
+// their channels hold, most first, so a stuck consumer sitting on messages
+// is easy to find and close. form is the close dialog, if open.
+type connectionsView struct {
+	*listView
+	form *form
+}
+
+func newConnectionsView() *connectionsView {
+	v := &connectionsView{}
+	v.listView = newListView("Connections",
+		[]string{"Connection", "Client", "User", "VHost", "State", "Channels", "Unacked", "In/s", "Out/s"},
+		func(width int) []int { return spreadWidths(width, 0, 16, 12, 10, 9, 9, 9, 10, 10) },
+		v.rows)
+	return v
+}
+
+func (v *connectionsView) rows(d *dashboard) ([]listRow, string) {
+	unacked := connectionUnacked(d.channels)
+	connections := append([]ConnectionInfo(nil), d.connections...)
+	sort.SliceStable(connections, func(i, j int) bool {
+		if a, b := unacked[connections[i].Name], unacked[connections[j].Name]; a != b {
+			return a > b
+		}
+		return connections[i].Name < connections[j].Name
+	})
+
+	var rows []listRow
+	for _, c := range connections {
+		connected := "-"
+		if c.ConnectedAt > 0 {
+			connected = formatUptime(time.Since(time.UnixMilli(c.ConnectedAt)).Milliseconds()) + " ago"
+		}
+		detail := fmt.Sprintf("%s from %s:%d, user %s on %s, %s over %s, connected %s.\nReceived %s, sent %s; %d channels holding %s unacknowledged messages.",
+			c.Name, c.PeerHost, c.PeerPort, c.User, c.Node, c.Protocol, c.AuthMechanism, connected,
+			formatBytes(c.RecvOct), formatBytes(c.SendOct), c.Channels, groupDigits(unacked[c.Name]))
+		if unacked[c.Name] > 0 {
+			detail += "\nClosing it (D) requeues them for other consumers."
+		}
+		rows = append(rows, listRow{
+			cells: []string{
+				c.Name, clientName(c), c.User, c.VHost, c.State, fmt.Sprint(c.Channels), groupDigits(unacked[c.Name]),
+				formatBytes(int64(c.RecvOctDetails.Rate)), formatBytes(int64(c.SendOctDetails.Rate)),
+			},
+			broken: isBlocked(c),
+			detail: detail,
+			key:    c.Name,
+		})
+	}
+	return rows, "No connections."
+}
+
+func (v *connectionsView) Render(d *dashboard, ui uiState) {
+	v.listView.Render(d, ui)
+	if v.form != nil {
+		v.form.Render()
+	}
+}
+
+func (v *connectionsView) HandleKey(d *dashboard, id string) bool {
+	if v.form != nil {
+		if v.form.Feed(id) {
+			v.form = nil
+		}
+		return true
+	}
+	if id == "D" {
+		if d.readOnly("closing connections") {
+			return true
+		}
+		for _, c := range d.connections {
+			if row, ok := v.selectedRow(d); ok && c.Name == row.key {
+				v.form = d.closeForm(c)
+			}
+		}
+		return true
+	}
+	return v.listView.HandleKey(d, id)
+}
+
+// Capturing reports whether the close dialog is open.
+func (v *connectionsView) Capturing() bool {
+	return v.form != nil
+}
@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/genc-murat/rabbitspy/management"
+)
+
+// consumerGroup is the consumers of one stream that share a name, such as a
+// single active consumer group or an application tracking its offset on the
+// server. Consumers without a name are a group of their own.
+type consumerGroup struct {
+	stream  management.QueueRef
+	name    string
+	members []StreamConsumer
+	active  int
+	// offset, lag and rate are those of the active members: the lowest
+	// offset and the largest lag, and the combined consume rate.
+	offset int64
+	lag    int64
+	rate   float64
+}
+
+func streamKey(q management.QueueRef) string {
+	return q.VHost + "/" + q.Name
+}
+
+// consumerName is the name a stream consumer registered with, if any.
+func consumerName(c StreamConsumer) string {
+	name, _ := c.Properties["name"].(string)
+	return name
+}
+
+// consumerLag is how many messages c is behind. The broker reports it as
+// offset_lag; when it reports none, the lag is estimated from the stream's
+// committed offset.
+func consumerLag(c StreamConsumer, committed map[string]int64) int64 {
+	if c.OffsetLag > 0 {
+		return c.OffsetLag
+	}
+	if end, ok := committed[streamKey(c.Queue)]; ok && end > c.Offset {
+		return end - c.Offset
+	}
+	return 0
+}
+
+// consumerGroups groups stream consumers by stream and name, most lagging
+// group first. Inactive members of a single active consumer group are
+// standing by, so only the active ones count towards the group's progress;
+// a group with no active member is judged by all of them.
+func consumerGroups(consumers []StreamConsumer, queues []QueueInfo) []consumerGroup {
+	committed := make(map[string]int64)
+	for _, q := range queues {
+		if q.CommittedOffset != nil {
+			committed[q.Key()] = *q.CommittedOffset
+		}
+	}
+
+	index := make(map[string]int)
+	var groups []consumerGroup
+	for _, c := range consumers {
+		key := fmt.Sprintf("%s\x00%s#%d", streamKey(c.Queue), c.ConnectionDetails.Name, c.SubscriptionID)
+		if name := consumerName(c); name != "" {
+			key = streamKey(c.Queue) + "\x00" + name
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, consumerGroup{stream: c.Queue, name: consumerName(c)})
+		}
+		groups[i].members = append(groups[i].members, c)
+		if c.Active {
+			groups[i].active++
+		}
+	}
+
+	for i := range groups {
+		g := &groups[i]
+		first := true
+		for _, c := range g.members {
+			if g.active > 0 && !c.Active {
+				continue
+			}
+			if lag := consumerLag(c, committed); lag > g.lag {
+				g.lag = lag
+			}
+			if first || c.Offset < g.offset {
+				g.offset = c.Offset
+			}
+			first = false
+			g.rate += c.ConsumedDetails.Rate
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].lag > groups[j].lag })
+	return groups
+}
+
+// lagTrend says whether a group is catching up with the stream's publish
+// rate, and how soon at the current rates.
+func (g consumerGroup) lagTrend(publishRate float64) string {
+	switch {
+	case g.lag == 0:
+		return "caught up"
+	case g.rate > publishRate:
+		eta := time.Duration(float64(g.lag) / (g.rate - publishRate) * float64(time.Second))
+		return "catching up, ~" + formatUptime(eta.Milliseconds())
+	case g.rate == 0:
+		return "[stalled](fg:red)"
+	default:
+		return "[falling behind](fg:red)"
+	}
+}
+
+// streamLags is the largest consumer group lag of each stream, keyed like
+// QueueInfo.Key.
+func streamLags(consumers []StreamConsumer, queues []QueueInfo) map[string]int64 {
+	lags := make(map[string]int64)
+	for _, g := range consumerGroups(consumers, queues) {
+		if key := streamKey(g.stream); g.lag > lags[key] {
+			lags[key] = g.lag
+		}
+	}
+	return lags
+}
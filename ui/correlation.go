@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// QueueDependency declares that the Downstream queues are fed by, or wait
+// on, the Upstream one, so their alerts are usually a consequence of its
+// trouble. All are queue name globs.
+type QueueDependency struct {
+	Upstream   string   `json:"upstream"`
+	Downstream []string `json:"downstream"`
+}
+
+// dependsOn reports whether queue b is declared downstream of queue a.
+func dependsOn(deps []QueueDependency, a, b string) bool {
+	for _, dep := range deps {
+		if ok, _ := path.Match(dep.Upstream, a); !ok {
+			continue
+		}
+		for _, down := range dep.Downstream {
+			if ok, _ := path.Match(down, b); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// correlateAlerts folds the alerts of queues whose upstream is alerting at
+// the same time into one incident named after the furthest upstream queue
+// alerting, the likely cause. Alerts about other queues, or not about a
+// queue, pass through unchanged.
+func correlateAlerts(alerts []Alert, deps []QueueDependency) []Alert {
+	if len(deps) == 0 {
+		return alerts
+	}
+	byQueue := make(map[string][]Alert)
+	var queues []string
+	var result []Alert
+	for _, a := range alerts {
+		if a.Queue == "" {
+			result = append(result, a)
+			continue
+		}
+		key := a.VHost + "/" + a.Queue
+		if _, ok := byQueue[key]; !ok {
+			queues = append(queues, key)
+		}
+		byQueue[key] = append(byQueue[key], a)
+	}
+	sort.Strings(queues)
+	feeds := func(up, down string) bool {
+		a, b := byQueue[up][0], byQueue[down][0]
+		return a.VHost == b.VHost && dependsOn(deps, a.Queue, b.Queue)
+	}
+
+	// root follows alerting upstream queues in the same vhost as far as
+	// they go.
+	root := func(key string) string {
+		seen := map[string]bool{key: true}
+		for {
+			next := ""
+			for _, up := range queues {
+				if !seen[up] && feeds(up, key) {
+					next = up
+					break
+				}
+			}
+			if next == "" {
+				return key
+			}
+			seen[next] = true
+			key = next
+		}
+	}
+	groups := make(map[string][]string)
+	for _, key := range queues {
+		r := root(key)
+		groups[r] = append(groups[r], key)
+	}
+
+	for _, cause := range queues {
+		members, ok := groups[cause]
+		if !ok {
+			continue
+		}
+		if len(members) == 1 {
+			result = append(result, byQueue[cause]...)
+			continue
+		}
+		incident := byQueue[cause][0]
+		incident.Key = "incident:" + cause
+		var causes, effects []string
+		for _, a := range byQueue[cause] {
+			causes = append(causes, a.Message)
+		}
+		for _, key := range members {
+			for _, a := range byQueue[key] {
+				if a.Severity == SeverityCritical {
+					incident.Severity = SeverityCritical
+				}
+			}
+			if key != cause {
+				incident.Downstream = append(incident.Downstream, key)
+				effects = append(effects, key)
+			}
+		}
+		incident.Message = fmt.Sprintf("Incident, likely caused by %s: %s; also alerting downstream: %s",
+			cause, strings.Join(causes, "; "), strings.Join(effects, ", "))
+		result = append(result, incident)
+	}
+	return result
+}
@@ -0,0 +1,398 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// dashboard holds everything fetched from one broker between polls, plus
+// the alert state derived from it. Views only read from it.
+type dashboard struct {
+	name   string
+	config Config
+	amqp   *amqpConnector
+
+	queues   []QueueInfo
+	previous map[string]QueueInfo
+	// behind are the queues whose consumers have been falling behind.
+	behind      map[string]slowConsumer
+	overview    *Overview
+	exchanges   []ExchangeInfo
+	bindings    []BindingInfo
+	connections []ConnectionInfo
+	channels    []ChannelInfo
+	nodes       []NodeInfo
+	policies    []PolicyInfo
+	shovels     []ShovelInfo
+	federation  []FederationLink
+	features    []FeatureFlag
+	vhosts      []VHostInfo
+
+	streamPublishers []StreamPublisher
+	streamConsumers  []StreamConsumer
+
+	lastErr    error
+	lastUpdate time.Time
+
+	// shovelsMissing, federationMissing and streamsMissing are set while
+	// the plugin serving that endpoint is not enabled.
+	shovelsMissing    bool
+	federationMissing bool
+	streamsMissing    bool
+
+	alerts         *alertManager
+	rules          []AlertRule
+	baselines      *baselineStore
+	activeAlerts   []Alert
+	apiDownAfter   time.Duration
+	lastAPISuccess time.Time
+
+	// notice is a message about the last action, shown in the status bar
+	// until noticeUntil. syncing and rebalanceUntil track actions whose
+	// progress is still being reported.
+	notice         string
+	noticeUntil    time.Time
+	syncing        map[string]bool
+	rebalanceUntil time.Time
+	// verifications are the expected effects of destructive actions still
+	// being checked against polls.
+	verifications []verification
+	// jobs are long actions running in the background, and redraw is
+	// signalled when their progress changes.
+	jobs   []*job
+	redraw chan<- struct{}
+
+	tracker    *bindingTracker
+	history    *queueHistory
+	nodeTrends *nodeTrendTracker
+	blocked    *blockedTracker
+	counters   *counterTracker
+	latencies  *latencyTracker
+
+	annotations *annotationLog
+	snoozed     snoozes
+}
+
+func newDashboard(name string, config Config, notifiers ...Notifier) *dashboard {
+	return &dashboard{
+		name:           name,
+		config:         config,
+		amqp:           newAMQPConnector(config),
+		alerts:         newAlertManager(notifiers...),
+		apiDownAfter:   time.Duration(config.Alerts.APIDownSeconds) * time.Second,
+		lastAPISuccess: time.Now(),
+		tracker:        newBindingTracker(time.Duration(config.Bindings.UnusedWindowSeconds) * time.Second),
+		history:        newQueueHistory(),
+		syncing:        make(map[string]bool),
+		latencies:      newLatencyTracker(),
+		annotations:    &annotationLog{},
+		snoozed:        make(snoozes),
+		blocked:        newBlockedTracker(),
+		counters:       &counterTracker{},
+		nodeTrends: newNodeTrendTracker(
+			time.Duration(config.Nodes.TrendWindowSeconds)*time.Second,
+			time.Duration(config.Nodes.ExhaustionHorizonSeconds)*time.Second,
+		),
+	}
+}
+
+// pollResult is everything one poll fetched, with the error of each
+// request. Fetching only reads the configuration, so it can run in the
+// background while the UI keeps drawing the previous poll.
+type pollResult struct {
+	queues    []QueueInfo
+	queuesErr error
+
+	overview      Overview
+	overviewErr   error
+	exchanges     []ExchangeInfo
+	exchangesErr  error
+	bindings      []BindingInfo
+	bindingsErr   error
+	channels      []ChannelInfo
+	channelsErr   error
+	nodes         []NodeInfo
+	nodesErr      error
+	features      []FeatureFlag
+	featuresErr   error
+	policies      []PolicyInfo
+	policiesErr   error
+	shovels       []ShovelInfo
+	shovelsErr    error
+	federation    []FederationLink
+	federationErr error
+	vhosts        []VHostInfo
+	vhostsErr     error
+
+	// streamsPolled is set when the queues include streams.
+	streamsPolled    bool
+	streamPublishers []StreamPublisher
+	publishersErr    error
+	streamConsumers  []StreamConsumer
+	consumersErr     error
+
+	connections    []ConnectionInfo
+	connectionsErr error
+
+	// metricsPolled is set when a Prometheus port is configured.
+	metricsPolled bool
+	metrics       []metricSample
+	metricsErr    error
+}
+
+// inParallel runs fns concurrently and waits for all of them.
+func inParallel(fns ...func()) {
+	var wg sync.WaitGroup
+	for _, fn := range fns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+	wg.Wait()
+}
+
+// fetch requests every endpoint a poll needs at the same time, so a poll
+// takes as long as the slowest request rather than all of them together.
+func (d *dashboard) fetch() pollResult {
+	var r pollResult
+	config := d.config
+	inParallel(
+		func() {
+			r.queues, r.queuesErr = getQueues(config)
+			if r.queuesErr == nil && hasStreams(r.queues) {
+				r.streamsPolled = true
+				r.streamPublishers, r.publishersErr = getStreamPublishers(config)
+				if !errors.Is(r.publishersErr, errNotFound) {
+					r.streamConsumers, r.consumersErr = getStreamConsumers(config)
+				}
+			}
+		},
+		func() { r.overview, r.overviewErr = getOverview(config) },
+		func() { r.exchanges, r.exchangesErr = getExchanges(config) },
+		func() { r.bindings, r.bindingsErr = getBindings(config) },
+		func() { r.channels, r.channelsErr = getChannels(config) },
+		func() { r.nodes, r.nodesErr = getNodes(config) },
+		func() { r.features, r.featuresErr = getFeatureFlags(config) },
+		func() { r.policies, r.policiesErr = getPolicies(config) },
+		func() { r.shovels, r.shovelsErr = getShovels(config) },
+		func() { r.federation, r.federationErr = getFederationLinks(config) },
+		func() { r.connections, r.connectionsErr = getConnections(config) },
+		func() { r.vhosts, r.vhostsErr = getVHosts(config) },
+		func() {
+			if config.RabbitMQ.PrometheusPort != "" {
+				r.metricsPolled = true
+				r.metrics, r.metricsErr = getGlobalMetrics(config)
+			}
+		},
+	)
+	return r
+}
+
+// poll refreshes all data from the management API and re-evaluates alerts.
+func (d *dashboard) poll() {
+	d.apply(d.fetch())
+}
+
+// apply takes in a poll's results and re-evaluates alerts. On failure the
+// previously fetched data is kept so views can keep showing the last known
+// state.
+func (d *dashboard) apply(r pollResult) {
+	var current []Alert
+
+	for _, key := range d.snoozed.expire(time.Now()) {
+		d.setNotice("%s is no longer snoozed", key)
+	}
+
+	err := r.queuesErr
+	d.lastErr = err
+	if err != nil {
+		log.Printf("Error listing queues: %s", err)
+		if isAuthError(err) {
+			current = append(current, Alert{
+				Key:      "management-api-auth",
+				Severity: SeverityCritical,
+				Message:  fmt.Sprintf("Management API rejected the credentials: %v", err),
+			})
+		} else if down := time.Since(d.lastAPISuccess); down >= d.apiDownAfter {
+			current = append(current, Alert{
+				Key:      "management-api-down",
+				Severity: SeverityCritical,
+				Message:  fmt.Sprintf("Management API unreachable for %s: %v", down.Round(time.Second), err),
+			})
+		}
+	} else {
+		d.previous = make(map[string]QueueInfo, len(d.queues))
+		for _, q := range d.queues {
+			d.previous[q.Key()] = q
+		}
+		d.queues = r.queues
+		d.lastAPISuccess = time.Now()
+		d.lastUpdate = d.lastAPISuccess
+		d.history.Record(d.lastUpdate, d.queues)
+		d.behind = slowConsumers(d.history, d.queues, d.config.SlowConsumers)
+		if d.baselines != nil {
+			if err := d.baselines.Record(d.name, d.lastUpdate, d.queues); err != nil {
+				log.Printf("Error saving baselines: %s", err)
+			}
+			current = append(current, d.baselines.Alerts(d.name, d.snoozed.watching(d.queues))...)
+		}
+
+		if r.overviewErr != nil {
+			log.Printf("Error fetching overview: %s", r.overviewErr)
+		} else {
+			d.overview = &r.overview
+		}
+
+		if r.exchangesErr != nil {
+			log.Printf("Error listing exchanges: %s", r.exchangesErr)
+		}
+		if r.bindingsErr != nil {
+			log.Printf("Error listing bindings: %s", r.bindingsErr)
+		} else {
+			d.bindings = r.bindings
+		}
+		if r.exchanges != nil {
+			d.exchanges = r.exchanges
+			d.tracker.Add(r.exchanges, d.queues)
+		}
+		if alert, ok := unroutableAlert(d.exchanges); ok {
+			current = append(current, alert)
+		}
+		if r.channelsErr != nil {
+			log.Printf("Error listing channels: %s", r.channelsErr)
+		} else {
+			d.channels = r.channels
+		}
+
+		if r.nodesErr != nil {
+			log.Printf("Error listing nodes: %s", r.nodesErr)
+		} else {
+			d.nodes = r.nodes
+			current = append(current, d.nodeTrends.Update(d.nodes)...)
+			if alert, ok := partitionAlert(d.nodes); ok {
+				current = append(current, alert)
+			}
+		}
+
+		// Brokers before 3.8 have no feature flags; the overview just leaves
+		// them out.
+		if err := r.featuresErr; err != nil && !errors.Is(err, errNotFound) {
+			log.Printf("Error listing feature flags: %s", err)
+		} else {
+			d.features = r.features
+		}
+
+		if r.policiesErr != nil {
+			log.Printf("Error listing policies: %s", r.policiesErr)
+		} else {
+			d.policies = r.policies
+		}
+		if r.vhostsErr != nil {
+			log.Printf("Error listing vhosts: %s", r.vhostsErr)
+		} else {
+			d.vhosts = r.vhosts
+		}
+
+		if errors.Is(r.shovelsErr, errNotFound) {
+			d.shovels, d.shovelsMissing = nil, true
+		} else if r.shovelsErr != nil {
+			log.Printf("Error listing shovels: %s", r.shovelsErr)
+		} else {
+			d.shovels, d.shovelsMissing = r.shovels, false
+		}
+		if errors.Is(r.federationErr, errNotFound) {
+			d.federation, d.federationMissing = nil, true
+		} else if r.federationErr != nil {
+			log.Printf("Error listing federation links: %s", r.federationErr)
+		} else {
+			d.federation, d.federationMissing = r.federation, false
+		}
+
+		if r.streamsPolled {
+			d.applyStreams(r)
+		}
+
+		if r.connectionsErr != nil {
+			log.Printf("Error listing connections: %s", r.connectionsErr)
+		} else {
+			d.connections = r.connections
+			d.blocked.Update(d.connections, d.lastUpdate)
+		}
+
+		if r.metricsPolled {
+			d.applyMetrics(r)
+		}
+
+		current = append(current, ruleAlerts(d.rules, d.snoozed.watching(d.queues), d.connections, d.channels)...)
+	}
+
+	if d.config.Quotas.enabled() {
+		current = append(current, connectionQuotaAlerts(d.config.Quotas, d.connections)...)
+	}
+
+	for _, queue := range d.snoozed.watching(d.queues) {
+		if isErrorQueue(queue.Name) {
+			current = append(current, Alert{
+				Key:      "error-queues",
+				Severity: SeverityWarning,
+				Message:  "Error queue(s) detected!",
+			})
+			break
+		}
+	}
+
+	current = d.snoozed.quiet(current)
+	d.activeAlerts = d.alerts.Update(correlateAlerts(current, d.config.Alerts.Dependencies))
+	d.trackActions()
+	d.checkVerifications()
+}
+
+// applyMetrics takes in the global counters from the Prometheus endpoint.
+// Brokers before 3.10 serve the endpoint without them.
+func (d *dashboard) applyMetrics(r pollResult) {
+	t := d.counters
+	t.lastErr = r.metricsErr
+	if r.metricsErr != nil {
+		log.Printf("Error fetching global counters: %s", r.metricsErr)
+		return
+	}
+	t.missing = len(r.metrics) == 0
+	if !t.missing {
+		t.Record(counterTotals(r.metrics), d.lastUpdate)
+	}
+}
+
+// applyStreams takes in stream publishers and consumers from the stream
+// management plugin.
+func (d *dashboard) applyStreams(r pollResult) {
+	if errors.Is(r.publishersErr, errNotFound) {
+		d.streamPublishers, d.streamConsumers, d.streamsMissing = nil, nil, true
+		return
+	}
+	d.streamsMissing = false
+	if r.publishersErr != nil {
+		log.Printf("Error listing stream publishers: %s", r.publishersErr)
+	} else {
+		d.streamPublishers = r.streamPublishers
+		d.latencies.Record(r.streamPublishers)
+	}
+	if r.consumersErr != nil {
+		log.Printf("Error listing stream consumers: %s", r.consumersErr)
+	} else {
+		d.streamConsumers = r.streamConsumers
+	}
+}
+
+func (d *dashboard) hasCritical() bool {
+	for _, alert := range d.activeAlerts {
+		if alert.Severity == SeverityCritical {
+			return true
+		}
+	}
+	return false
+}
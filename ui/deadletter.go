@@ -0,0 +1,204 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// deadLetterRoute is where one queue's rejected, expired or overflowing
+// messages end up. problem says why the chain is broken, and is empty when
+// dead-lettered messages reach at least one queue.
+type deadLetterRoute struct {
+	queue    QueueInfo
+	exchange string
+	key      string
+	hasKey   bool
+	targets  []string
+	problem  string
+}
+
+// topicMatch reports whether a topic binding key matches a routing key:
+// * matches one word and # matches zero or more.
+func topicMatch(pattern, key string) bool {
+	var match func(p, k []string) bool
+	match = func(p, k []string) bool {
+		switch {
+		case len(p) == 0:
+			return len(k) == 0
+		case p[0] == "#":
+			for i := 0; i <= len(k); i++ {
+				if match(p[1:], k[i:]) {
+					return true
+				}
+			}
+			return false
+		case len(k) == 0:
+			return false
+		case p[0] == "*" || p[0] == k[0]:
+			return match(p[1:], k[1:])
+		}
+		return false
+	}
+	return match(strings.Split(pattern, "."), strings.Split(key, "."))
+}
+
+// bindingMatches reports whether a message with the given routing key would
+// follow b out of an exchange of the given type. Without a key the message
+// keeps its original routing key, which could be anything, as could the
+// headers a headers exchange matches on.
+func bindingMatches(exchangeType string, b BindingInfo, key string, hasKey bool) bool {
+	if !hasKey {
+		return true
+	}
+	switch exchangeType {
+	case "direct":
+		return b.RoutingKey == key
+	case "topic":
+		return topicMatch(b.RoutingKey, key)
+	}
+	return true
+}
+
+// deadLetterRoutes resolves the dead-letter exchange and routing key of
+// every queue that has one into the queues the messages would be routed
+// to, one binding level deep.
+func deadLetterRoutes(queues []QueueInfo, exchanges []ExchangeInfo, bindings []BindingInfo) []deadLetterRoute {
+	exchangeTypes := make(map[string]string, len(exchanges))
+	for _, e := range exchanges {
+		exchangeTypes[e.Key()] = e.Type
+	}
+	queueExists := make(map[string]bool, len(queues))
+	for _, q := range queues {
+		queueExists[q.Key()] = true
+	}
+
+	var routes []deadLetterRoute
+	for _, q := range queues {
+		dlx, ok := queueSetting(q, "dead-letter-exchange")
+		if !ok {
+			continue
+		}
+		r := deadLetterRoute{queue: q, exchange: fmt.Sprint(dlx)}
+		if key, ok := queueSetting(q, "dead-letter-routing-key"); ok {
+			r.key, r.hasKey = fmt.Sprint(key), true
+		}
+
+		if r.exchange == "" {
+			// The default exchange routes straight to the queue named by
+			// the routing key.
+			switch {
+			case !r.hasKey:
+				r.problem = "the default exchange needs a dead-letter routing key"
+			case !queueExists[q.VHost+"/"+r.key]:
+				r.problem = fmt.Sprintf("queue %s does not exist", r.key)
+			default:
+				r.targets = []string{r.key}
+			}
+			routes = append(routes, r)
+			continue
+		}
+
+		exchangeType, ok := exchangeTypes[q.VHost+"/"+r.exchange]
+		if !ok {
+			r.problem = fmt.Sprintf("exchange %s does not exist", r.exchange)
+			routes = append(routes, r)
+			continue
+		}
+		bound := 0
+		for _, b := range bindings {
+			if b.VHost != q.VHost || b.Source != r.exchange {
+				continue
+			}
+			bound++
+			if !bindingMatches(exchangeType, b, r.key, r.hasKey) {
+				continue
+			}
+			target := b.Destination
+			if b.DestinationType == "exchange" {
+				target = "exchange " + b.Destination
+			}
+			r.targets = append(r.targets, target)
+		}
+		switch {
+		case bound == 0:
+			r.problem = fmt.Sprintf("exchange %s has no bindings, so dead-lettered messages are dropped", r.exchange)
+		case len(r.targets) == 0:
+			r.problem = fmt.Sprintf("no binding of %s matches routing key %s", r.exchange, r.key)
+		}
+		for _, target := range r.targets {
+			if target == q.Name {
+				r.problem = "dead-letters into itself; the broker drops expired messages caught in such a cycle"
+			}
+		}
+		routes = append(routes, r)
+	}
+	return routes
+}
+
+// deadLetterChain follows a route through the dead-letter queues' own
+// dead-letter settings, e.g. orders → dlx [orders] → orders.dlq → parking.
+func deadLetterChain(r deadLetterRoute, routes []deadLetterRoute) string {
+	byQueue := make(map[string]deadLetterRoute, len(routes))
+	for _, route := range routes {
+		byQueue[route.queue.Key()] = route
+	}
+	chain := r.queue.Name
+	seen := map[string]bool{r.queue.Key(): true}
+	for hop := 0; hop < 5; hop++ {
+		exchange := r.exchange
+		if exchange == "" {
+			exchange = "(default)"
+		}
+		if r.hasKey {
+			exchange += " [" + r.key + "]"
+		}
+		chain += " → " + exchange
+		if len(r.targets) == 0 {
+			return chain + " → ✗"
+		}
+		chain += " → " + strings.Join(r.targets, ", ")
+		if len(r.targets) > 1 {
+			return chain
+		}
+		next, ok := byQueue[r.queue.VHost+"/"+r.targets[0]]
+		if !ok || seen[next.queue.Key()] {
+			return chain
+		}
+		seen[next.queue.Key()] = true
+		r = next
+	}
+	return chain
+}
+
+func newDeadLetterView() *listView {
+	return newListView("Dead letters",
+		[]string{"Queue", "DLX", "Routing key", "Dead-letter queues", "Problem"},
+		func(width int) []int { return spreadWidths(width, width/5, width/7, width/7, 0, 0) },
+		func(d *dashboard) ([]listRow, string) {
+			routes := deadLetterRoutes(d.queues, d.exchanges, d.bindings)
+			var rows []listRow
+			for _, r := range routes {
+				exchange, key := r.exchange, r.key
+				if exchange == "" {
+					exchange = "(default)"
+				}
+				if !r.hasKey {
+					key = "(original)"
+				}
+				detail := deadLetterChain(r, routes)
+				if !r.hasKey {
+					detail += "\nMessages keep their original routing keys, so every binding of the exchange is listed."
+				}
+				if r.problem != "" {
+					detail += "\n[Broken: " + r.problem + "](fg:red)"
+				}
+				rows = append(rows, listRow{
+					cells:  []string{r.queue.Key(), exchange, key, strings.Join(r.targets, ", "), r.problem},
+					broken: r.problem != "",
+					detail: detail,
+					key:    r.queue.Key(),
+				})
+			}
+			return rows, "No queue has a dead-letter exchange."
+		})
+}
@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxDecodedBytes caps what a compressed payload is inflated to, so that a
+// small message cannot blow up in memory.
+const maxDecodedBytes = 16 << 20
+
+// gzipMagic starts every gzip stream, so gzipped payloads are inflated
+// even when the publisher set no content encoding.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// decodePayload undoes the content encodings of a payload, named in
+// contentEncoding in the order they were applied, as in HTTP: gzip,
+// deflate and base64. Other names, such as a charset set there by mistake,
+// are left alone. With base64Decode the result is base64-decoded as well,
+// and a gzip stream left at the end is inflated. It returns the payload
+// decoded as far as it got, the steps taken and the error that stopped it.
+func decodePayload(payload []byte, contentEncoding string, base64Decode bool) ([]byte, []string, error) {
+	var steps []string
+	encodings := strings.Split(contentEncoding, ",")
+	if base64Decode {
+		// Undone last.
+		encodings = append([]string{"base64"}, encodings...)
+	}
+	for i := len(encodings) - 1; i >= 0; i-- {
+		encoding := strings.ToLower(strings.TrimSpace(encodings[i]))
+		var decoded []byte
+		var err error
+		switch encoding {
+		case "gzip", "x-gzip":
+			decoded, err = inflate(gzip.NewReader(bytes.NewReader(payload)))
+		case "deflate":
+			// HTTP's deflate is zlib-wrapped, but raw streams are common.
+			decoded, err = inflate(zlib.NewReader(bytes.NewReader(payload)))
+			if err != nil {
+				decoded, err = inflate(flate.NewReader(bytes.NewReader(payload)), nil)
+			}
+		case "base64":
+			decoded, err = decodeBase64(payload)
+		default:
+			continue
+		}
+		if err != nil {
+			return payload, steps, fmt.Errorf("%s decoding failed: %w", encoding, err)
+		}
+		payload, steps = decoded, append(steps, encoding)
+	}
+	if bytes.HasPrefix(payload, gzipMagic) {
+		if decoded, err := inflate(gzip.NewReader(bytes.NewReader(payload))); err == nil {
+			payload, steps = decoded, append(steps, "gzip (detected)")
+		}
+	}
+	return payload, steps, nil
+}
+
+// inflate reads a decompressing reader, cutting it at maxDecodedBytes.
+func inflate(r io.Reader, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(io.LimitReader(r, maxDecodedBytes))
+}
+
+// decodeBase64 reads standard or URL-safe base64, padded or not.
+func decodeBase64(data []byte) ([]byte, error) {
+	text := strings.Join(strings.Fields(string(data)), "")
+	var err error
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		var decoded []byte
+		if decoded, err = encoding.DecodeString(text); err == nil {
+			return decoded, nil
+		}
+	}
+	return nil, err
+}
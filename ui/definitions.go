@@ -0,0 +1,322 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// definitionsPath is the API path of the definitions of the whole broker,
+// or of one vhost.
+func definitionsPath(vhost string) string {
+	if vhost == "" {
+		return "/api/definitions"
+	}
+	return "/api/definitions/" + url.PathEscape(vhost)
+}
+
+// definitionsFileName names an export after the cluster, the vhost if only
+// one was exported, and the time, e.g.
+// definitions-prod-orders-20240501-142233.json.
+func definitionsFileName(cluster, vhost string, at time.Time) string {
+	name := "definitions-" + safeFileName(cluster)
+	if vhost != "" {
+		name += "-" + safeVHostName(vhost)
+	}
+	return name + "-" + at.Format("20060102-150405") + ".json"
+}
+
+// safeFileName replaces the characters of s that are not safe in a file
+// name everywhere with underscores.
+func safeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// safeVHostName is a vhost's name for a file name; the default vhost, "/",
+// is called root.
+func safeVHostName(vhost string) string {
+	if vhost == "/" {
+		return "root"
+	}
+	return safeFileName(vhost)
+}
+
+// exportDefinitions downloads the broker's definitions, or one vhost's, and
+// writes them indented to a timestamped file in dir, returning its path.
+// The export is kept exactly as the broker sent it so it can be imported
+// back. It holds user password hashes, so only the owner can read it.
+func exportDefinitions(config Config, cluster, vhost, dir string, at time.Time) (string, error) {
+	var defs json.RawMessage
+	if err := getJSON(config, definitionsPath(vhost), &defs); err != nil {
+		return "", err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, defs, "", "  "); err != nil {
+		return "", err
+	}
+	indented.WriteByte('\n')
+
+	if dir == "" {
+		dir = "."
+	}
+	path := filepath.Join(dir, definitionsFileName(cluster, vhost, at))
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, indented.Bytes(), 0o600); err != nil {
+		return "", err
+	}
+	return path, os.Rename(tmp, path)
+}
+
+// ExportDefinitions writes the definitions of the named cluster, or the
+// only one, to a timestamped file in dir and prints its path to out. An
+// empty vhost exports every vhost.
+func ExportDefinitions(config Config, cluster, vhost, dir string, out io.Writer) error {
+	config, err := pickCluster(config, cluster)
+	if err != nil {
+		return err
+	}
+	if cluster == "" {
+		cluster = config.ClusterConfigs()[0].Name
+	}
+	path, err := exportDefinitions(config, cluster, vhost, dir, time.Now())
+	if err != nil {
+		return fmt.Errorf("exporting definitions: %w", err)
+	}
+	fmt.Fprintln(out, path)
+	return nil
+}
+
+// exportForm asks which vhost to export, all by default, and where to.
+func (d *dashboard) exportForm() *form {
+	return newForm("Export definitions", []*formField{
+		{label: "VHost", hint: "empty for all"},
+		{label: "Directory", input: lineInput{value: "."}},
+	}, func(values map[string]string) error {
+		path, err := exportDefinitions(d.config, d.name, values["VHost"], values["Directory"], time.Now())
+		if err != nil {
+			return err
+		}
+		d.setNotice("[✓ Definitions written to %s](fg:green)", path)
+		return nil
+	})
+}
+
+// definitionKinds are the sections of a definitions file, with the fields
+// that identify an object in each. Exports of a single vhost leave the
+// vhost out, which then identifies nothing on either side of a diff.
+var definitionKinds = []struct {
+	name  string
+	label string
+	key   []string
+}{
+	{"vhosts", "vhost", []string{"name"}},
+	{"users", "user", []string{"name"}},
+	{"permissions", "permission", []string{"user", "vhost"}},
+	{"topic_permissions", "topic permission", []string{"user", "vhost", "exchange"}},
+	{"parameters", "parameter", []string{"component", "vhost", "name"}},
+	{"global_parameters", "global parameter", []string{"name"}},
+	{"policies", "policy", []string{"vhost", "name"}},
+	{"queues", "queue", []string{"vhost", "name"}},
+	{"exchanges", "exchange", []string{"vhost", "name"}},
+	{"bindings", "binding", []string{"vhost", "source", "destination_type", "destination", "routing_key", "arguments"}},
+}
+
+// definitionChange is one object an import would create, or change and
+// in which fields. kind is the label of its section.
+type definitionChange struct {
+	kind   string
+	key    string
+	create bool
+	fields []string
+}
+
+func (c definitionChange) String() string {
+	if c.create {
+		return fmt.Sprintf("+ %s %s", c.kind, c.key)
+	}
+	return fmt.Sprintf("~ %s %s: %s", c.kind, c.key, strings.Join(c.fields, ", "))
+}
+
+// definitionObjects reads the objects of every known section of a
+// definitions document, each by its identifying key.
+func definitionObjects(data []byte) (map[string]map[string]map[string]interface{}, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	objects := make(map[string]map[string]map[string]interface{})
+	for _, kind := range definitionKinds {
+		var list []map[string]interface{}
+		if raw, ok := doc[kind.name]; ok {
+			if err := json.Unmarshal(raw, &list); err != nil {
+				return nil, fmt.Errorf("%s: %w", kind.name, err)
+			}
+		}
+		byKey := make(map[string]map[string]interface{}, len(list))
+		for _, o := range list {
+			var parts []string
+			for _, field := range kind.key {
+				if v, ok := o[field]; ok {
+					parts = append(parts, fmt.Sprint(v))
+				}
+			}
+			byKey[strings.Join(parts, "/")] = o
+		}
+		objects[kind.name] = byKey
+	}
+	return objects, nil
+}
+
+// diffDefinitions lists what importing file over current would do. An
+// import only adds and overwrites: objects missing from the file are left
+// alone, so nothing is listed as deleted.
+func diffDefinitions(current, file []byte) ([]definitionChange, error) {
+	have, err := definitionObjects(current)
+	if err != nil {
+		return nil, fmt.Errorf("current definitions: %w", err)
+	}
+	want, err := definitionObjects(file)
+	if err != nil {
+		return nil, err
+	}
+	var changes []definitionChange
+	for _, kind := range definitionKinds {
+		keys := make([]string, 0, len(want[kind.name]))
+		for key := range want[kind.name] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			o := want[kind.name][key]
+			existing, ok := have[kind.name][key]
+			if !ok {
+				changes = append(changes, definitionChange{kind: kind.label, key: key, create: true})
+				continue
+			}
+			var fields []string
+			for field, value := range o {
+				if !reflect.DeepEqual(existing[field], value) {
+					fields = append(fields, field)
+				}
+			}
+			if len(fields) > 0 {
+				sort.Strings(fields)
+				changes = append(changes, definitionChange{kind: kind.label, key: key, fields: fields})
+			}
+		}
+	}
+	return changes, nil
+}
+
+// planImport reads a definitions file and compares it with what the broker
+// has now, in vhost or everywhere.
+func planImport(config Config, vhost, path string) ([]byte, []definitionChange, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var current json.RawMessage
+	if err := getJSON(config, definitionsPath(vhost), &current); err != nil {
+		return nil, nil, err
+	}
+	changes, err := diffDefinitions(current, file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return file, changes, nil
+}
+
+// importDefinitions uploads a definitions document to the broker, or to
+// one vhost.
+func importDefinitions(config Config, vhost string, data []byte) error {
+	return apiRequest(config, "POST", definitionsPath(vhost), json.RawMessage(data), nil)
+}
+
+// ImportDefinitions prints what importing the definitions file at path
+// into the named cluster, or the only one, would create and change, and
+// imports it when apply is set. An empty vhost imports into the whole
+// broker.
+func ImportDefinitions(config Config, cluster, vhost, path string, apply bool, out io.Writer) error {
+	config, err := pickCluster(config, cluster)
+	if err != nil {
+		return err
+	}
+	data, changes, err := planImport(config, vhost, path)
+	if err != nil {
+		return err
+	}
+	for _, c := range changes {
+		fmt.Fprintln(out, c)
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(out, "Nothing to import: the broker already has every definition in the file.")
+		return nil
+	}
+	if !apply {
+		fmt.Fprintf(out, "%s; run again with --apply to import.\n", changeCounts(changes))
+		return nil
+	}
+	if err := importDefinitions(config, vhost, data); err != nil {
+		return fmt.Errorf("importing %s: %w", path, err)
+	}
+	fmt.Fprintf(out, "Imported: %s.\n", changeCounts(changes))
+	return nil
+}
+
+// changeCounts summarises a diff, e.g. "3 to create, 1 to change".
+func changeCounts(changes []definitionChange) string {
+	created := 0
+	for _, c := range changes {
+		if c.create {
+			created++
+		}
+	}
+	return fmt.Sprintf("%d to create, %d to change", created, len(changes)-created)
+}
+
+// importForm asks for a definitions file to import, and where. Submitting
+// only compares it with the broker: the changes are shown and must be
+// confirmed before anything is uploaded.
+func (d *dashboard) importForm(planned func(path, vhost string, data []byte, changes []definitionChange)) *form {
+	return newForm("Import definitions", []*formField{
+		{label: "File", hint: "path of a definitions export"},
+		{label: "VHost", hint: "empty for the whole broker"},
+	}, func(values map[string]string) error {
+		if values["File"] == "" {
+			return fmt.Errorf("a file is needed")
+		}
+		data, changes, err := planImport(d.config, values["VHost"], values["File"])
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			d.setNotice("Nothing to import: %s has every definition in %s", d.name, values["File"])
+			return nil
+		}
+		planned(values["File"], values["VHost"], data, changes)
+		return nil
+	})
+}
+
+// startImport uploads a definitions file whose changes were confirmed.
+func (d *dashboard) startImport(path, vhost string, data []byte, changes []definitionChange) {
+	if err := importDefinitions(d.config, vhost, data); err != nil {
+		d.setNotice("[Import of %s failed: %s](fg:red)", path, err)
+		return
+	}
+	d.setNotice("[✓ Imported %s: %s](fg:green)", path, changeCounts(changes))
+	d.poll()
+}
@@ -0,0 +1,188 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// nodeLoad is how many queues have a replica on a node and how many of them
+// it leads. Classic queues count on the node hosting them; quorum queues and
+// streams on every member, with the leader counted separately.
+type nodeLoad struct {
+	node     string
+	running  bool
+	uptime   int64
+	replicas int
+	leaders  int
+	quorum   int
+}
+
+// queueDistribution counts queues per node. Nodes that hold nothing, such as
+// one that has just restarted, are listed too.
+func queueDistribution(nodes []NodeInfo, queues []QueueInfo) []nodeLoad {
+	loads := make(map[string]*nodeLoad)
+	load := func(node string) *nodeLoad {
+		l, ok := loads[node]
+		if !ok {
+			l = &nodeLoad{node: node}
+			loads[node] = l
+		}
+		return l
+	}
+	for _, n := range nodes {
+		l := load(n.Name)
+		l.running, l.uptime = n.Running, n.Uptime
+	}
+	for _, q := range queues {
+		leader := q.Node
+		if q.Leader != "" {
+			leader = q.Leader
+		}
+		replicas := q.Members
+		if len(replicas) == 0 {
+			replicas = append([]string{q.Node}, q.SlaveNodes...)
+		}
+		for _, node := range replicas {
+			if node != "" {
+				load(node).replicas++
+			}
+		}
+		if leader == "" {
+			continue
+		}
+		load(leader).leaders++
+		if q.Type == "quorum" {
+			load(leader).quorum++
+		}
+	}
+
+	result := make([]nodeLoad, 0, len(loads))
+	for _, l := range loads {
+		result = append(result, *l)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].node < result[j].node })
+	return result
+}
+
+// fairLeaders is how many leaders each running node would hold if they were
+// spread evenly.
+func fairLeaders(loads []nodeLoad) float64 {
+	total, running := 0, 0
+	for _, l := range loads {
+		total += l.leaders
+		if l.running {
+			running++
+		}
+	}
+	if running == 0 {
+		return 0
+	}
+	return float64(total) / float64(running)
+}
+
+// leaderImbalance describes how far a node's leader count is from an even
+// spread, or returns "" when it is close enough. Small differences are
+// ignored: with 5 leaders on 3 nodes one node always has an extra one.
+func leaderImbalance(l nodeLoad, fair float64) string {
+	if !l.running {
+		return ""
+	}
+	diff := float64(l.leaders) - fair
+	if math.Abs(diff) < 2 || math.Abs(diff) < fair/4 {
+		return ""
+	}
+	if diff > 0 {
+		return fmt.Sprintf("%.0f over", diff)
+	}
+	return fmt.Sprintf("%.0f under", -diff)
+}
+
+// distributionView is the per-node queue page. R rebalances quorum queue
+// leaders, as it does on the queue page; confirm is its confirmation, if
+// the policy asks for one.
+type distributionView struct {
+	*listView
+	confirm *typedConfirmation
+}
+
+func newDistributionView() *distributionView {
+	v := &distributionView{}
+	v.listView = newListView("Distribution",
+		[]string{"Node", "Status", "Uptime", "Queues", "Leaders", "Quorum leaders", "Share", "Imbalance"},
+		func(width int) []int { return spreadWidths(width, 0, 8, 9, 8, 8, 15, 7, 12) },
+		v.rows)
+	return v
+}
+
+func (v *distributionView) rows(d *dashboard) ([]listRow, string) {
+	loads := queueDistribution(d.nodes, d.queues)
+	fair := fairLeaders(loads)
+	total := 0
+	for _, l := range loads {
+		total += l.leaders
+	}
+
+	var rows []listRow
+	for _, l := range loads {
+		status, uptime := "running", formatUptime(l.uptime)
+		if !l.running {
+			status, uptime = "down", "-"
+		}
+		share := "-"
+		if total > 0 {
+			share = fmt.Sprintf("%.0f%%", float64(l.leaders)*100/float64(total))
+		}
+		imbalance := leaderImbalance(l, fair)
+
+		detail := fmt.Sprintf("%s holds a replica of %d queues and leads %d of them (%d quorum).\nAn even spread would be %.1f leaders per running node.",
+			l.node, l.replicas, l.leaders, l.quorum, fair)
+		switch {
+		case !l.running:
+			detail += "\n[The node is down; its queues are led elsewhere or unavailable.](fg:red)"
+		case imbalance != "" && float64(l.leaders) < fair:
+			detail += fmt.Sprintf("\n[Up %s and leading too few queues, as happens after a restart. Press R to rebalance quorum queue leaders.](fg:yellow)", uptime)
+		case imbalance != "":
+			detail += "\n[Leading more than its share. Press R to rebalance quorum queue leaders.](fg:yellow)"
+		}
+		rows = append(rows, listRow{
+			cells:  []string{l.node, status, uptime, fmt.Sprint(l.replicas), fmt.Sprint(l.leaders), fmt.Sprint(l.quorum), share, imbalance},
+			broken: !l.running || imbalance != "",
+			detail: detail,
+			key:    l.node,
+		})
+	}
+	return rows, "No node information available."
+}
+
+func (v *distributionView) Render(d *dashboard, ui uiState) {
+	v.prompt = ""
+	if v.confirm != nil {
+		v.prompt = v.confirm.Prompt()
+	}
+	v.listView.Render(d, ui)
+}
+
+func (v *distributionView) HandleKey(d *dashboard, id string) bool {
+	if v.confirm != nil {
+		if done, confirmed := v.confirm.Feed(id); done {
+			if !confirmed {
+				d.setNotice("%s of %s cancelled", v.confirm.action, v.confirm.name)
+			}
+			v.confirm = nil
+		}
+		return true
+	}
+	if id == "R" {
+		if !d.readOnly("rebalancing") {
+			v.confirm = d.rebalanceConfirmation()
+		}
+		return true
+	}
+	return v.listView.HandleKey(d, id)
+}
+
+// Capturing reports whether a rebalance is being confirmed.
+func (v *distributionView) Capturing() bool {
+	return v.confirm != nil
+}
@@ -0,0 +1,325 @@
+package ui
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// drainBatch is how many drained messages are written and synced to the
+// file before they are acknowledged, removing them from the queue.
+const drainBatch = 100
+
+// drainFormats are the file formats messages can be drained to. "ndjson"
+// writes one message per line as the management API describes it, with
+// binary payloads base64-encoded; "binary" writes each message as a
+// length-prefixed JSON header and the length-prefixed payload as it is.
+var drainFormats = []string{"ndjson", "binary"}
+
+// drainExtensions name drain files by format, and tell formats apart when
+// they are read back.
+var drainExtensions = map[string]string{"ndjson": ".ndjson", "binary": ".bin"}
+
+// drainFileName names a drain file after the queue and the time, e.g.
+// drain-root-orders-20240501-142233.ndjson.
+func drainFileName(q QueueInfo, format string, at time.Time) string {
+	return fmt.Sprintf("drain-%s-%s-%s%s", safeVHostName(q.VHost), safeFileName(q.Name), at.Format("20060102-150405"), drainExtensions[format])
+}
+
+// writeDrained appends one message to a drain file.
+func writeDrained(w io.Writer, format string, m MessageInfo, body []byte) error {
+	if format == "ndjson" {
+		line, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(line, '\n'))
+		return err
+	}
+	m.Payload, m.PayloadEncoding = "", ""
+	header, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	for _, part := range [][]byte{header, body} {
+		if err := binary.Write(w, binary.BigEndian, uint32(len(part))); err != nil {
+			return err
+		}
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drainMessages takes up to n ready messages off q over AMQP and writes
+// them with their properties to a new file at path. Each batch is synced
+// to disk before it is acknowledged, so a message leaves the queue only
+// once it is in the file; when the drain fails or is cancelled, the messages
+// not yet acknowledged go back to the queue.
+func drainMessages(ctx context.Context, config Config, q QueueInfo, path, format string, n int, j *job) error {
+	if config.ReadOnly {
+		return errReadOnly
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+
+	conn, err := amqp.Dial(amqpURI(config) + url.PathEscape(q.VHost))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+
+	var last uint64
+	pending := 0
+	commit := func() error {
+		if pending == 0 {
+			return nil
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if err := file.Sync(); err != nil {
+			return err
+		}
+		if err := ch.Ack(last, true); err != nil {
+			return err
+		}
+		done := pending
+		pending = 0
+		j.update(func() { j.done += done })
+		return nil
+	}
+	for taken := 0; taken < n && ctx.Err() == nil; taken++ {
+		d, ok, err := ch.Get(q.Name, false)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// The queue ran dry before n.
+			j.update(func() { j.total = j.done + pending })
+			break
+		}
+		if err := writeDrained(w, format, deliveryMessage(d), d.Body); err != nil {
+			return err
+		}
+		last = d.DeliveryTag
+		if pending++; pending == drainBatch {
+			if err := commit(); err != nil {
+				return err
+			}
+		}
+	}
+	return commit()
+}
+
+// drainForm asks how many of q's ready messages to drain to which file
+// format, and where. drain is given the file and count to confirm.
+func (d *dashboard) drainForm(q QueueInfo, drain func(path, format string, n int)) *form {
+	return newForm("Drain "+q.Key()+" to a file", []*formField{
+		{label: "Format", choices: drainFormats},
+		{label: "Directory", input: lineInput{value: "."}},
+		{label: "Messages", input: lineInput{value: strconv.Itoa(q.MessagesReady)}},
+	}, func(values map[string]string) error {
+		n, err := strconv.Atoi(values["Messages"])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("messages must be a positive whole number")
+		}
+		dir := values["Directory"]
+		if dir == "" {
+			dir = "."
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", dir)
+		}
+		drain(filepath.Join(dir, drainFileName(q, values["Format"], time.Now())), values["Format"], n)
+		return nil
+	})
+}
+
+// startDrain drains messages of q to a file as a job.
+func (d *dashboard) startDrain(q QueueInfo, path, format string, n int) {
+	config := d.config
+	d.startJob(fmt.Sprintf("Drain %s → %s", q.Key(), filepath.Base(path)), n, func(ctx context.Context, j *job) error {
+		return audited(config, "drain queue", q.Key(), fmt.Sprintf("%d to %s", n, path), func() error {
+			return drainMessages(ctx, config, q, path, format, n, j)
+		})
+	})
+}
+
+// drainReader reads back the messages of a drain file, in either format.
+type drainReader struct {
+	r      *bufio.Reader
+	binary bool
+}
+
+func newDrainReader(r io.Reader, path string) *drainReader {
+	return &drainReader{r: bufio.NewReader(r), binary: filepath.Ext(path) == drainExtensions["binary"]}
+}
+
+// next reads the next message, or returns io.EOF after the last.
+func (dr *drainReader) next() (MessageInfo, error) {
+	var m MessageInfo
+	if !dr.binary {
+		line, err := dr.r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err == nil {
+				return dr.next()
+			}
+			return m, err
+		}
+		return m, json.Unmarshal(line, &m)
+	}
+	var parts [2][]byte
+	for i := range parts {
+		var size uint32
+		if err := binary.Read(dr.r, binary.BigEndian, &size); err != nil {
+			if i == 1 && err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return m, err
+		}
+		parts[i] = make([]byte, size)
+		if _, err := io.ReadFull(dr.r, parts[i]); err != nil {
+			return m, err
+		}
+	}
+	if err := json.Unmarshal(parts[0], &m); err != nil {
+		return m, err
+	}
+	m.Payload, m.PayloadEncoding = string(parts[1]), "string"
+	if !utf8.Valid(parts[1]) {
+		m.Payload, m.PayloadEncoding = base64.StdEncoding.EncodeToString(parts[1]), "base64"
+	}
+	return m, nil
+}
+
+// countDrained counts the messages of the drain file at path, checking
+// that all of it can be read.
+func countDrained(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	dr := newDrainReader(file, path)
+	for n := 0; ; n++ {
+		if _, err := dr.next(); err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, fmt.Errorf("message %d of %s: %w", n+1, path, err)
+		}
+	}
+}
+
+// replayMessages publishes the messages of a drain file to exchange in
+// vhost, rate a second, with their properties and headers unchanged. An
+// empty routing key sends each with its own. Messages the exchange routes
+// nowhere are counted as failed.
+func replayMessages(ctx context.Context, config Config, path, vhost, exchange, routingKey string, rate float64, j *job) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	dr := newDrainReader(file, path)
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+	defer ticker.Stop()
+	for {
+		m, err := dr.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		key := routingKey
+		if key == "" {
+			key = m.RoutingKey
+		}
+		req, err := republishRequest(m, key, nil)
+		if err != nil {
+			return err
+		}
+		routed, err := publishMessage(config, vhost, exchange, req)
+		if err != nil {
+			return err
+		}
+		j.update(func() {
+			j.done++
+			if !routed {
+				j.failed++
+			}
+		})
+	}
+}
+
+// replayForm asks for a drain file to publish again, where to and how
+// fast. By default the messages go straight to queue q through the default
+// exchange.
+func (d *dashboard) replayForm(q QueueInfo) *form {
+	return d.confirmForm(newForm("Replay messages into "+q.VHost, []*formField{
+		{label: "File", hint: "a drain file, .ndjson or .bin"},
+		{label: "Exchange", hint: "empty for the default exchange"},
+		{label: "Routing key", hint: "empty keeps each message's own", input: lineInput{value: q.Name}},
+		{label: "Rate per second", input: lineInput{value: strconv.FormatFloat(d.config.Requeue.RatePerSecond, 'g', -1, 64)}},
+	}, func(values map[string]string) error {
+		path := values["File"]
+		if path == "" {
+			return fmt.Errorf("a file is needed")
+		}
+		rate, err := strconv.ParseFloat(values["Rate per second"], 64)
+		if err != nil || rate <= 0 {
+			return fmt.Errorf("the rate must be a positive number")
+		}
+		n, err := countDrained(path)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("%s has no messages", path)
+		}
+		exchange, key := values["Exchange"], values["Routing key"]
+		target := exchange
+		switch {
+		case target != "":
+		case key != "":
+			target = q.VHost + "/" + key
+		default:
+			target = "their routing keys"
+		}
+		config := d.config
+		d.startJob(fmt.Sprintf("Replay %s → %s", filepath.Base(path), target), n, func(ctx context.Context, j *job) error {
+			return replayMessages(ctx, config, path, q.VHost, exchange, key, rate, j)
+		})
+		return nil
+	}), "replay", func(values map[string]string) (string, string) {
+		return filepath.Base(values["File"]), fmt.Sprintf("Replay %s into %s?", values["File"], q.VHost)
+	})
+}
@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// Dashboard polls one cluster and evaluates its alerts. It is the data model
+// behind every rabbitspy view, for programs that embed the queue table or
+// the alerting in their own UI.
+type Dashboard struct {
+	d *dashboard
+}
+
+// NewDashboard prepares a dashboard for one of config.ClusterConfigs(), loading
+// the alert rules it configures. config should come from LoadConfig. Alerts
+// are passed to notifiers, and to the webhooks config lists, as they are
+// raised. Baselines are not loaded: their file can only be used by one
+// process at a time.
+func NewDashboard(config Config, cluster ClusterConfig, notifiers ...Notifier) (*Dashboard, error) {
+	webhooks, err := newWebhookNotifiers(config, cluster)
+	if err != nil {
+		return nil, err
+	}
+	d := newDashboard(cluster.Name, config.forCluster(cluster), append(notifiers, webhooks...)...)
+	rules, err := loadAlertRules(config.Alerts.RulesFile, config.variableLookup(cluster))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load alert rules for cluster %s: %w", cluster.Name, err)
+	}
+	d.rules = rules
+	return &Dashboard{d: d}, nil
+}
+
+// Poll fetches the cluster's state from the management API and updates the
+// active alerts. It is not safe to call concurrently with the other methods.
+func (d *Dashboard) Poll() {
+	d.d.poll()
+}
+
+func (d *Dashboard) Name() string              { return d.d.name }
+func (d *Dashboard) Queues() []QueueInfo       { return d.d.queues }
+func (d *Dashboard) Overview() *Overview       { return d.d.overview }
+func (d *Dashboard) Nodes() []NodeInfo         { return d.d.nodes }
+func (d *Dashboard) Alerts() []Alert           { return d.d.activeAlerts }
+func (d *Dashboard) LastUpdate() time.Time     { return d.d.lastUpdate }
+func (d *Dashboard) Err() error                { return d.d.lastErr }
+func (d *Dashboard) Summary() string           { return clusterSummary(d.d.queues, d.d.overview) }
+func (d *Dashboard) Bindings() []BindingInfo   { return d.d.bindings }
+func (d *Dashboard) Exchanges() []ExchangeInfo { return d.d.exchanges }
+
+// QueueTable is a termui widget showing a dashboard's queues with the
+// configured columns and tiers, laid out to fit its own rect.
+type QueueTable struct {
+	*widgets.Table
+	columns []queueColumn
+	frozen  int
+	tiers   []TierConfig
+	sort    []sortKey
+}
+
+func NewQueueTable(config Config) (*QueueTable, error) {
+	columns, err := resolveQueueColumns(config.UI.Columns)
+	if err != nil {
+		return nil, err
+	}
+	table := widgets.NewTable()
+	table.TextStyle = termui.NewStyle(termui.ColorWhite)
+	table.TextAlignment = termui.AlignLeft
+	table.RowSeparator = false
+	table.FillRow = true
+	return &QueueTable{Table: table, columns: columns, frozen: config.UI.FrozenColumns, tiers: config.Tiers, sort: config.queueSort()}, nil
+}
+
+// Update fills the table from d. Call it after SetRect and each Poll.
+func (t *QueueTable) Update(d *Dashboard) {
+	visible, widths := layoutColumns(t.columns, t.frozen, 0, t.GetRect().Dx())
+	header := make([]string, len(visible))
+	for k, i := range visible {
+		header[k] = fmt.Sprintf("[%s](fg:black,bg:yellow)", truncateString(t.columns[i].header, widths[k]))
+	}
+	rows := [][]string{header}
+	t.RowStyles = make(map[int]termui.Style)
+	counts := bindingCounts(d.d.bindings)
+	lags := streamLags(d.d.streamConsumers, d.d.queues)
+	for i, q := range sortByTier(t.tiers, sortQueues(t.sort, d.d.queues)) {
+		if tier := queueTier(t.tiers, q); tier < len(t.tiers) {
+			if style, ok := t.tiers[tier].style(); ok {
+				t.RowStyles[i+1] = style
+			}
+		}
+		prev, seen := d.d.previous[q.Key()]
+		cell := queueCell{queue: q, prev: prev, flash: d.d.flashing() && seen, bindings: counts[q.Key()], lag: lags[q.Key()]}
+		_, cell.behind = d.d.behind[q.Key()]
+		rows = append(rows, queueRow(t.columns, visible, widths, cell))
+	}
+	t.ColumnWidths = widths
+	t.Rows = rows
+}
+
+// AlertList is a termui widget listing a dashboard's active alerts.
+type AlertList struct {
+	*widgets.Paragraph
+}
+
+func NewAlertList() *AlertList {
+	p := widgets.NewParagraph()
+	p.BorderStyle = termui.NewStyle(termui.ColorRed)
+	return &AlertList{Paragraph: p}
+}
+
+// Update fills the list from d.
+func (l *AlertList) Update(d *Dashboard) {
+	renderAlerts(l.Paragraph, d.d.activeAlerts)
+}
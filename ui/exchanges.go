@@ -0,0 +1,240 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// exchangePath is the API path of an exchange, with the vhost and name
+// escaped.
+func exchangePath(vhost, name string) string {
+	return "/api/exchanges/" + url.PathEscape(vhost) + "/" + url.PathEscape(name)
+}
+
+// isSystemExchange reports the default exchange and the amq.* exchanges
+// every vhost is created with, which clients expect to exist.
+func isSystemExchange(e ExchangeInfo) bool {
+	return e.Name == "" || strings.HasPrefix(e.Name, "amq.")
+}
+
+// deleteExchange deletes e and its bindings. With ifUnused the broker
+// refuses when it is the source of any binding.
+func deleteExchange(config Config, e ExchangeInfo, ifUnused bool) error {
+	path := exchangePath(e.VHost, e.Name)
+	if ifUnused {
+		path += "?if-unused=true"
+	}
+	return apiRequest(config, "DELETE", path, nil, nil)
+}
+
+// expectExchangeDeleted checks that a deleted exchange is gone. Exchanges
+// are not part of the queue listing, so it reads them from the dashboard.
+func expectExchangeDeleted(d *dashboard, e ExchangeInfo) verification {
+	return verification{check: func([]QueueInfo) (string, bool) {
+		for _, x := range d.exchanges {
+			if x.Key() == e.Key() {
+				return fmt.Sprintf("exchange %s still exists", e.Key()), false
+			}
+		}
+		return fmt.Sprintf("deleted exchange %s", e.Key()), true
+	}}
+}
+
+// startExchangeDelete deletes an exchange, refusing the system ones, and
+// checks the following polls for it to be gone.
+func (d *dashboard) startExchangeDelete(e ExchangeInfo, ifUnused bool) {
+	if isSystemExchange(e) {
+		d.setNotice("[%s is a system exchange and is not deleted](fg:red)", exchangeName(e))
+		return
+	}
+	if err := deleteExchange(d.config, e, ifUnused); err != nil {
+		d.setNotice("[Delete of exchange %s failed: %s](fg:red)", e.Key(), err)
+		return
+	}
+	d.verifyAfter(expectExchangeDeleted(d, e))
+}
+
+// exchangeName is how an exchange is listed; the default exchange has no
+// name of its own.
+func exchangeName(e ExchangeInfo) string {
+	if e.Name == "" {
+		return e.VHost + "/(AMQP default)"
+	}
+	return e.Key()
+}
+
+// routesNothing reports an exchange that is being published to but passes
+// nothing on, usually because it has no binding matching the routing keys
+// publishers use.
+func routesNothing(e ExchangeInfo) bool {
+	return e.MessageStats.PublishInDetails.Rate > 0 && e.MessageStats.PublishOutDetails.Rate == 0
+}
+
+// exchangesView lists exchanges with their publish-in, publish-out and
+// confirm rates. Exchanges that receive messages but route none are shown
+// in red and listed first, then the busiest ones. form is the publish
+// dialog and confirm a delete waiting for the name, if open.
+type exchangesView struct {
+	*listView
+	form    *form
+	confirm *typedConfirmation
+}
+
+func newExchangesView() *exchangesView {
+	v := &exchangesView{}
+	v.listView = newListView("Exchanges",
+		[]string{"Exchange", "Type", "In/s", "Out/s", "Confirm/s", "Bindings"},
+		func(width int) []int { return spreadWidths(width, 0, 8, 8, 8, 10, 9) },
+		v.rows)
+	return v
+}
+
+func (v *exchangesView) rows(d *dashboard) ([]listRow, string) {
+	exchanges := append([]ExchangeInfo(nil), d.exchanges...)
+	sort.SliceStable(exchanges, func(i, j int) bool {
+		if a, b := routesNothing(exchanges[i]), routesNothing(exchanges[j]); a != b {
+			return a
+		}
+		if a, b := exchanges[i].MessageStats.PublishInDetails.Rate, exchanges[j].MessageStats.PublishInDetails.Rate; a != b {
+			return a > b
+		}
+		return exchanges[i].Key() < exchanges[j].Key()
+	})
+
+	bindings := make(map[string]int)
+	for _, b := range d.bindings {
+		bindings[b.VHost+"/"+b.Source]++
+	}
+
+	var rows []listRow
+	for _, e := range exchanges {
+		stats := e.MessageStats
+		count := fmt.Sprint(bindings[e.Key()])
+		if e.Name == "" {
+			// Every queue is bound to the default exchange by its name.
+			count = "-"
+		}
+
+		detail := fmt.Sprintf("%s (%s) received %.1f msg/s and routed %.1f msg/s; %.1f msg/s were confirmed to publishers.\nTotals: %d in, %d out, %d confirmed.",
+			exchangeName(e), e.Type, stats.PublishInDetails.Rate, stats.PublishOutDetails.Rate, stats.ConfirmDetails.Rate,
+			stats.PublishIn, stats.PublishOut, stats.Confirm)
+		if e.Internal {
+			detail += "\nInternal: only other exchanges can publish to it."
+		}
+		if routesNothing(e) {
+			detail += "\n[Receives messages but routes none: check its bindings against the routing keys publishers use.](fg:red)"
+		}
+		rows = append(rows, listRow{
+			cells: []string{
+				exchangeName(e), e.Type,
+				rateCell(stats.PublishInDetails.Rate), rateCell(stats.PublishOutDetails.Rate), rateCell(stats.ConfirmDetails.Rate),
+				count,
+			},
+			broken: routesNothing(e),
+			detail: detail,
+			key:    e.Key(),
+		})
+	}
+	return rows, "No exchanges."
+}
+
+func (v *exchangesView) Render(d *dashboard, ui uiState) {
+	v.prompt = ""
+	if v.confirm != nil {
+		v.prompt = v.confirm.Prompt()
+	}
+	v.listView.Render(d, ui)
+	if v.form != nil {
+		v.form.Render()
+	}
+}
+
+// exchangeWrites are the exchange page's keys whose actions change the
+// broker, with what they do, for read-only mode to refuse.
+var exchangeWrites = map[string]string{"S": "publishing", "L": "binding", "U": "unbinding", "D": "deleting"}
+
+func (v *exchangesView) HandleKey(d *dashboard, id string) bool {
+	if v.form != nil {
+		if v.form.Feed(id) {
+			v.form = nil
+		}
+		return true
+	}
+	if v.confirm != nil {
+		if done, confirmed := v.confirm.Feed(id); done {
+			if !confirmed {
+				d.setNotice("%s of %s cancelled", v.confirm.action, v.confirm.name)
+			}
+			v.confirm = nil
+		}
+		return true
+	}
+	if action, ok := exchangeWrites[id]; ok && d.readOnly(action) {
+		return true
+	}
+	switch id {
+	case "S":
+		if e, ok := v.selectedExchange(d); ok {
+			v.form = d.publishForm(e.VHost, e.Name, "")
+		}
+		return true
+	case "L", "U":
+		vhost, source := "/", ""
+		if e, ok := v.selectedExchange(d); ok {
+			vhost, source = e.VHost, e.Name
+		}
+		if id == "L" {
+			v.form = d.bindForm(vhost, source, "queue", "")
+		} else {
+			v.form = d.unbindForm(vhost, source, "queue", "")
+		}
+		return true
+	case "D":
+		e, ok := v.selectedExchange(d)
+		if !ok {
+			return true
+		}
+		if isSystemExchange(e) {
+			d.setNotice("[%s is a system exchange and cannot be deleted from here](fg:yellow)", exchangeName(e))
+			return true
+		}
+		bindings := 0
+		for _, b := range d.bindings {
+			if b.VHost == e.VHost && (b.Source == e.Name || b.DestinationType == "exchange" && b.Destination == e.Name) {
+				bindings++
+			}
+		}
+		c := &typedConfirmation{
+			action:   "Delete",
+			name:     e.Name,
+			question: fmt.Sprintf("Delete exchange %s and its %d bindings?", e.Key(), bindings),
+			toggles:  []confirmToggle{{key: "<C-u>", label: "if unused"}},
+		}
+		c.run = func() { d.startExchangeDelete(e, c.toggled("<C-u>")) }
+		v.confirm = d.confirm("delete_exchange", c)
+		return true
+	}
+	return v.listView.HandleKey(d, id)
+}
+
+// selectedExchange returns the exchange of the selected row.
+func (v *exchangesView) selectedExchange(d *dashboard) (ExchangeInfo, bool) {
+	row, ok := v.selectedRow(d)
+	if !ok {
+		return ExchangeInfo{}, false
+	}
+	for _, e := range d.exchanges {
+		if e.Key() == row.key {
+			return e, true
+		}
+	}
+	return ExchangeInfo{}, false
+}
+
+// Capturing reports whether the publish dialog or a delete confirmation is
+// open.
+func (v *exchangesView) Capturing() bool {
+	return v.form != nil || v.confirm != nil
+}
@@ -0,0 +1,38 @@
+package ui
+
+import "fmt"
+
+// federationTarget names the local object a link federates and its
+// counterpart upstream.
+func federationTarget(l FederationLink) (string, string) {
+	if l.Type == "queue" {
+		return "queue " + l.Queue, "queue " + l.UpstreamQueue
+	}
+	return "exchange " + l.Exchange, "exchange " + l.UpstreamExchange
+}
+
+func newFederationView() *listView {
+	return newListView("Federation",
+		[]string{"Upstream", "VHost", "Status", "Local", "Upstream object", "Error"},
+		func(width int) []int { return spreadWidths(width, width/6, 10, 10, 0, 0, 0) },
+		func(d *dashboard) ([]listRow, string) {
+			if d.federationMissing {
+				return nil, "The federation plugin is not enabled."
+			}
+			var rows []listRow
+			for _, l := range d.federation {
+				local, upstream := federationTarget(l)
+				detail := fmt.Sprintf("URI: %s  Node: %s  Since: %s\nLocal: %s  Upstream: %s", redactURI(l.URI), l.Node, l.Timestamp, local, upstream)
+				if l.Error != "" {
+					detail += "\n[Last error: " + l.Error + "](fg:red)"
+				}
+				rows = append(rows, listRow{
+					cells:  []string{l.Upstream, l.VHost, l.Status, local, upstream, l.Error},
+					broken: l.Status != "running" && l.Status != "starting",
+					detail: detail,
+					key:    l.VHost + "/" + l.Upstream + "/" + local,
+				})
+			}
+			return rows, "No federation links."
+		})
+}
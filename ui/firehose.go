@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+func vhostPath(vhost string) string {
+	return "/api/vhosts/" + url.PathEscape(vhost)
+}
+
+// setTracing switches the firehose of a vhost on or off, as rabbitmqctl
+// trace_on and trace_off do. Updating a vhost replaces its metadata, so
+// the description, tags and default queue type it has are sent back too.
+func setTracing(config Config, vhost string, on bool) error {
+	var current map[string]interface{}
+	if err := getJSON(config, vhostPath(vhost), &current); err != nil {
+		return err
+	}
+	body := map[string]interface{}{"tracing": on}
+	for _, field := range []string{"description", "tags", "default_queue_type"} {
+		if value, ok := current[field]; ok && value != nil {
+			body[field] = value
+		}
+	}
+	return apiRequest(config, "PUT", vhostPath(vhost), body, nil)
+}
+
+// tracing reports whether the firehose of vhost is on, as of the last poll.
+func tracing(d *dashboard, vhost string) bool {
+	for _, v := range d.vhosts {
+		if v.Name == vhost {
+			return v.Tracing
+		}
+	}
+	return false
+}
+
+// tracingSummary names the vhosts whose firehose is on, for the status
+// line, since tracing slows every publish and delivery until it is turned
+// off again.
+func tracingSummary(d *dashboard) string {
+	var on []string
+	for _, v := range d.vhosts {
+		if v.Tracing {
+			on = append(on, v.Name)
+		}
+	}
+	if len(on) == 0 {
+		return ""
+	}
+	return "[⦿ firehose on: " + strings.Join(on, ", ") + "](fg:yellow)"
+}
+
+// expectTracing checks that a vhost's firehose was switched. Vhosts are not
+// part of the queue listing, so it reads them from the dashboard.
+func expectTracing(d *dashboard, vhost string, on bool) verification {
+	state := map[bool]string{true: "on", false: "off"}
+	return verification{check: func([]QueueInfo) (string, bool) {
+		if tracing(d, vhost) != on {
+			return fmt.Sprintf("waiting for the firehose of %s to turn %s", vhost, state[on]), false
+		}
+		return fmt.Sprintf("firehose of %s is %s", vhost, state[on]), true
+	}}
+}
+
+// startTracing switches the firehose of a vhost and checks the following
+// polls for the change.
+func (d *dashboard) startTracing(vhost string, on bool) {
+	if err := setTracing(d.config, vhost, on); err != nil {
+		d.setNotice("[Switching the firehose of %s failed: %s](fg:red)", vhost, err)
+		return
+	}
+	d.verifyAfter(expectTracing(d, vhost, on))
+}
@@ -0,0 +1,27 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+)
+
+// flashDuration is how long a changed cell stays highlighted after a poll.
+const flashDuration = 1500 * time.Millisecond
+
+// flashing reports whether cells that changed in the last poll should still
+// be highlighted.
+func (d *dashboard) flashing() bool {
+	return d.previous != nil && time.Since(d.lastUpdate) < flashDuration
+}
+
+// flashCell highlights value when it moved since the previous poll: red
+// when it grew, green when it shrank. Otherwise styled is returned as is.
+func flashCell(styled, value string, now, before int64, flash bool) string {
+	if !flash || now == before {
+		return styled
+	}
+	if now > before {
+		return fmt.Sprintf("[%s](fg:black,bg:red)", value)
+	}
+	return fmt.Sprintf("[%s](fg:black,bg:green)", value)
+}
@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// flowIndicator marks objects the broker is holding back with credit flow,
+// as the S column does for queues.
+const flowIndicator = "[≈ flow](fg:yellow,mod:bold)"
+
+// flowSummary counts the channels, connections and queues in flow control,
+// or returns "" when there are none. Flow control slows publishers down
+// without any queue growing, which is why it is shown on every page.
+func flowSummary(d *dashboard) string {
+	plural := func(n int, what string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s", what)
+		}
+		return fmt.Sprintf("%d %ss", n, what)
+	}
+	var parts []string
+	channels := 0
+	for _, c := range d.channels {
+		if c.State == "flow" {
+			channels++
+		}
+	}
+	if channels > 0 {
+		parts = append(parts, plural(channels, "channel"))
+	}
+	connections := 0
+	for _, c := range d.connections {
+		if c.State == "flow" {
+			connections++
+		}
+	}
+	if connections > 0 {
+		parts = append(parts, plural(connections, "connection"))
+	}
+	queues := 0
+	for _, q := range d.queues {
+		if queueState(q) == "flow" {
+			queues++
+		}
+	}
+	if queues > 0 {
+		parts = append(parts, plural(queues, "queue"))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("[≈ flow control: %s](fg:yellow,mod:bold)", strings.Join(parts, ", "))
+}
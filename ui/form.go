@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// formField is one line of a form: free text, or one of choices picked with
+// the left and right keys. hint is shown next to an empty text field.
+type formField struct {
+	label   string
+	hint    string
+	input   lineInput
+	choices []string
+	choice  int
+}
+
+func (f *formField) value() string {
+	if len(f.choices) > 0 {
+		return f.choices[f.choice]
+	}
+	return strings.TrimSpace(f.input.value)
+}
+
+// form collects several values at once in a box drawn over the page. submit
+// gets the values by label; when it fails the form stays open with the
+// error, so nothing typed is lost. preview, if set, describes the effect of
+// the values typed so far and is redrawn as they change. confirm, if set,
+// asks for the submission to be confirmed first; confirming is that
+// confirmation while it is typed.
+type form struct {
+	fields     []*formField
+	focus      int
+	submit     func(values map[string]string) error
+	preview    func(values map[string]string) string
+	confirm    func(values map[string]string) *typedConfirmation
+	confirming *typedConfirmation
+	err        string
+	box        *widgets.Paragraph
+}
+
+func newForm(title string, fields []*formField, submit func(map[string]string) error) *form {
+	box := widgets.NewParagraph()
+	box.Title = " " + title + " "
+	box.BorderStyle = termui.NewStyle(termui.ColorYellow)
+	return &form{fields: fields, submit: submit, box: box}
+}
+
+// Feed applies one key event and reports whether the form was submitted or
+// cancelled.
+func (f *form) Feed(id string) (done bool) {
+	if f.confirming != nil {
+		done, confirmed := f.confirming.Feed(id)
+		if !done {
+			return false
+		}
+		f.confirming = nil
+		if !confirmed {
+			f.err = "not confirmed"
+			return false
+		}
+		return f.submitValues()
+	}
+	field := f.fields[f.focus]
+	switch id {
+	case "<Escape>":
+		return true
+	case "<Enter>":
+		if f.confirm != nil {
+			f.confirming = f.confirm(f.values())
+			return false
+		}
+		return f.submitValues()
+	case "<Tab>", "<Down>":
+		f.focus = (f.focus + 1) % len(f.fields)
+	case "<Up>":
+		f.focus = (f.focus + len(f.fields) - 1) % len(f.fields)
+	case "<Left>", "<Right>", "<Space>":
+		if len(field.choices) == 0 {
+			field.input.Feed(id)
+			break
+		}
+		step := 1
+		if id == "<Left>" {
+			step = len(field.choices) - 1
+		}
+		field.choice = (field.choice + step) % len(field.choices)
+	default:
+		if len(field.choices) == 0 {
+			field.input.Feed(id)
+		}
+	}
+	return false
+}
+
+// submitValues submits the form, keeping it open with the error if that
+// fails.
+func (f *form) submitValues() (done bool) {
+	if err := f.submit(f.values()); err != nil {
+		f.err = err.Error()
+		return false
+	}
+	return true
+}
+
+// values are the field values by label.
+func (f *form) values() map[string]string {
+	values := make(map[string]string, len(f.fields))
+	for _, field := range f.fields {
+		values[field.label] = field.value()
+	}
+	return values
+}
+
+// Render draws the form centred over whatever the page drew.
+func (f *form) Render() {
+	width, height := termui.TerminalDimensions()
+	labelWidth := 0
+	for _, field := range f.fields {
+		if len(field.label) > labelWidth {
+			labelWidth = len(field.label)
+		}
+	}
+
+	var lines []string
+	for i, field := range f.fields {
+		value := field.input.value
+		if len(field.choices) > 0 {
+			value = "‹ " + field.value() + " ›"
+		} else if i == f.focus {
+			value += "_"
+		}
+		if field.hint != "" && (value == "" || value == "_") {
+			value += "[" + field.hint + "](fg:white)"
+		}
+		label := fmt.Sprintf("%-*s", labelWidth, field.label)
+		if i == f.focus {
+			label = "[" + label + "](fg:black,bg:yellow)"
+		}
+		lines = append(lines, label+"  "+value)
+	}
+	lines = append(lines, "")
+	if f.preview != nil {
+		lines = append(lines, strings.Split(f.preview(f.values()), "\n")...)
+	}
+	if f.err != "" {
+		lines = append(lines, "[✗ "+f.err+"](fg:red)")
+	}
+	if f.confirming != nil {
+		lines = append(lines, "["+f.confirming.question+"](fg:red,mod:bold)", f.confirming.entry())
+	} else {
+		lines = append(lines, "[Tab/↑↓ field · ←→ choice · Enter to submit · Esc to cancel](fg:white)")
+	}
+
+	boxWidth := width * 2 / 3
+	if boxWidth < 60 {
+		boxWidth = width
+	}
+	boxHeight := len(lines) + 2
+	x, y := (width-boxWidth)/2, (height-boxHeight)/2
+	if y < 0 {
+		y = 0
+	}
+	f.box.Text = strings.Join(lines, "\n")
+	f.box.SetRect(x, y, x+boxWidth, y+boxHeight)
+	drawWidgets(f.box)
+}
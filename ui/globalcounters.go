@@ -0,0 +1,212 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// globalCounter is a row of the global counters page: the cluster-wide
+// total of the rabbitmq_global_* metrics it adds up. Only samples whose
+// labels match are included, which tells dead-lettered messages from ones
+// dropped because their queue has no dead-letter exchange.
+type globalCounter struct {
+	label   string
+	metrics []string
+	match   func(labels map[string]string) bool
+	help    string
+}
+
+// deadLetterReasons are the metrics counting messages that left a queue
+// through dead-lettering, whether or not a dead-letter exchange received
+// them.
+var deadLetterReasons = []string{
+	"rabbitmq_global_messages_dead_lettered_expired_total",
+	"rabbitmq_global_messages_dead_lettered_maxlen_total",
+	"rabbitmq_global_messages_dead_lettered_rejected_total",
+	"rabbitmq_global_messages_dead_lettered_delivery_limit_total",
+}
+
+var globalCounters = []globalCounter{
+	{"Published", []string{"rabbitmq_global_messages_received_total"}, nil,
+		"Messages received from publishers."},
+	{"Confirmed", []string{"rabbitmq_global_messages_confirmed_total"}, nil,
+		"Messages confirmed to publishers that use publisher confirms."},
+	{"Routed", []string{"rabbitmq_global_messages_routed_total"}, nil,
+		"Messages routed to at least one queue."},
+	{"Delivered", []string{"rabbitmq_global_messages_delivered_total"}, nil,
+		"Messages delivered to consumers or fetched with basic.get."},
+	{"Acknowledged", []string{"rabbitmq_global_messages_acknowledged_total"}, nil,
+		"Messages acknowledged by consumers."},
+	{"Dead-lettered", deadLetterReasons, func(labels map[string]string) bool { return labels["dead_letter_strategy"] != "disabled" },
+		"Messages that expired, overflowed a length limit, were rejected or hit the delivery limit, and went to a dead-letter exchange."},
+	{"Dropped, no DLX", deadLetterReasons, func(labels map[string]string) bool { return labels["dead_letter_strategy"] == "disabled" },
+		"Messages that would have been dead-lettered, lost because their queue has no dead-letter exchange."},
+	{"Unroutable dropped", []string{"rabbitmq_global_messages_unroutable_dropped_total"}, nil,
+		"Messages no binding matched, dropped without the publisher knowing."},
+	{"Unroutable returned", []string{"rabbitmq_global_messages_unroutable_returned_total"}, nil,
+		"Messages no binding matched, returned to publishers that set mandatory."},
+}
+
+// metricSample is one line of the Prometheus text format.
+type metricSample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+// parseMetrics reads the Prometheus text format, keeping only the samples
+// whose name starts with prefix. Comments and lines it can't read are
+// skipped.
+func parseMetrics(r io.Reader, prefix string) ([]metricSample, error) {
+	var samples []metricSample
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		s := metricSample{labels: make(map[string]string)}
+		rest := line
+		if i := strings.IndexAny(line, "{ "); i >= 0 {
+			s.name, rest = line[:i], line[i:]
+		}
+		if strings.HasPrefix(rest, "{") {
+			end := strings.Index(rest, "}")
+			if end < 0 {
+				continue
+			}
+			for _, pair := range strings.Split(rest[1:end], ",") {
+				k, v, ok := strings.Cut(pair, "=")
+				if ok {
+					s.labels[strings.TrimSpace(k)] = strings.Trim(v, `"`)
+				}
+			}
+			rest = rest[end+1:]
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		s.value = value
+		samples = append(samples, s)
+	}
+	return samples, scanner.Err()
+}
+
+// getGlobalMetrics fetches the rabbitmq_global_* counters from the
+// rabbitmq_prometheus plugin, which RabbitMQ 3.10 and later serve.
+func getGlobalMetrics(config Config) ([]metricSample, error) {
+	endpoint := fmt.Sprintf("http://%s:%s/metrics", config.RabbitMQ.Host, config.RabbitMQ.PrometheusPort)
+	client := &http.Client{Timeout: apiTimeout}
+	resp, err := client.Get(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &apiError{Status: resp.StatusCode}
+	}
+	return parseMetrics(resp.Body, "rabbitmq_global_")
+}
+
+// counterTotals adds up each global counter over the samples, across
+// protocols and queue types.
+func counterTotals(samples []metricSample) map[string]float64 {
+	totals := make(map[string]float64)
+	for _, c := range globalCounters {
+		for _, s := range samples {
+			if !slices.Contains(c.metrics, s.name) || (c.match != nil && !c.match(s.labels)) {
+				continue
+			}
+			totals[c.label] += s.value
+		}
+	}
+	return totals
+}
+
+// counterTracker keeps the last two readings of the global counters, so
+// their rate can be worked out.
+type counterTracker struct {
+	at, prevAt   time.Time
+	totals, prev map[string]float64
+	lastErr      error
+	missing      bool
+}
+
+// Record takes in a reading made at now.
+func (t *counterTracker) Record(totals map[string]float64, now time.Time) {
+	t.prev, t.prevAt = t.totals, t.at
+	t.totals, t.at = totals, now
+}
+
+// Rate is how fast the counter grew between the last two readings, or 0
+// before there are two. A counter that went down was reset by a node
+// restart, so it has no rate either.
+func (t *counterTracker) Rate(label string) float64 {
+	if t.prev == nil {
+		return 0
+	}
+	elapsed := t.at.Sub(t.prevAt).Seconds()
+	delta := t.totals[label] - t.prev[label]
+	if elapsed <= 0 || delta < 0 {
+		return 0
+	}
+	return delta / elapsed
+}
+
+// globalCountersView shows the cluster-wide message counters of the
+// Prometheus endpoint with their current rates.
+type globalCountersView struct {
+	*listView
+}
+
+func newGlobalCountersView() *globalCountersView {
+	v := &globalCountersView{}
+	v.listView = newListView("Global counters",
+		[]string{"Counter", "Total", "Rate/s"},
+		func(width int) []int { return spreadWidths(width, 0, 16, 12) },
+		v.rows)
+	return v
+}
+
+func (v *globalCountersView) rows(d *dashboard) ([]listRow, string) {
+	t := d.counters
+	switch {
+	case d.config.RabbitMQ.PrometheusPort == "":
+		return nil, "Set prometheus_port (usually 15692) for the cluster to show the global counters of the rabbitmq_prometheus plugin, RabbitMQ 3.10 or later."
+	case t.missing:
+		return nil, "[The Prometheus endpoint serves no global counters; they need RabbitMQ 3.10 or later.](fg:yellow)"
+	case t.totals == nil && t.lastErr != nil:
+		return nil, fmt.Sprintf("[Prometheus endpoint unavailable: %s](fg:red)", t.lastErr)
+	case t.totals == nil:
+		return nil, "Loading..."
+	}
+
+	var rows []listRow
+	for _, c := range globalCounters {
+		rate := t.Rate(c.label)
+		detail := c.help + "\nSource: " + strings.Join(c.metrics, ", ")
+		if c.match != nil {
+			detail += " (by dead_letter_strategy)"
+		}
+		broken := rate > 0 && (c.label == "Dropped, no DLX" || c.label == "Unroutable dropped")
+		rows = append(rows, listRow{
+			cells:  []string{c.label, groupDigits(int(t.totals[c.label])), rateCell(rate)},
+			broken: broken,
+			detail: detail,
+			key:    c.label,
+		})
+	}
+	return rows, ""
+}
@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// queueGraph is the full-screen time-series view of a single queue, drawn
+// from the samples buffered in queueHistory.
+type queueGraph struct {
+	counts *annotatedPlot
+	rates  *annotatedPlot
+	footer *widgets.Paragraph
+
+	// prompt, when set, replaces the footer text while an annotation is
+	// being typed.
+	prompt string
+}
+
+func newQueueGraph() *queueGraph {
+	counts := widgets.NewPlot()
+	counts.LineColors = []termui.Color{termui.ColorYellow, termui.ColorRed}
+	counts.AxesColor = termui.ColorWhite
+	counts.BorderStyle = termui.NewStyle(termui.ColorCyan)
+
+	rates := widgets.NewPlot()
+	rates.LineColors = []termui.Color{termui.ColorGreen, termui.ColorCyan}
+	rates.AxesColor = termui.ColorWhite
+	rates.BorderStyle = termui.NewStyle(termui.ColorCyan)
+
+	footer := widgets.NewParagraph()
+	footer.BorderStyle = termui.NewStyle(termui.ColorYellow)
+
+	return &queueGraph{counts: &annotatedPlot{Plot: counts}, rates: &annotatedPlot{Plot: rates}, footer: footer}
+}
+
+// plotSeries prepares values for a termui line plot, which needs at least
+// two points per line and draws from the left edge without clipping.
+func plotSeries(values []float64, width int) []float64 {
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	for len(values) < 2 {
+		values = append([]float64{0}, values...)
+	}
+	return values
+}
+
+func plotMax(series ...[]float64) float64 {
+	max := 1.0
+	for _, s := range series {
+		for _, v := range s {
+			if v > max {
+				max = v
+			}
+		}
+	}
+	return max
+}
+
+func (g *queueGraph) Render(d *dashboard, key string, ui uiState) {
+	width, height := termui.TerminalDimensions()
+	termui.Clear()
+
+	samples := d.history.Samples(key)
+	plotWidth := width - 8
+
+	ready := plotSeries(lastN(samples, plotWidth, func(s queueSample) float64 { return float64(s.ready) }), plotWidth)
+	unacked := plotSeries(lastN(samples, plotWidth, func(s queueSample) float64 { return float64(s.unacked) }), plotWidth)
+	publish := plotSeries(lastN(samples, plotWidth, func(s queueSample) float64 { return s.publishRate }), plotWidth)
+	deliver := plotSeries(lastN(samples, plotWidth, func(s queueSample) float64 { return s.deliverRate }), plotWidth)
+
+	g.counts.Title = fmt.Sprintf(" %s: ready (yellow) / unacked (red) ", key)
+	g.counts.Data = [][]float64{ready, unacked}
+	g.counts.MaxVal = plotMax(ready, unacked)
+	g.rates.Title = " msg/s: publish (green) / deliver (cyan) "
+	g.rates.Data = [][]float64{publish, deliver}
+	g.rates.MaxVal = plotMax(publish, deliver)
+
+	span := "no samples yet"
+	if len(samples) > 0 {
+		last := samples[len(samples)-1]
+		span = fmt.Sprintf("%d samples over %s | ready %d, unacked %d, publish %.1f/s, deliver %.1f/s",
+			len(samples), last.at.Sub(samples[0].at).Round(time.Second),
+			last.ready, last.unacked, last.publishRate, last.deliverRate)
+	}
+	g.footer.Text = span + "  (a to annotate, g/Esc to return)"
+	if ui.paused {
+		g.footer.Text += "  [ PAUSED ](fg:black,bg:red,mod:bold)"
+	}
+	if g.prompt != "" {
+		g.footer.Text = g.prompt
+	}
+
+	// Annotations made while the plotted samples were taken are marked on
+	// both plots and listed under them.
+	var events []annotation
+	g.counts.marks, g.rates.marks = nil, nil
+	if plotted := tail(samples, plotWidth); len(plotted) > 0 {
+		events = d.annotations.Between(plotted[0].at, time.Now())
+		columns := annotationColumns(events, plotted)
+		for i := range columns {
+			// plotSeries pads short series on the left.
+			columns[i] += len(ready) - len(plotted)
+		}
+		g.counts.marks, g.rates.marks = columns, columns
+	}
+	timelineHeight := len(events)
+	if timelineHeight > 5 {
+		timelineHeight = 5
+		events = events[len(events)-5:]
+	}
+	if timelineHeight > 0 {
+		g.footer.Text += "\n" + annotationTimeline(events)
+	}
+	footerHeight := 3 + timelineHeight
+
+	middle := (height - footerHeight) / 2
+	g.counts.SetRect(0, 0, width, middle)
+	g.rates.SetRect(0, middle, width, height-footerHeight)
+	g.footer.SetRect(0, height-footerHeight, width, height)
+	drawWidgets(g.counts, g.rates, g.footer)
+}
@@ -0,0 +1,182 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHeadlessListen is where headless mode serves its endpoints when no
+// address is given.
+const defaultHeadlessListen = ":9912"
+
+// headless runs the dashboards without a UI, for containers: it polls,
+// alerts and records history as the interactive UI does, and serves the
+// state over HTTP. mu guards the dashboards, which the handlers read while
+// polls are applied.
+type headless struct {
+	mu         sync.Mutex
+	dashboards []*dashboard
+}
+
+// routes adds the status, metrics and health endpoints to mux.
+func (h *headless) routes(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", h.health)
+	mux.HandleFunc("/api/status", h.status)
+	mux.HandleFunc("/metrics", h.metrics)
+}
+
+// run polls every interval and takes in annotations until ctx is done.
+func (h *headless) run(ctx context.Context, s *session, interval time.Duration) {
+	for {
+		results := s.fetchAll()
+		for range s.dashboards {
+			f := <-results
+			h.mu.Lock()
+			f.d.apply(f.r)
+			h.mu.Unlock()
+		}
+		wait := time.After(interval)
+	waiting:
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case a := <-s.annotated:
+				h.mu.Lock()
+				s.annotate(a)
+				h.mu.Unlock()
+			case <-wait:
+				break waiting
+			}
+		}
+	}
+}
+
+// health answers 200 while every cluster's last poll succeeded, and 503
+// with the failures otherwise, for container liveness and readiness checks.
+func (h *headless) health(w http.ResponseWriter, _ *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var failing []string
+	for _, d := range h.dashboards {
+		switch {
+		case d.lastErr != nil:
+			failing = append(failing, fmt.Sprintf("%s: %s", d.name, d.lastErr))
+		case d.lastUpdate.IsZero():
+			failing = append(failing, d.name+": not polled yet")
+		}
+	}
+	if len(failing) > 0 {
+		http.Error(w, strings.Join(failing, "\n"), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// clusterStatus is one cluster in the /api/status response.
+type clusterStatus struct {
+	Name       string        `json:"name"`
+	LastUpdate time.Time     `json:"last_update"`
+	Error      string        `json:"error,omitempty"`
+	Queues     []queueStatus `json:"queues"`
+	Alerts     []alertStatus `json:"alerts"`
+}
+
+type queueStatus struct {
+	VHost       string  `json:"vhost"`
+	Name        string  `json:"name"`
+	Type        string  `json:"type"`
+	State       string  `json:"state"`
+	Ready       int     `json:"ready"`
+	Unacked     int     `json:"unacked"`
+	Consumers   int     `json:"consumers"`
+	PublishRate float64 `json:"publish_rate"`
+	DeliverRate float64 `json:"deliver_rate"`
+}
+
+type alertStatus struct {
+	Key      string        `json:"key"`
+	Severity AlertSeverity `json:"severity"`
+	Message  string        `json:"message"`
+	Since    time.Time     `json:"since"`
+}
+
+func (h *headless) status(w http.ResponseWriter, _ *http.Request) {
+	h.mu.Lock()
+	clusters := make([]clusterStatus, 0, len(h.dashboards))
+	for _, d := range h.dashboards {
+		c := clusterStatus{Name: d.name, LastUpdate: d.lastUpdate, Queues: []queueStatus{}, Alerts: []alertStatus{}}
+		if d.lastErr != nil {
+			c.Error = d.lastErr.Error()
+		}
+		for _, q := range d.queues {
+			c.Queues = append(c.Queues, queueStatus{
+				VHost: q.VHost, Name: q.Name, Type: q.Type, State: queueState(q),
+				Ready: q.MessagesReady, Unacked: q.MessagesUnack, Consumers: q.Consumers,
+				PublishRate: q.MessageStats.PublishDetails.Rate, DeliverRate: q.MessageStats.DeliverGetDetails.Rate,
+			})
+		}
+		for _, a := range d.activeAlerts {
+			c.Alerts = append(c.Alerts, alertStatus{Key: a.Key, Severity: a.Severity, Message: ansiMarkup(a.Message, false), Since: a.Since})
+		}
+		clusters = append(clusters, c)
+	}
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(clusters)
+}
+
+// metrics serves the polled queue figures and active alerts in the
+// Prometheus text format, so rabbitspy's own view, including alerts no
+// exporter knows about, can be scraped.
+func (h *headless) metrics(w http.ResponseWriter, _ *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	label := func(s string) string {
+		return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+	}
+	gauge := func(name, help string, sample func(d *dashboard, emit func(labels string, value float64))) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		for _, d := range h.dashboards {
+			sample(d, func(labels string, value float64) {
+				fmt.Fprintf(w, "%s{cluster=\"%s\"%s} %g\n", name, label(d.name), labels, value)
+			})
+		}
+	}
+	queueGauge := func(name, help string, value func(q QueueInfo) float64) {
+		gauge(name, help, func(d *dashboard, emit func(string, float64)) {
+			for _, q := range d.queues {
+				emit(fmt.Sprintf(`,vhost="%s",queue="%s"`, label(q.VHost), label(q.Name)), value(q))
+			}
+		})
+	}
+
+	gauge("rabbitspy_up", "Whether the last poll of the management API succeeded.", func(d *dashboard, emit func(string, float64)) {
+		up := 0.0
+		if d.lastErr == nil && !d.lastUpdate.IsZero() {
+			up = 1
+		}
+		emit("", up)
+	})
+	queueGauge("rabbitspy_queue_messages_ready", "Messages ready for delivery.", func(q QueueInfo) float64 { return float64(q.MessagesReady) })
+	queueGauge("rabbitspy_queue_messages_unacked", "Messages delivered and not yet acknowledged.", func(q QueueInfo) float64 { return float64(q.MessagesUnack) })
+	queueGauge("rabbitspy_queue_consumers", "Consumers of the queue.", func(q QueueInfo) float64 { return float64(q.Consumers) })
+	queueGauge("rabbitspy_queue_publish_rate", "Messages published per second.", func(q QueueInfo) float64 { return q.MessageStats.PublishDetails.Rate })
+	queueGauge("rabbitspy_queue_deliver_rate", "Messages delivered per second.", func(q QueueInfo) float64 { return q.MessageStats.DeliverGetDetails.Rate })
+	gauge("rabbitspy_alerts", "Active alerts by key and severity.", func(d *dashboard, emit func(string, float64)) {
+		alerts := append([]Alert(nil), d.activeAlerts...)
+		sort.Slice(alerts, func(i, j int) bool { return alerts[i].Key < alerts[j].Key })
+		for _, a := range alerts {
+			emit(fmt.Sprintf(`,key="%s",severity="%s"`, label(a.Key), a.Severity), 1)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"unicode/utf8"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// heldMessages are messages fetched over AMQP and not acknowledged yet, so
+// each can be acked or rejected on its own, which the management API's get
+// cannot do. Releasing them closes the channel, which puts the unsettled
+// ones back in the queue.
+type heldMessages struct {
+	config     Config
+	queue      QueueInfo
+	conn       *amqp.Connection
+	ch         *amqp.Channel
+	deliveries []amqp.Delivery
+	// settled says what was done with each message: "", "acked" or
+	// "rejected".
+	settled []string
+}
+
+// holdMessages takes up to count messages from the head of q with
+// basic.get, without acknowledging them.
+func holdMessages(config Config, q QueueInfo, count int) (*heldMessages, []MessageInfo, error) {
+	conn, err := amqp.Dial(amqpURI(config) + url.PathEscape(q.VHost))
+	if err != nil {
+		return nil, nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	h := &heldMessages{config: config, queue: q, conn: conn, ch: ch}
+	var messages []MessageInfo
+	for len(h.deliveries) < count {
+		d, ok, err := ch.Get(q.Name, false)
+		if err != nil {
+			h.release()
+			return nil, nil, err
+		}
+		if !ok {
+			break
+		}
+		h.deliveries = append(h.deliveries, d)
+		h.settled = append(h.settled, "")
+		messages = append(messages, deliveryMessage(d))
+	}
+	return h, messages, nil
+}
+
+// settle acks message i, removing it from the queue, or rejects it without
+// requeueing, which dead-letters it when the queue has a dead-letter
+// exchange and drops it otherwise.
+func (h *heldMessages) settle(i int, ack bool) error {
+	detail := fmt.Sprintf("message %d", i+1)
+	if ack {
+		return audited(h.config, "ack message", h.queue.Key(), detail, func() error {
+			if err := h.deliveries[i].Ack(false); err != nil {
+				return err
+			}
+			h.settled[i] = "acked"
+			return nil
+		})
+	}
+	return audited(h.config, "reject message", h.queue.Key(), detail, func() error {
+		if err := h.deliveries[i].Reject(false); err != nil {
+			return err
+		}
+		h.settled[i] = "rejected"
+		return nil
+	})
+}
+
+func (h *heldMessages) release() {
+	h.ch.Close()
+	h.conn.Close()
+}
+
+// deliveryMessage describes a delivery the way the management API
+// describes a message, so the browser shows both alike.
+func deliveryMessage(d amqp.Delivery) MessageInfo {
+	m := MessageInfo{
+		PayloadBytes:    len(d.Body),
+		Redelivered:     d.Redelivered,
+		Exchange:        d.Exchange,
+		RoutingKey:      d.RoutingKey,
+		MessageCount:    int(d.MessageCount),
+		Payload:         string(d.Body),
+		PayloadEncoding: "string",
+	}
+	if !utf8.Valid(d.Body) {
+		m.Payload, m.PayloadEncoding = base64.StdEncoding.EncodeToString(d.Body), "base64"
+	}
+	p := &m.Properties
+	p.ContentType, p.ContentEncoding = d.ContentType, d.ContentEncoding
+	p.DeliveryMode, p.Priority = int(d.DeliveryMode), int(d.Priority)
+	p.CorrelationID, p.ReplyTo, p.Expiration, p.MessageID = d.CorrelationId, d.ReplyTo, d.Expiration, d.MessageId
+	if !d.Timestamp.IsZero() {
+		p.Timestamp = d.Timestamp.Unix()
+	}
+	p.Type, p.UserID, p.AppID = d.Type, d.UserId, d.AppId
+	if len(d.Headers) > 0 {
+		p.Headers = map[string]interface{}(d.Headers)
+	}
+	return m
+}
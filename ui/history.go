@@ -0,0 +1,71 @@
+package ui
+
+import "time"
+
+// historySize caps each queue's series at one hour of samples at the
+// default refresh interval.
+const historySize = 720
+
+type queueSample struct {
+	at          time.Time
+	ready       int
+	unacked     int
+	publishRate float64
+	deliverRate float64
+}
+
+// queueHistory buffers recent samples per queue between polls so views can
+// draw graphs without asking the management API for history.
+type queueHistory struct {
+	series map[string][]queueSample
+}
+
+func newQueueHistory() *queueHistory {
+	return &queueHistory{series: make(map[string][]queueSample)}
+}
+
+func (h *queueHistory) Record(at time.Time, queues []QueueInfo) {
+	seen := make(map[string]bool, len(queues))
+	for _, q := range queues {
+		key := q.Key()
+		seen[key] = true
+		samples := append(h.series[key], queueSample{
+			at:          at,
+			ready:       q.MessagesReady,
+			unacked:     q.MessagesUnack,
+			publishRate: q.MessageStats.PublishDetails.Rate,
+			deliverRate: q.MessageStats.DeliverGetDetails.Rate,
+		})
+		if len(samples) > historySize {
+			samples = samples[len(samples)-historySize:]
+		}
+		h.series[key] = samples
+	}
+	for key := range h.series {
+		if !seen[key] {
+			delete(h.series, key)
+		}
+	}
+}
+
+func (h *queueHistory) Samples(key string) []queueSample {
+	return h.series[key]
+}
+
+// tail returns the newest n samples.
+func tail(samples []queueSample, n int) []queueSample {
+	if len(samples) > n {
+		return samples[len(samples)-n:]
+	}
+	return samples
+}
+
+// lastN returns the values picked from the newest n samples.
+func lastN(samples []queueSample, n int, pick func(queueSample) float64) []float64 {
+	samples = tail(samples, n)
+	values := make([]float64, len(samples))
+	for i, s := range samples {
+		values[i] = pick(s)
+	}
+	return values
+}
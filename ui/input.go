@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// inputCapturer is implemented by views that can be reading a line of text.
+// While Capturing reports true, main passes every key to the view, including
+// the global ones like q and p.
+type inputCapturer interface {
+	Capturing() bool
+}
+
+// lineInput collects one line of text from termui key events.
+type lineInput struct {
+	value string
+}
+
+// Feed applies one key event and reports whether it ended the input, and
+// whether that was a cancel (Escape) rather than a submit (Enter).
+func (in *lineInput) Feed(id string) (done, cancelled bool) {
+	switch id {
+	case "<Enter>":
+		return true, false
+	case "<Escape>":
+		return true, true
+	case "<Space>":
+		in.value += " "
+	case "<Backspace>", "<C-<Backspace>>":
+		if r := []rune(in.value); len(r) > 0 {
+			in.value = string(r[:len(r)-1])
+		}
+	default:
+		if len([]rune(id)) == 1 {
+			in.value += id
+		}
+	}
+	return false, false
+}
+
+// typedConfirmation guards a destructive action: it runs only after the name
+// of its target has been typed exactly, so a stray key can't set it off.
+// question says what is about to happen; toggles are options switched with
+// their key while typing.
+type typedConfirmation struct {
+	action   string
+	name     string
+	question string
+	toggles  []confirmToggle
+	input    lineInput
+	run      func()
+}
+
+// confirmToggle is an on/off option of a typedConfirmation, such as a
+// precondition the broker should check.
+type confirmToggle struct {
+	key   string
+	label string
+	on    bool
+}
+
+// toggled reports whether the option switched with key is on.
+func (c *typedConfirmation) toggled(key string) bool {
+	for _, t := range c.toggles {
+		if t.key == key {
+			return t.on
+		}
+	}
+	return false
+}
+
+// Feed applies one key event. Once the input ends it reports whether the
+// action ran; a cancel or a name that doesn't match leaves it undone.
+func (c *typedConfirmation) Feed(id string) (done, confirmed bool) {
+	for i := range c.toggles {
+		if c.toggles[i].key == id {
+			c.toggles[i].on = !c.toggles[i].on
+			return false, false
+		}
+	}
+	done, cancelled := c.input.Feed(id)
+	if !done {
+		return false, false
+	}
+	if cancelled || c.input.value != c.name {
+		return true, false
+	}
+	c.run()
+	return true, true
+}
+
+// Prompt shows what is typed so far, in place of the cluster summary.
+func (c *typedConfirmation) Prompt() string {
+	return fmt.Sprintf("[%s](fg:red,mod:bold) ", c.question) + c.entry()
+}
+
+// entry is the part of the prompt after the question: the name to type,
+// what is typed so far and the keys.
+func (c *typedConfirmation) entry() string {
+	prompt := fmt.Sprintf("Type %s to confirm: %s_  [Enter to confirm, Esc to cancel", c.name, c.input.value)
+	for _, t := range c.toggles {
+		box := "[ ]"
+		if t.on {
+			box = "[x]"
+		}
+		key := strings.Trim(t.key, "<>")
+		if strings.HasPrefix(key, "C-") {
+			key = "Ctrl+" + strings.ToUpper(key[2:])
+		}
+		prompt += fmt.Sprintf(", %s %s %s", key, box, t.label)
+	}
+	return prompt + "](fg:white)"
+}
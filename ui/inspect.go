@@ -0,0 +1,430 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// hexdumpWidth is how many bytes a hexdump line shows.
+const hexdumpWidth = 16
+
+// messageInspector shows one message in two panels: its properties and
+// headers, nested ones indented, and its payload, decoded from its content
+// encoding, JSON pretty-printed and coloured, other text as it is and
+// binary payloads as a hexdump. x shows any payload as a hexdump, b decodes
+// it from base64, and the payload panel scrolls on its own.
+type messageInspector struct {
+	masker       *payloadMasker
+	previewBytes int
+	hex          bool
+	// base64 decodes the payload from base64 after its content encodings.
+	base64 bool
+	// text is the payload shown, decoded and masked, for y to copy.
+	text []byte
+
+	properties *widgets.Paragraph
+	payload    *payloadPanel
+}
+
+func newMessageInspector(config BrowserConfig, masker *payloadMasker) *messageInspector {
+	properties := widgets.NewParagraph()
+	properties.Title = " Message "
+	properties.BorderStyle = termui.NewStyle(termui.ColorYellow)
+	payload := &payloadPanel{Block: termui.NewBlock()}
+	payload.BorderStyle = termui.NewStyle(termui.ColorYellow)
+	return &messageInspector{masker: masker, previewBytes: config.PreviewBytes, properties: properties, payload: payload}
+}
+
+// Feed handles the inspector's keys and reports whether it used id. The
+// page keys scroll the payload.
+func (mi *messageInspector) Feed(id string) bool {
+	page := max(mi.payload.Inner.Dy()-1, 1)
+	switch id {
+	case "x":
+		mi.hex = !mi.hex
+		mi.payload.scroll = 0
+	case "b":
+		mi.base64 = !mi.base64
+		mi.payload.scroll = 0
+	case "<PageDown>":
+		mi.payload.scroll += page
+	case "<PageUp>":
+		mi.payload.scroll = max(mi.payload.scroll-page, 0)
+	default:
+		return false
+	}
+	return true
+}
+
+// reset scrolls back to the top, for another message.
+func (mi *messageInspector) reset() {
+	mi.payload.scroll = 0
+}
+
+// show fills both panels with m, intro first in the properties panel.
+func (mi *messageInspector) show(intro string, m MessageInfo) {
+	var lines []string
+	if intro != "" {
+		lines = append(lines, intro, "")
+	}
+	mi.properties.Text = strings.Join(append(lines, propertyLines(m)...), "\n")
+	mi.text = nil
+
+	payload, err := messagePayload(m)
+	if err != nil {
+		mi.payload.Title = " Payload "
+		mi.payload.lines = textCells("Undecodable payload: "+err.Error(), termui.NewStyle(termui.ColorRed))
+		return
+	}
+	payload, steps, err := decodePayload(payload, m.Properties.ContentEncoding, mi.base64)
+	var notes [][]termui.Cell
+	if err != nil {
+		notes = textCells(err.Error(), termui.NewStyle(termui.ColorRed))
+	}
+	decoded := len(payload)
+	payload = mi.masker.Mask(payload)
+	mi.text = payload
+	kind := "text"
+	var indented bytes.Buffer
+	switch {
+	case !isText(payload):
+		kind = "binary"
+	case json.Indent(&indented, payload, "", "  ") == nil:
+		kind = "JSON"
+		if !mi.hex {
+			payload = indented.Bytes()
+		}
+	}
+	cut := 0
+	if len(payload) > mi.previewBytes {
+		cut = len(payload) - mi.previewBytes
+		payload = payload[:mi.previewBytes]
+	}
+	switch {
+	case mi.hex || kind == "binary":
+		mi.payload.lines = hexdumpCells(payload)
+	case kind == "JSON":
+		mi.payload.lines = jsonCells(payload)
+	default:
+		mi.payload.lines = textCells(string(payload), termui.NewStyle(termui.ColorWhite))
+	}
+	mi.payload.lines = append(notes, mi.payload.lines...)
+	if cut > 0 {
+		mi.payload.lines = append(mi.payload.lines, textCells(fmt.Sprintf("... %s not shown", formatBytes(int64(cut))), termui.NewStyle(termui.ColorWhite, termui.ColorClear, termui.ModifierBold))...)
+	}
+	view := "x hexdump"
+	if mi.hex {
+		view = "x formatted"
+	}
+	size := formatBytes(int64(m.PayloadBytes))
+	if len(steps) > 0 {
+		kind = strings.Join(steps, " → ") + " → " + kind
+		size = fmt.Sprintf("%s from %s", formatBytes(int64(decoded)), size)
+	}
+	decode := "b base64"
+	if mi.base64 {
+		decode = "b undo base64"
+	}
+	mi.payload.Title = fmt.Sprintf(" Payload · %s, %s · %s · %s · PgUp/PgDn ", kind, size, view, decode)
+}
+
+// hexdumpLine is how wide a hexdump line is, with the panel's borders.
+const hexdumpLine = 8 + 2 + hexdumpWidth*3 + 1 + 1 + hexdumpWidth + 2 + 2
+
+// SetRect lays the properties panel out left of the payload, leaving the
+// payload room for whole hexdump lines where the screen allows.
+func (mi *messageInspector) SetRect(x0, y0, x1, y1 int) {
+	split := x0 + (x1-x0)*2/5
+	if x1-split < hexdumpLine {
+		split = max(x1-hexdumpLine, x0+(x1-x0)/4)
+	}
+	mi.properties.SetRect(x0, y0, split, y1)
+	mi.payload.SetRect(split, y0, x1, y1)
+}
+
+// error shows err in place of a message.
+func (mi *messageInspector) error(err error) {
+	mi.properties.Text = "[" + err.Error() + "](fg:red)"
+	mi.payload.Title, mi.payload.lines, mi.text = " Payload ", nil, nil
+}
+
+// clear empties both panels.
+func (mi *messageInspector) clear() {
+	mi.properties.Text = ""
+	mi.payload.Title, mi.payload.lines, mi.text = " Payload ", nil, nil
+}
+
+// propertyLines lists the properties a message has set, then its headers,
+// tables and lists of tables, such as x-death, nested below their name.
+func propertyLines(m MessageInfo) []string {
+	p := m.Properties
+	var lines []string
+	add := func(label, value string) {
+		if value != "" {
+			lines = append(lines, fmt.Sprintf("[%s:](fg:cyan) %s", label, value))
+		}
+	}
+	add("Content type", p.ContentType)
+	add("Content encoding", p.ContentEncoding)
+	switch p.DeliveryMode {
+	case 1:
+		add("Delivery mode", "transient")
+	case 2:
+		add("Delivery mode", "persistent")
+	}
+	if p.Priority > 0 {
+		add("Priority", fmt.Sprint(p.Priority))
+	}
+	add("Message ID", p.MessageID)
+	add("Correlation ID", p.CorrelationID)
+	add("Reply to", p.ReplyTo)
+	add("Expiration", p.Expiration)
+	if p.Timestamp > 0 {
+		add("Timestamp", time.Unix(p.Timestamp, 0).Format("2006-01-02 15:04:05"))
+	}
+	add("Type", p.Type)
+	add("User ID", p.UserID)
+	add("App ID", p.AppID)
+	if len(lines) == 0 {
+		lines = append(lines, "No properties set.")
+	}
+	if len(p.Headers) > 0 {
+		lines = append(lines, "", "[Headers](fg:yellow,mod:bold)")
+		lines = append(lines, tableLines(p.Headers, "")...)
+	}
+	return lines
+}
+
+// tableLines lists the fields of a header table by name, indented by
+// indent.
+func tableLines(table map[string]interface{}, indent string) []string {
+	names := make([]string, 0, len(table))
+	for name := range table {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lines []string
+	for _, name := range names {
+		lines = append(lines, valueLines(name, table[name], indent)...)
+	}
+	return lines
+}
+
+// valueLines renders one header field: scalars and lists of them on one
+// line, tables and lists of tables nested below, the entries of a list
+// marked with a dash.
+func valueLines(name string, value interface{}, indent string) []string {
+	label := fmt.Sprintf("%s[%s:](fg:cyan)", indent, name)
+	if table, ok := headerTable(value); ok {
+		return append([]string{label}, tableLines(table, indent+"  ")...)
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return []string{label + " " + headerScalar(value)}
+	}
+	nested := false
+	for _, v := range list {
+		if _, ok := headerTable(v); ok {
+			nested = true
+		}
+	}
+	if !nested {
+		items := make([]string, len(list))
+		for i, v := range list {
+			items[i] = headerScalar(v)
+		}
+		return []string{label + " " + strings.Join(items, ", ")}
+	}
+	lines := []string{label}
+	for _, v := range list {
+		table, ok := headerTable(v)
+		if !ok {
+			lines = append(lines, indent+"  - "+headerScalar(v))
+			continue
+		}
+		entry := tableLines(table, indent+"    ")
+		if len(entry) > 0 {
+			entry[0] = indent + "  - " + strings.TrimPrefix(entry[0], indent+"    ")
+		}
+		lines = append(lines, entry...)
+	}
+	return lines
+}
+
+// headerTable reads a nested table, as the management API sends it, a
+// JSON object, or as AMQP does.
+func headerTable(v interface{}) (map[string]interface{}, bool) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return t, true
+	case amqp.Table:
+		return t, true
+	}
+	return nil, false
+}
+
+func headerScalar(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case time.Time:
+		return s.Format("2006-01-02 15:04:05")
+	case []byte:
+		if isText(s) {
+			return string(s)
+		}
+		return fmt.Sprintf("% x", s)
+	case nil:
+		return "null"
+	}
+	return fmt.Sprint(v)
+}
+
+// payloadPanel draws lines of cells as they are, wrapping long ones, so
+// that brackets in a payload are never taken for termui style markup.
+type payloadPanel struct {
+	*termui.Block
+	lines  [][]termui.Cell
+	scroll int
+}
+
+func (p *payloadPanel) Draw(buf *termui.Buffer) {
+	p.Block.Draw(buf)
+	width := p.Inner.Dx()
+	if width <= 0 {
+		return
+	}
+	var rows [][]termui.Cell
+	for _, line := range p.lines {
+		for len(line) > width {
+			rows = append(rows, line[:width])
+			line = line[width:]
+		}
+		rows = append(rows, line)
+	}
+	p.scroll = max(min(p.scroll, len(rows)-p.Inner.Dy()), 0)
+	for y, row := range rows[p.scroll:] {
+		if y >= p.Inner.Dy() {
+			break
+		}
+		for x, cell := range row {
+			buf.SetCell(cell, image.Pt(p.Inner.Min.X+x, p.Inner.Min.Y+y))
+		}
+	}
+}
+
+// textCells splits text into lines of cells in one style, with tabs as
+// spaces and carriage returns dropped.
+func textCells(text string, style termui.Style) [][]termui.Cell {
+	var lines [][]termui.Cell
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.ReplaceAll(strings.TrimSuffix(line, "\r"), "\t", "    ")
+		lines = append(lines, termui.RunesToStyledCells([]rune(line), style))
+	}
+	return lines
+}
+
+// jsonCells colours indented JSON: keys cyan, strings green, numbers
+// yellow and true, false and null magenta. It copes with JSON cut off
+// anywhere, as a preview is.
+func jsonCells(text []byte) [][]termui.Cell {
+	var (
+		punctuation = termui.NewStyle(termui.ColorWhite)
+		key         = termui.NewStyle(termui.ColorCyan)
+		str         = termui.NewStyle(termui.ColorGreen)
+		number      = termui.NewStyle(termui.ColorYellow)
+		literal     = termui.NewStyle(termui.ColorMagenta)
+	)
+	var lines [][]termui.Cell
+	for _, line := range strings.Split(string(text), "\n") {
+		runes := []rune(line)
+		var cells []termui.Cell
+		for i := 0; i < len(runes); {
+			r := runes[i]
+			switch {
+			case r == '"':
+				end := i + 1
+				for end < len(runes) && runes[end] != '"' {
+					if runes[end] == '\\' {
+						end++
+					}
+					end++
+				}
+				end = min(end+1, len(runes))
+				style := str
+				if rest := strings.TrimSpace(string(runes[end:])); strings.HasPrefix(rest, ":") {
+					style = key
+				}
+				cells = append(cells, termui.RunesToStyledCells(runes[i:end], style)...)
+				i = end
+			case r == '-' || unicode.IsDigit(r) || unicode.IsLetter(r):
+				end := i
+				for end < len(runes) && (strings.ContainsRune("+-.eE", runes[end]) || unicode.IsDigit(runes[end]) || unicode.IsLetter(runes[end])) {
+					end++
+				}
+				style := number
+				if unicode.IsLetter(r) {
+					style = literal
+				}
+				cells = append(cells, termui.RunesToStyledCells(runes[i:end], style)...)
+				i = end
+			default:
+				cells = append(cells, termui.Cell{Rune: r, Style: punctuation})
+				i++
+			}
+		}
+		lines = append(lines, cells)
+	}
+	return lines
+}
+
+// hexdumpCells lays out data as hexdump -C does: the offset, sixteen bytes
+// in hex in two groups of eight, and the printable ones as text.
+func hexdumpCells(data []byte) [][]termui.Cell {
+	offset := termui.NewStyle(termui.ColorYellow)
+	hex := termui.NewStyle(termui.ColorWhite)
+	text := termui.NewStyle(termui.ColorCyan)
+	var lines [][]termui.Cell
+	for start := 0; start < len(data); start += hexdumpWidth {
+		chunk := data[start:min(start+hexdumpWidth, len(data))]
+		var b strings.Builder
+		for i := 0; i < hexdumpWidth; i++ {
+			if i == hexdumpWidth/2 {
+				b.WriteByte(' ')
+			}
+			if i < len(chunk) {
+				fmt.Fprintf(&b, "%02x ", chunk[i])
+			} else {
+				b.WriteString("   ")
+			}
+		}
+		printable := make([]rune, len(chunk))
+		for i, c := range chunk {
+			printable[i] = '.'
+			if c >= 0x20 && c < 0x7f {
+				printable[i] = rune(c)
+			}
+		}
+		line := termui.RunesToStyledCells([]rune(fmt.Sprintf("%08x  ", start)), offset)
+		line = append(line, termui.RunesToStyledCells([]rune(b.String()+" "), hex)...)
+		line = append(line, termui.RunesToStyledCells([]rune("|"+string(printable)+"|"), text)...)
+		lines = append(lines, line)
+	}
+	if len(data) == 0 {
+		lines = append(lines, termui.RunesToStyledCells([]rune("(empty)"), hex))
+	}
+	return lines
+}
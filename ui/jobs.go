@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// job is a long action running next to the UI, such as republishing a
+// dead-letter queue message by message. It only reports back through its
+// counters, under mu, which the status line shows as a progress bar, and
+// signals redraw whenever they change.
+type job struct {
+	title  string
+	cancel context.CancelFunc
+	redraw chan<- struct{}
+	// verify, when set, is checked against the polls after the job
+	// succeeded.
+	verify *verification
+
+	mu       sync.Mutex
+	done     int
+	total    int
+	failed   int
+	skipped  int
+	paused   bool
+	err      error
+	finished time.Time
+}
+
+// startJob runs work in the background as a job of d. work counts its
+// progress with j.update and returns when it is finished, cancelled or
+// failed.
+func (d *dashboard) startJob(title string, total int, work func(ctx context.Context, j *job) error) *job {
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{title: title, total: total, cancel: cancel, redraw: d.redraw}
+	d.jobs = append(d.jobs, j)
+	go func() {
+		err := work(ctx, j)
+		if ctx.Err() != nil && err == nil {
+			err = fmt.Errorf("cancelled")
+		}
+		j.update(func() { j.err, j.finished = err, time.Now() })
+		cancel()
+	}()
+	return j
+}
+
+// update changes the job's counters and has the UI redraw.
+func (j *job) update(change func()) {
+	j.mu.Lock()
+	change()
+	j.mu.Unlock()
+	if j.redraw == nil {
+		return
+	}
+	select {
+	case j.redraw <- struct{}{}:
+	default:
+	}
+}
+
+// cancelJobs stops every job still running.
+func (d *dashboard) cancelJobs() {
+	running := 0
+	for _, j := range d.jobs {
+		j.mu.Lock()
+		if j.finished.IsZero() {
+			j.cancel()
+			running++
+		}
+		j.mu.Unlock()
+	}
+	if running == 0 {
+		d.setNotice("No job is running")
+	}
+}
+
+// pruneJobs starts the verification of jobs that succeeded and forgets
+// jobs that finished longer than noticeDuration ago.
+func (d *dashboard) pruneJobs() {
+	kept := d.jobs[:0]
+	for _, j := range d.jobs {
+		j.mu.Lock()
+		old := !j.finished.IsZero() && time.Since(j.finished) > noticeDuration
+		if !j.finished.IsZero() && j.verify != nil {
+			if j.err == nil {
+				v := *j.verify
+				v.until = time.Now().Add(verifyTimeout)
+				d.verifications = append(d.verifications, v)
+			}
+			j.verify = nil
+		}
+		j.mu.Unlock()
+		if !old {
+			kept = append(kept, j)
+		}
+	}
+	d.jobs = kept
+}
+
+// summary is the job's progress bar, e.g. "Requeue //orders.dlq ▕████░░░░▏ 40/80".
+func (j *job) summary() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	text := j.title + " " + progressBar(j.done, j.total, 10) + fmt.Sprintf(" %d/%d", j.done, j.total)
+	if j.failed > 0 {
+		text += fmt.Sprintf(", %d failed", j.failed)
+	}
+	if j.skipped > 0 {
+		text += fmt.Sprintf(", %d skipped", j.skipped)
+	}
+	switch {
+	case j.finished.IsZero() && j.paused:
+		return "[" + text + ", paused](fg:yellow)"
+	case j.finished.IsZero():
+		return "[" + text + "](fg:cyan)"
+	case j.err != nil:
+		return "[" + text + ": " + j.err.Error() + "](fg:red)"
+	case j.failed > 0:
+		return "[" + text + ", done](fg:yellow)"
+	}
+	return "[" + text + ", done](fg:green)"
+}
+
+func progressBar(done, total, width int) string {
+	filled := width
+	if total > 0 && done < total {
+		filled = done * width / total
+	}
+	return "▕" + strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + "▏"
+}
+
+// jobSummary lists the progress of d's current and recently finished jobs
+// for the status line.
+func jobSummary(d *dashboard) string {
+	parts := make([]string, len(d.jobs))
+	for i, j := range d.jobs {
+		parts[i] = j.summary()
+	}
+	return strings.Join(parts, "  ")
+}
@@ -0,0 +1,225 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// listRow is one row of a listView. Broken rows are drawn in red, and detail
+// is shown below the table while the row is selected. key identifies the
+// object for views that act on the selected row.
+type listRow struct {
+	cells  []string
+	broken bool
+	detail string
+	key    string
+}
+
+// listView is a page that shows one kind of object as a table with a
+// selectable row and a detail pane. rows returns the rows to show, or a
+// message to print instead when there are none.
+type listView struct {
+	title  string
+	header []string
+	widths func(width int) []int
+	rows   func(d *dashboard) ([]listRow, string)
+
+	summary *widgets.Paragraph
+	table   *widgets.Table
+	detail  *widgets.Paragraph
+	status  *statusBar
+
+	// prompt, when set, replaces the cluster summary, e.g. while a value
+	// is being typed.
+	prompt string
+
+	// selected is the key of the highlighted row; cursor and offset are
+	// resolved from it against each refresh's rows, so the selection stays
+	// on the same object while rows move.
+	selected string
+	cursor   int
+	offset   int
+}
+
+func newListView(title string, header []string, widths func(int) []int, rows func(*dashboard) ([]listRow, string)) *listView {
+	summary := widgets.NewParagraph()
+	summary.BorderStyle = termui.NewStyle(termui.ColorCyan)
+
+	table := widgets.NewTable()
+	table.TextStyle = termui.NewStyle(termui.ColorWhite)
+	table.TextAlignment = termui.AlignLeft
+	table.BorderStyle = termui.NewStyle(termui.ColorCyan)
+	table.RowSeparator = false
+	table.FillRow = true
+
+	detail := widgets.NewParagraph()
+	detail.BorderStyle = termui.NewStyle(termui.ColorCyan)
+
+	return &listView{
+		title:   title,
+		header:  header,
+		widths:  widths,
+		rows:    rows,
+		summary: summary,
+		table:   table,
+		detail:  detail,
+		status:  newStatusBar(),
+	}
+}
+
+func (v *listView) Render(d *dashboard, ui uiState) {
+	width, height := termui.TerminalDimensions()
+	rows, empty := v.rows(d)
+
+	v.summary.Title = " " + d.name + " · " + v.title + " "
+	v.summary.Text = clusterSummary(d.queues, d.overview)
+	if v.prompt != "" {
+		v.summary.Text = v.prompt
+	}
+
+	detailHeight := 7
+	tableBottom := height - statusBarHeight - detailHeight
+	pageRows := tableBottom - 3 - 3
+	if pageRows < 1 {
+		pageRows = 1
+	}
+	v.follow(rows)
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+pageRows {
+		v.offset = v.cursor - pageRows + 1
+	}
+	if v.offset > len(rows)-pageRows {
+		v.offset = len(rows) - pageRows
+	}
+	if v.offset < 0 {
+		v.offset = 0
+	}
+	end := v.offset + pageRows
+	if end > len(rows) {
+		end = len(rows)
+	}
+
+	v.table.ColumnWidths = v.widths(width)
+	header := make([]string, len(v.header))
+	for i, h := range v.header {
+		header[i] = fmt.Sprintf("[%s](fg:black,bg:yellow)", h)
+	}
+	tableRows := [][]string{header}
+	v.table.RowStyles = make(map[int]termui.Style)
+	for i, row := range rows[v.offset:end] {
+		switch {
+		case v.offset+i == v.cursor:
+			v.table.RowStyles[i+1] = termui.NewStyle(termui.ColorWhite, termui.ColorBlue, termui.ModifierBold)
+		case row.broken:
+			v.table.RowStyles[i+1] = termui.NewStyle(termui.ColorRed, termui.ColorClear, termui.ModifierBold)
+		}
+		tableRows = append(tableRows, row.cells)
+	}
+	if len(rows) == 0 {
+		blank := make([]string, len(v.header))
+		blank[0] = empty
+		tableRows = append(tableRows, blank)
+	}
+	v.table.Rows = tableRows
+	v.table.Title = ""
+	if len(rows) > pageRows {
+		v.table.Title = fmt.Sprintf(" %d-%d of %d ", v.offset+1, end, len(rows))
+	}
+
+	v.detail.Title = " Details "
+	v.detail.Text = ""
+	if len(rows) > 0 {
+		v.detail.Text = rows[v.cursor].detail
+	}
+
+	termui.Clear()
+	v.summary.SetRect(0, 0, width, 3)
+	v.table.SetRect(0, 3, width, tableBottom)
+	v.detail.SetRect(0, tableBottom, width, height-statusBarHeight)
+	drawables := []termui.Drawable{v.summary, v.table, v.detail}
+	drawWidgets(append(drawables, v.status.Layout(d, ui, width, height)...)...)
+}
+
+// follow moves the cursor to the selected row in the current rows, and the
+// scroll offset with it so the row stays on the same line of the screen. When
+// the row is gone, or rows have no keys, the cursor keeps its index.
+func (v *listView) follow(rows []listRow) {
+	for i, row := range rows {
+		if row.key != "" && row.key == v.selected {
+			v.offset += i - v.cursor
+			v.cursor = i
+			return
+		}
+	}
+	if v.cursor >= len(rows) {
+		v.cursor = len(rows) - 1
+	}
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+	v.selected = ""
+	if len(rows) > 0 {
+		v.selected = rows[v.cursor].key
+	}
+}
+
+// selectedRow returns the selected row.
+func (v *listView) selectedRow(d *dashboard) (listRow, bool) {
+	rows, _ := v.rows(d)
+	v.follow(rows)
+	if v.cursor < 0 || v.cursor >= len(rows) {
+		return listRow{}, false
+	}
+	return rows[v.cursor], true
+}
+
+// moveCursor shifts the selection by delta rows; the key under it is
+// resolved on the next render.
+func (v *listView) moveCursor(delta int) {
+	v.cursor += delta
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+	v.selected = ""
+}
+
+func (v *listView) HandleKey(d *dashboard, id string) bool {
+	switch id {
+	case "j", "<Down>":
+		v.moveCursor(1)
+		return true
+	case "k", "<Up>":
+		v.moveCursor(-1)
+		return true
+	}
+	return false
+}
+
+// spreadWidths gives fixed columns their width and shares what is left of
+// an outer width between the flexible ones, marked with 0.
+func spreadWidths(width int, fixed ...int) []int {
+	inner := width - 2 - len(fixed)
+	flexible := 0
+	for _, w := range fixed {
+		inner -= w
+		if w == 0 {
+			flexible++
+		}
+	}
+	widths := append([]int(nil), fixed...)
+	last := -1
+	for i, w := range widths {
+		if w == 0 {
+			widths[i] = inner / flexible
+			last = i
+		}
+	}
+	if last >= 0 {
+		widths[last] += inner % flexible
+	}
+	return widths
+}
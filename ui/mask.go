@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const maskedValue = "****"
+
+// maskStep is one segment of a JSON path: a field name, an array index, a
+// wildcard over array elements or object fields, or a recursive descent.
+type maskStep struct {
+	field     string
+	index     int
+	wildcard  bool
+	recursive bool
+}
+
+type maskPath []maskStep
+
+// parseMaskPath understands the subset of JSONPath that is useful for
+// masking: $.a.b, $.a[0].b, $.a[*].b, $.a.* and $..b.
+func parseMaskPath(path string) (maskPath, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("json path %q must start with $", path)
+	}
+	rest := path[1:]
+	var steps maskPath
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			name, tail := splitPathField(rest[2:])
+			if name == "" {
+				return nil, fmt.Errorf("json path %q: missing field after ..", path)
+			}
+			steps = append(steps, maskStep{field: name, recursive: true})
+			rest = tail
+		case strings.HasPrefix(rest, "."):
+			name, tail := splitPathField(rest[1:])
+			if name == "" {
+				return nil, fmt.Errorf("json path %q: missing field after .", path)
+			}
+			steps = append(steps, maskStep{field: name, wildcard: name == "*"})
+			rest = tail
+		case strings.HasPrefix(rest, "["):
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("json path %q: unterminated [", path)
+			}
+			inner := rest[1:end]
+			if inner == "*" {
+				steps = append(steps, maskStep{wildcard: true})
+			} else if n, err := strconv.Atoi(inner); err == nil && n >= 0 {
+				steps = append(steps, maskStep{index: n, field: ""})
+			} else {
+				steps = append(steps, maskStep{field: strings.Trim(inner, `'"`)})
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("json path %q: unexpected %q", path, rest)
+		}
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("json path %q selects the whole document", path)
+	}
+	return steps, nil
+}
+
+func splitPathField(s string) (string, string) {
+	end := strings.IndexAny(s, ".[")
+	if end < 0 {
+		return s, ""
+	}
+	return s[:end], s[end:]
+}
+
+// payloadMasker replaces the values at configured JSON paths before a
+// payload is displayed or written out. Payloads that are not JSON are passed
+// through unchanged.
+type payloadMasker struct {
+	paths []maskPath
+}
+
+func newPayloadMasker(paths []string) (*payloadMasker, error) {
+	m := &payloadMasker{}
+	for _, p := range paths {
+		parsed, err := parseMaskPath(p)
+		if err != nil {
+			return nil, err
+		}
+		m.paths = append(m.paths, parsed)
+	}
+	return m, nil
+}
+
+func (m *payloadMasker) Mask(payload []byte) []byte {
+	if m == nil || len(m.paths) == 0 || !json.Valid(payload) {
+		return payload
+	}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return payload
+	}
+
+	for _, path := range m.paths {
+		doc = maskAt(doc, path)
+	}
+
+	masked, err := json.Marshal(doc)
+	if err != nil {
+		return payload
+	}
+	return masked
+}
+
+func maskAt(node interface{}, path maskPath) interface{} {
+	if len(path) == 0 {
+		return maskedValue
+	}
+	step, rest := path[0], path[1:]
+
+	if step.recursive {
+		return maskRecursive(node, step.field, rest)
+	}
+
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if step.wildcard {
+			for key, child := range v {
+				v[key] = maskAt(child, rest)
+			}
+		} else if child, ok := v[step.field]; ok && step.field != "" {
+			v[step.field] = maskAt(child, rest)
+		}
+	case []interface{}:
+		if step.wildcard {
+			for i, child := range v {
+				v[i] = maskAt(child, rest)
+			}
+		} else if step.field == "" && step.index < len(v) {
+			v[step.index] = maskAt(v[step.index], rest)
+		}
+	}
+	return node
+}
+
+// maskRecursive applies rest to every field named field at any depth.
+func maskRecursive(node interface{}, field string, rest maskPath) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if key == field {
+				v[key] = maskAt(child, rest)
+			} else {
+				v[key] = maskRecursive(child, field, rest)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = maskRecursive(child, field, rest)
+		}
+	}
+	return node
+}
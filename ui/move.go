@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// moveCheckInterval is how often a move's shovel and source queue are
+// checked for progress.
+const moveCheckInterval = time.Second
+
+// shovelParameter is the body of PUT /api/parameters/shovel/{vhost}/{name}
+// for a dynamic shovel from one queue of the broker to another.
+type shovelParameter struct {
+	Value shovelDefinition `json:"value"`
+}
+
+// shovelDefinition is a dynamic shovel's value. SrcDeleteAfter is a
+// message count or "queue-length"; left out, the shovel runs until it is
+// deleted.
+type shovelDefinition struct {
+	SrcProtocol     string      `json:"src-protocol"`
+	SrcURI          string      `json:"src-uri"`
+	SrcQueue        string      `json:"src-queue"`
+	SrcDeleteAfter  interface{} `json:"src-delete-after,omitempty"`
+	DestProtocol    string      `json:"dest-protocol"`
+	DestURI         string      `json:"dest-uri"`
+	DestQueue       string      `json:"dest-queue,omitempty"`
+	DestExchange    string      `json:"dest-exchange,omitempty"`
+	DestExchangeKey string      `json:"dest-exchange-key,omitempty"`
+	AckMode         string      `json:"ack-mode"`
+}
+
+func shovelPath(vhost, name string) string {
+	return "/api/parameters/shovel/" + url.PathEscape(vhost) + "/" + url.PathEscape(name)
+}
+
+// localURI is the shovel URI of a vhost on the broker running the shovel.
+func localURI(vhost string) string {
+	return "amqp:///" + url.PathEscape(vhost)
+}
+
+// moveMessages moves n ready messages from one queue to another of the same
+// vhost with a temporary dynamic shovel that deletes itself after n
+// messages, acknowledging each only once the destination confirmed it, so
+// nothing is lost if the move is interrupted. Progress is read from the
+// source queue's depth. Cancelling deletes the shovel, leaving the messages
+// not yet moved where they were.
+func moveMessages(ctx context.Context, config Config, from QueueInfo, to string, n int, j *job) error {
+	local := localURI(from.VHost)
+	name := fmt.Sprintf("rabbitspy-move-%d", time.Now().UnixNano())
+	shovel := shovelParameter{Value: shovelDefinition{
+		SrcProtocol: "amqp091", SrcURI: local, SrcQueue: from.Name, SrcDeleteAfter: n,
+		DestProtocol: "amqp091", DestURI: local, DestQueue: to,
+		AckMode: "on-confirm",
+	}}
+	if err := apiRequest(config, "PUT", shovelPath(from.VHost, name), shovel, nil); err != nil {
+		if errors.Is(err, errNotFound) {
+			return fmt.Errorf("moving needs the shovel plugin: %w", err)
+		}
+		return err
+	}
+	remove := func() error { return apiRequest(config, "DELETE", shovelPath(from.VHost, name), nil, nil) }
+
+	ticker := time.NewTicker(moveCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return remove()
+		case <-ticker.C:
+		}
+		var q QueueInfo
+		if err := getJSON(config, queuePath(from), &q); err == nil {
+			moved := from.MessagesReady - q.MessagesReady
+			moved = max(0, min(n, moved))
+			j.update(func() { j.done = moved })
+		}
+		var shovels []ShovelInfo
+		err := getJSON(config, "/api/shovels/"+url.PathEscape(from.VHost), &shovels)
+		if err != nil {
+			continue
+		}
+		running := false
+		for _, s := range shovels {
+			if s.Name != name {
+				continue
+			}
+			running = true
+			if s.State == "terminated" {
+				remove()
+				return fmt.Errorf("the shovel stopped: %s", s.Reason)
+			}
+		}
+		if running {
+			continue
+		}
+		// A shovel is only listed once it has started, so one that is not
+		// listed may still be starting; it has deleted itself after n
+		// messages only when its parameter is gone too.
+		if err := getJSON(config, shovelPath(from.VHost, name), nil); errors.Is(err, errNotFound) {
+			j.update(func() { j.done = n })
+			return nil
+		}
+	}
+}
+
+// moveForm asks where to move how many of from's ready messages.
+func (d *dashboard) moveForm(from QueueInfo) *form {
+	return d.confirmForm(newForm("Move messages from "+from.Key(), []*formField{
+		{label: "To queue"},
+		{label: "Messages", input: lineInput{value: strconv.Itoa(from.MessagesReady)}},
+	}, func(values map[string]string) error { return d.startMove(from, values) }), "move", func(values map[string]string) (string, string) {
+		return from.Name, fmt.Sprintf("Move %s messages from %s to %s?", values["Messages"], from.Key(), values["To queue"])
+	})
+}
+
+// startMove starts moving messages as a moveForm's values say, and checks
+// the polls after it for the counts to add up.
+func (d *dashboard) startMove(from QueueInfo, values map[string]string) error {
+	to, ok := findQueue(d.queues, from.VHost+"/"+values["To queue"])
+	switch {
+	case values["To queue"] == "":
+		return fmt.Errorf("a destination queue is needed")
+	case !ok:
+		return fmt.Errorf("there is no queue %s in vhost %s", values["To queue"], from.VHost)
+	case to.Key() == from.Key():
+		return fmt.Errorf("the destination is the source queue")
+	}
+	n, err := strconv.Atoi(values["Messages"])
+	if err != nil || n <= 0 {
+		return fmt.Errorf("messages must be a positive whole number")
+	}
+	if n > from.MessagesReady {
+		return fmt.Errorf("%s has only %s ready messages", from.Key(), groupDigits(from.MessagesReady))
+	}
+	config := d.config
+	j := d.startJob(fmt.Sprintf("Move %s → %s", from.Key(), to.Name), n, func(ctx context.Context, j *job) error {
+		return moveMessages(ctx, config, from, to.Name, n, j)
+	})
+	v := expectMoved(from, to, n)
+	j.verify = &v
+	return nil
+}
@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+)
+
+type usageSample struct {
+	at   time.Time
+	used float64
+}
+
+// usageTrend fits a least-squares line through recent samples of a
+// resource counter so exhaustion can be projected before it happens.
+type usageTrend struct {
+	samples []usageSample
+}
+
+func (t *usageTrend) add(at time.Time, used float64, window time.Duration) {
+	t.samples = append(t.samples, usageSample{at: at, used: used})
+	for len(t.samples) > 0 && at.Sub(t.samples[0].at) > window {
+		t.samples = t.samples[1:]
+	}
+}
+
+// slope returns the growth rate in units per second and whether enough
+// samples were collected to trust it.
+func (t *usageTrend) slope(minSpan time.Duration) (float64, bool) {
+	n := len(t.samples)
+	if n < 3 || t.samples[n-1].at.Sub(t.samples[0].at) < minSpan {
+		return 0, false
+	}
+	origin := t.samples[0].at
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range t.samples {
+		x := s.at.Sub(origin).Seconds()
+		sumX += x
+		sumY += s.used
+		sumXY += x * s.used
+		sumXX += x * x
+	}
+	denom := float64(n)*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0, false
+	}
+	return (float64(n)*sumXY - sumX*sumY) / denom, true
+}
+
+type nodeResource struct {
+	key   string
+	label string
+	used  func(NodeInfo) int
+	total func(NodeInfo) int
+}
+
+var trackedNodeResources = []nodeResource{
+	{"fd", "file descriptors", func(n NodeInfo) int { return n.FDUsed }, func(n NodeInfo) int { return n.FDTotal }},
+	{"sockets", "sockets", func(n NodeInfo) int { return n.SocketsUsed }, func(n NodeInfo) int { return n.SocketsTotal }},
+}
+
+// nodeTrendTracker raises alerts for node file descriptor and socket usage,
+// both when usage is already close to the limit and when the current growth
+// rate would exhaust it within the configured horizon.
+type nodeTrendTracker struct {
+	window  time.Duration
+	horizon time.Duration
+	trends  map[string]*usageTrend
+}
+
+func newNodeTrendTracker(window, horizon time.Duration) *nodeTrendTracker {
+	return &nodeTrendTracker{window: window, horizon: horizon, trends: make(map[string]*usageTrend)}
+}
+
+func (t *nodeTrendTracker) Update(nodes []NodeInfo) []Alert {
+	now := time.Now()
+	var alerts []Alert
+	for _, node := range nodes {
+		for _, res := range trackedNodeResources {
+			used, total := res.used(node), res.total(node)
+			if total <= 0 {
+				continue
+			}
+			key := node.Name + "/" + res.key
+			trend := t.trends[key]
+			if trend == nil {
+				trend = &usageTrend{}
+				t.trends[key] = trend
+			}
+			trend.add(now, float64(used), t.window)
+
+			if float64(used) >= 0.9*float64(total) {
+				alerts = append(alerts, Alert{
+					Key:      "node-" + key + "-high",
+					Severity: SeverityCritical,
+					Message:  fmt.Sprintf("Node %s is using %d/%d %s", node.Name, used, total, res.label),
+				})
+				continue
+			}
+
+			slope, ok := trend.slope(t.window / 4)
+			if !ok || slope <= 0 {
+				continue
+			}
+			eta := time.Duration(float64(total-used) / slope * float64(time.Second))
+			if eta <= t.horizon {
+				alerts = append(alerts, Alert{
+					Key:      "node-" + key + "-trend",
+					Severity: SeverityWarning,
+					Message: fmt.Sprintf("Node %s will run out of %s in ~%s at current rate (%d/%d)",
+						node.Name, res.label, eta.Round(time.Minute), used, total),
+				})
+			}
+		}
+	}
+	return alerts
+}
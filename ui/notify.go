@@ -0,0 +1,171 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// WebhookConfig posts every dispatched alert to URL. The body is rendered
+// from Template, or from the file TemplateFile names, with Go text/template
+// over a notification; when neither is set a JSON object with every field
+// is sent.
+type WebhookConfig struct {
+	Name         string            `json:"name"`
+	URL          string            `json:"url"`
+	Template     string            `json:"template"`
+	TemplateFile string            `json:"template_file"`
+	ContentType  string            `json:"content_type"`
+	Headers      map[string]string `json:"headers"`
+}
+
+const defaultNotificationTemplate = `{"cluster":{{json .Cluster}},"severity":{{json .Severity}},"key":{{json .Key}},` +
+	`"message":{{json .Message}},"queue":{{json .Queue}},"vhost":{{json .VHost}},"value":{{.Value}},` +
+	`"threshold":{{.Threshold}},"runbook":{{json .Runbook}},"management_url":{{json .ManagementURL}},` +
+	`"grafana_url":{{json .GrafanaURL}},"downstream":{{json .Downstream}},"since":{{json .Since}}}`
+
+const webhookTimeout = 5 * time.Second
+
+// notification is what notifier templates are rendered with: the alert and
+// where to look at it.
+type notification struct {
+	Alert
+	Cluster       string
+	ManagementURL string
+	GrafanaURL    string
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": func(v interface{}) string { return strings.ToUpper(fmt.Sprint(v)) },
+	"lower": func(v interface{}) string { return strings.ToLower(fmt.Sprint(v)) },
+}
+
+// notificationTemplate parses a webhook's template.
+func notificationTemplate(w WebhookConfig) (*template.Template, error) {
+	text := w.Template
+	if w.TemplateFile != "" {
+		data, err := os.ReadFile(w.TemplateFile)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	if text == "" {
+		text = defaultNotificationTemplate
+	}
+	return template.New(w.Name).Funcs(templateFuncs).Parse(text)
+}
+
+func validateWebhooks(config *Config) error {
+	for i := range config.Notifiers {
+		w := &config.Notifiers[i]
+		if w.Name == "" {
+			w.Name = fmt.Sprintf("notifier %d", i+1)
+		}
+		if w.URL == "" {
+			return fmt.Errorf("notifiers: %s has no url", w.Name)
+		}
+		if w.Template != "" && w.TemplateFile != "" {
+			return fmt.Errorf("notifiers: %s sets both template and template_file", w.Name)
+		}
+		if _, err := notificationTemplate(*w); err != nil {
+			return fmt.Errorf("notifiers: %s: %w", w.Name, err)
+		}
+		if w.ContentType == "" {
+			w.ContentType = "application/json"
+		}
+	}
+	if _, err := template.New("grafana").Funcs(templateFuncs).Parse(config.Links.Grafana); err != nil {
+		return fmt.Errorf("links.grafana: %w", err)
+	}
+	return nil
+}
+
+// webhookNotifier sends one cluster's alerts to one webhook.
+type webhookNotifier struct {
+	config  WebhookConfig
+	body    *template.Template
+	grafana *template.Template
+	cluster string
+	// management is the management UI's base URL.
+	management string
+	client     *http.Client
+}
+
+// newWebhookNotifiers builds the configured webhooks for a cluster. config
+// should come from LoadConfig, which has validated the templates.
+func newWebhookNotifiers(config Config, cluster ClusterConfig) ([]Notifier, error) {
+	grafana, err := template.New("grafana").Funcs(templateFuncs).Parse(config.Links.Grafana)
+	if err != nil {
+		return nil, fmt.Errorf("links.grafana: %w", err)
+	}
+	var notifiers []Notifier
+	for _, w := range config.Notifiers {
+		body, err := notificationTemplate(w)
+		if err != nil {
+			return nil, fmt.Errorf("notifiers: %s: %w", w.Name, err)
+		}
+		notifiers = append(notifiers, &webhookNotifier{
+			config:     w,
+			body:       body,
+			grafana:    grafana,
+			cluster:    cluster.Name,
+			management: fmt.Sprintf("http://%s:%s/", cluster.Host, cluster.ManagementPort),
+			client:     &http.Client{Timeout: webhookTimeout},
+		})
+	}
+	return notifiers, nil
+}
+
+// notification fills in the links for alert. The management link opens the
+// queue's page when the alert is about a queue.
+func (n *webhookNotifier) notification(alert Alert) (notification, error) {
+	data := notification{Alert: alert, Cluster: n.cluster, ManagementURL: n.management}
+	if alert.Queue != "" {
+		data.ManagementURL += "#/queues/" + url.PathEscape(alert.VHost) + "/" + url.PathEscape(alert.Queue)
+	}
+	var grafana strings.Builder
+	if err := n.grafana.Execute(&grafana, data); err != nil {
+		return data, fmt.Errorf("links.grafana: %w", err)
+	}
+	data.GrafanaURL = grafana.String()
+	return data, nil
+}
+
+func (n *webhookNotifier) Notify(alert Alert) error {
+	data, err := n.notification(alert)
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	if err := n.body.Execute(&body, data); err != nil {
+		return fmt.Errorf("%s: %w", n.config.Name, err)
+	}
+	req, err := http.NewRequest(http.MethodPost, n.config.URL, &body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", n.config.Name, err)
+	}
+	req.Header.Set("Content-Type", n.config.ContentType)
+	for k, v := range n.config.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", n.config.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: %s", n.config.Name, resp.Status)
+	}
+	return nil
+}
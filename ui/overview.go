@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// overviewView is the cluster-level page: node resource gauges for memory
+// against the high watermark, disk against the free-space limit, and file
+// descriptors, above versions, listeners and feature flags.
+type overviewView struct {
+	summary   *widgets.Paragraph
+	versions  *widgets.Paragraph
+	listeners *widgets.Paragraph
+	features  *widgets.Paragraph
+	status    *statusBar
+}
+
+const overviewInfoHeight = 9
+
+func newOverviewView() *overviewView {
+	panel := func(title string) *widgets.Paragraph {
+		p := widgets.NewParagraph()
+		p.Title = title
+		p.BorderStyle = termui.NewStyle(termui.ColorCyan)
+		return p
+	}
+	return &overviewView{
+		summary:   panel(""),
+		versions:  panel(" Versions "),
+		listeners: panel(" Listeners "),
+		features:  panel(" Feature flags "),
+		status:    newStatusBar(),
+	}
+}
+
+// nodeVersion is the RabbitMQ version a node reports for its rabbit
+// application.
+func nodeVersion(n NodeInfo) string {
+	for _, app := range n.Applications {
+		if app.Name == "rabbit" {
+			return app.Version
+		}
+	}
+	return "?"
+}
+
+func formatUptime(ms int64) string {
+	d := time.Duration(ms) * time.Millisecond
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return d.Round(time.Second).String()
+	}
+}
+
+func versionsText(d *dashboard) string {
+	var b strings.Builder
+	if o := d.overview; o != nil {
+		fmt.Fprintf(&b, "Cluster %s\nRabbitMQ %s · Erlang %s\n", o.ClusterName, o.RabbitMQVersion, o.ErlangVersion)
+	}
+	versions := make(map[string]bool)
+	for _, n := range d.nodes {
+		versions[nodeVersion(n)] = true
+	}
+	for _, n := range d.nodes {
+		version := nodeVersion(n)
+		if len(versions) > 1 {
+			// Mixed versions are expected mid-upgrade but worth seeing.
+			version = "[" + version + "](fg:yellow)"
+		}
+		state := "[down](fg:red)"
+		if n.Running {
+			state = "up " + formatUptime(n.Uptime)
+		}
+		fmt.Fprintf(&b, "%s  %s  %s\n", n.Name, version, state)
+	}
+	return b.String()
+}
+
+func listenersText(d *dashboard) string {
+	if d.overview == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, l := range d.overview.Listeners {
+		fmt.Fprintf(&b, "%-12s %5d  %s on %s\n", l.Protocol, l.Port, l.IPAddress, l.Node)
+	}
+	return b.String()
+}
+
+// featuresText lists disabled flags first since they block upgrades.
+func featuresText(d *dashboard) string {
+	if len(d.features) == 0 {
+		return "Not reported by this broker."
+	}
+	var enabled, disabled []string
+	for _, f := range d.features {
+		if f.State == "enabled" {
+			enabled = append(enabled, f.Name)
+		} else {
+			disabled = append(disabled, f.Name+" ("+f.State+")")
+		}
+	}
+	text := fmt.Sprintf("%d enabled, %d not enabled\n", len(enabled), len(disabled))
+	if len(disabled) > 0 {
+		text += "[" + strings.Join(disabled, ", ") + "](fg:yellow)\n"
+	}
+	return text + strings.Join(enabled, ", ")
+}
+
+func gaugeColor(percent int) termui.Color {
+	switch {
+	case percent >= 90:
+		return termui.ColorRed
+	case percent >= 70:
+		return termui.ColorYellow
+	default:
+		return termui.ColorGreen
+	}
+}
+
+func percentOf(used, total int64) int {
+	if total <= 0 {
+		return 0
+	}
+	p := int(used * 100 / total)
+	if p > 100 {
+		return 100
+	}
+	return p
+}
+
+func newResourceGauge(title string, percent int, label string) *widgets.Gauge {
+	g := widgets.NewGauge()
+	g.Title = title
+	g.Percent = percent
+	g.Label = label
+	g.BarColor = gaugeColor(percent)
+	g.BorderStyle = termui.NewStyle(termui.ColorCyan)
+	g.LabelStyle = termui.NewStyle(termui.ColorWhite)
+	return g
+}
+
+// nodeGauges builds the memory, disk and fd gauges for one node. Disk is
+// shown as how close free space is to the configured limit, so a full bar
+// means the disk alarm is about to fire.
+func nodeGauges(node NodeInfo) []*widgets.Gauge {
+	diskPercent := 0
+	if node.DiskFree > 0 {
+		diskPercent = percentOf(node.DiskFreeLimit, node.DiskFree)
+	} else if node.DiskFreeLimit > 0 {
+		diskPercent = 100
+	}
+	return []*widgets.Gauge{
+		newResourceGauge(fmt.Sprintf(" %s memory ", node.Name), percentOf(node.MemUsed, node.MemLimit),
+			fmt.Sprintf("%s / %s watermark", formatBytes(node.MemUsed), formatBytes(node.MemLimit))),
+		newResourceGauge(" disk ", diskPercent,
+			fmt.Sprintf("%s free, limit %s", formatBytes(node.DiskFree), formatBytes(node.DiskFreeLimit))),
+		newResourceGauge(" file descriptors ", percentOf(int64(node.FDUsed), int64(node.FDTotal)),
+			fmt.Sprintf("%d / %d", node.FDUsed, node.FDTotal)),
+	}
+}
+
+func (v *overviewView) Render(d *dashboard, ui uiState) {
+	width, height := termui.TerminalDimensions()
+	termui.Clear()
+
+	v.summary.Title = " " + d.name + " · Overview "
+	v.summary.Text = clusterSummary(d.queues, d.overview)
+	v.summary.SetRect(0, 0, width, 3)
+	drawables := []termui.Drawable{v.summary}
+
+	y := 3
+	third := width / 3
+	infoTop := height - statusBarHeight - overviewInfoHeight
+	for _, node := range d.nodes {
+		if y+3 > infoTop {
+			break
+		}
+		for i, g := range nodeGauges(node) {
+			right := (i + 1) * third
+			if i == 2 {
+				right = width
+			}
+			g.SetRect(i*third, y, right, y+3)
+			drawables = append(drawables, g)
+		}
+		y += 3
+	}
+	if len(d.nodes) == 0 {
+		empty := widgets.NewParagraph()
+		empty.Text = "No node information available."
+		empty.SetRect(0, y, width, y+3)
+		drawables = append(drawables, empty)
+	}
+
+	v.versions.Text = versionsText(d)
+	v.listeners.Text = listenersText(d)
+	v.features.Text = featuresText(d)
+	v.versions.SetRect(0, infoTop, third, height-statusBarHeight)
+	v.listeners.SetRect(third, infoTop, 2*third, height-statusBarHeight)
+	v.features.SetRect(2*third, infoTop, width, height-statusBarHeight)
+	drawables = append(drawables, v.versions, v.listeners, v.features)
+
+	drawables = append(drawables, v.status.Layout(d, ui, width, height)...)
+	drawWidgets(drawables...)
+}
+
+func (v *overviewView) HandleKey(d *dashboard, id string) bool {
+	return false
+}
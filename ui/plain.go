@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var styleMarkup = regexp.MustCompile(`\[([^\]]*)\]\(([^)]*)\)`)
+
+var ansiColors = map[string]string{
+	"black": "30", "red": "31", "green": "32", "yellow": "33",
+	"blue": "34", "magenta": "35", "cyan": "36", "white": "37",
+}
+
+// ansiMarkup turns termui style markup such as "[12](fg:red)" into ANSI
+// escape codes, or strips it when color is false.
+func ansiMarkup(s string, color bool) string {
+	return styleMarkup.ReplaceAllStringFunc(s, func(m string) string {
+		parts := styleMarkup.FindStringSubmatch(m)
+		text, styles := parts[1], parts[2]
+		if !color {
+			return text
+		}
+		var codes []string
+		for _, item := range strings.Split(styles, ",") {
+			kv := strings.SplitN(strings.TrimSpace(item), ":", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			switch kv[0] {
+			case "fg":
+				if c, ok := ansiColors[kv[1]]; ok {
+					codes = append(codes, c)
+				}
+			case "bg":
+				if c, ok := ansiColors[kv[1]]; ok {
+					codes = append(codes, "4"+c[1:])
+				}
+			case "mod":
+				if kv[1] == "bold" {
+					codes = append(codes, "1")
+				}
+			}
+		}
+		if len(codes) == 0 {
+			return text
+		}
+		return "\x1b[" + strings.Join(codes, ";") + "m" + text + "\x1b[0m"
+	})
+}
+
+// runPlain is the termui-free renderer: it prints a fresh snapshot of every
+// cluster each interval, for serial consoles, minimal containers and CI logs.
+func runPlain(ctx context.Context, out io.Writer, dashboards []*dashboard, interval time.Duration) {
+	color := os.Getenv("NO_COLOR") == ""
+	for {
+		for _, d := range dashboards {
+			d.poll()
+			printPlain(out, d, color)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func printPlain(out io.Writer, d *dashboard, color bool) {
+	fmt.Fprintf(out, "=== %s %s ===\n", time.Now().Format("2006-01-02 15:04:05"), d.name)
+	if d.lastErr != nil {
+		fmt.Fprintf(out, "%s\n", ansiMarkup(fmt.Sprintf("[fetch failed: %s](fg:red)", d.lastErr), color))
+	}
+	fmt.Fprintln(out, ansiMarkup(clusterSummary(d.queues, d.overview), color))
+	if partitions := nodePartitions(d.nodes); len(partitions) > 0 {
+		fmt.Fprintln(out, ansiMarkup(fmt.Sprintf("[%s](fg:yellow,mod:bold)", partitionText(partitions)), color))
+	}
+	if alarms := nodeAlarms(d.nodes); len(alarms) > 0 {
+		fmt.Fprintln(out, ansiMarkup(fmt.Sprintf("[%s](fg:red,mod:bold)", alarmText(alarms)), color))
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "QUEUE\tTYPE\tSTATE\tREADY\tUNACKED\tTOTAL\tPUBLISH/s\tDELIVER/s\tMEM")
+	for _, q := range sortByTier(d.config.Tiers, sortQueues(d.config.queueSort(), d.queues)) {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%.1f\t%.1f\t%s\n",
+			q.Key(), q.Type, queueState(q), q.MessagesReady, q.MessagesUnack, q.Messages,
+			q.MessageStats.PublishDetails.Rate, q.MessageStats.DeliverGetDetails.Rate, formatBytes(q.Memory))
+	}
+	w.Flush()
+
+	for _, alert := range d.activeAlerts {
+		style := "fg:yellow"
+		if alert.Severity == SeverityCritical {
+			style = "fg:red,mod:bold"
+		}
+		fmt.Fprintln(out, ansiMarkup(fmt.Sprintf("[%s: %s](%s)", strings.ToUpper(string(alert.Severity)), alert.Message, style), color))
+	}
+	fmt.Fprintln(out)
+}
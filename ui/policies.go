@@ -0,0 +1,522 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// formatDefinition renders a policy definition as sorted key=value pairs.
+func formatDefinition(def map[string]interface{}) string {
+	keys := make([]string, 0, len(def))
+	for k := range def {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%v", k, def[k])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// policyQueues lists the queues the broker reports as governed by p.
+func policyQueues(p PolicyInfo, queues []QueueInfo) []string {
+	var names []string
+	for _, q := range queues {
+		if q.Policy == p.Name && q.VHost == p.VHost {
+			names = append(names, q.Name)
+		}
+	}
+	return names
+}
+
+// policiesView lists policies with their pattern, definition and priority,
+// and which queues each one is currently applied to. Policies that match no
+// queue are shown in yellow since they are often a typo in the pattern.
+type policiesView struct {
+	summary *widgets.Paragraph
+	table   *widgets.Table
+	detail  *widgets.Paragraph
+	status  *statusBar
+
+	// selected is the vhost/name of the highlighted policy, followed across
+	// refreshes like a listView's selection.
+	selected string
+	cursor   int
+	offset   int
+
+	// form is the policy editor and confirm a delete waiting for the
+	// policy's name, if open.
+	form    *form
+	confirm *typedConfirmation
+}
+
+func newPoliciesView() *policiesView {
+	summary := widgets.NewParagraph()
+	summary.BorderStyle = termui.NewStyle(termui.ColorCyan)
+
+	table := widgets.NewTable()
+	table.TextStyle = termui.NewStyle(termui.ColorWhite)
+	table.TextAlignment = termui.AlignLeft
+	table.BorderStyle = termui.NewStyle(termui.ColorCyan)
+	table.RowSeparator = false
+	table.FillRow = true
+
+	detail := widgets.NewParagraph()
+	detail.Title = " Policy "
+	detail.BorderStyle = termui.NewStyle(termui.ColorCyan)
+
+	return &policiesView{summary: summary, table: table, detail: detail, status: newStatusBar()}
+}
+
+func (v *policiesView) Render(d *dashboard, ui uiState) {
+	width, height := termui.TerminalDimensions()
+
+	policies := sortedPolicies(d.policies)
+
+	v.summary.Title = " " + d.name + " · Policies "
+	v.summary.Text = clusterSummary(d.queues, d.overview)
+	if v.confirm != nil {
+		v.summary.Text = v.confirm.Prompt()
+	}
+
+	detailHeight := 7
+	tableBottom := height - statusBarHeight - detailHeight
+	pageRows := tableBottom - 3 - 3
+	if pageRows < 1 {
+		pageRows = 1
+	}
+	v.follow(policies)
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+pageRows {
+		v.offset = v.cursor - pageRows + 1
+	}
+	if v.offset > len(policies)-pageRows {
+		v.offset = len(policies) - pageRows
+	}
+	if v.offset < 0 {
+		v.offset = 0
+	}
+	end := v.offset + pageRows
+	if end > len(policies) {
+		end = len(policies)
+	}
+
+	nameWidth := width / 6
+	v.table.ColumnWidths = []int{nameWidth, 10, nameWidth, 8, 4, 7, width - 2*nameWidth - 38}
+	rows := [][]string{{"Name", "VHost", "Pattern", "Apply to", "Pri", "Queues", "Definition"}}
+	for i := range rows[0] {
+		rows[0][i] = fmt.Sprintf("[%s](fg:black,bg:yellow)", rows[0][i])
+	}
+	v.table.RowStyles = make(map[int]termui.Style)
+	for i, p := range policies[v.offset:end] {
+		matched := len(policyQueues(p, d.queues))
+		switch {
+		case v.offset+i == v.cursor:
+			v.table.RowStyles[i+1] = termui.NewStyle(termui.ColorWhite, termui.ColorBlue, termui.ModifierBold)
+		case matched == 0:
+			v.table.RowStyles[i+1] = termui.NewStyle(termui.ColorYellow)
+		}
+		rows = append(rows, []string{
+			p.Name, p.VHost, p.Pattern, p.ApplyTo, fmt.Sprintf("%d", p.Priority),
+			fmt.Sprintf("%d", matched), formatDefinition(p.Definition),
+		})
+	}
+	if len(policies) == 0 {
+		rows = append(rows, []string{"No policies defined.", "", "", "", "", "", ""})
+	}
+	v.table.Rows = rows
+	v.table.Title = ""
+	if len(policies) > pageRows {
+		v.table.Title = fmt.Sprintf(" %d-%d of %d ", v.offset+1, end, len(policies))
+	}
+
+	v.detail.Text = ""
+	if len(policies) > 0 {
+		p := policies[v.cursor]
+		queues := policyQueues(p, d.queues)
+		applied := "none"
+		if len(queues) > 0 {
+			applied = strings.Join(queues, ", ")
+		}
+		v.detail.Title = fmt.Sprintf(" %s on %s ", p.Name, p.VHost)
+		v.detail.Text = fmt.Sprintf("Definition: %s\nApplied to: %s", formatDefinition(p.Definition), applied)
+	}
+
+	termui.Clear()
+	v.summary.SetRect(0, 0, width, 3)
+	v.table.SetRect(0, 3, width, tableBottom)
+	v.detail.SetRect(0, tableBottom, width, height-statusBarHeight)
+	drawables := []termui.Drawable{v.summary, v.table, v.detail}
+	drawWidgets(append(drawables, v.status.Layout(d, ui, width, height)...)...)
+	if v.form != nil {
+		v.form.Render()
+	}
+}
+
+// sortedPolicies orders policies by vhost and then highest priority first.
+func sortedPolicies(policies []PolicyInfo) []PolicyInfo {
+	policies = append([]PolicyInfo(nil), policies...)
+	sort.SliceStable(policies, func(i, j int) bool {
+		if policies[i].VHost != policies[j].VHost {
+			return policies[i].VHost < policies[j].VHost
+		}
+		return policies[i].Priority > policies[j].Priority
+	})
+	return policies
+}
+
+// follow keeps the cursor on the selected policy, as listView.follow does.
+func (v *policiesView) follow(policies []PolicyInfo) {
+	for i, p := range policies {
+		if p.VHost+"/"+p.Name == v.selected {
+			v.offset += i - v.cursor
+			v.cursor = i
+			return
+		}
+	}
+	if v.cursor >= len(policies) {
+		v.cursor = len(policies) - 1
+	}
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+	v.selected = ""
+	if len(policies) > 0 {
+		v.selected = policies[v.cursor].VHost + "/" + policies[v.cursor].Name
+	}
+}
+
+// policyWrites are the policy page's keys whose actions change the broker,
+// with what they do, for read-only mode to refuse.
+var policyWrites = map[string]string{"N": "creating policies", "E": "editing policies", "D": "deleting policies"}
+
+func (v *policiesView) HandleKey(d *dashboard, id string) bool {
+	if v.form != nil {
+		if v.form.Feed(id) {
+			v.form = nil
+		}
+		return true
+	}
+	if v.confirm != nil {
+		if done, confirmed := v.confirm.Feed(id); done {
+			if !confirmed {
+				d.setNotice("%s of %s cancelled", v.confirm.action, v.confirm.name)
+			}
+			v.confirm = nil
+		}
+		return true
+	}
+	if action, ok := policyWrites[id]; ok && d.readOnly(action) {
+		return true
+	}
+	policies := sortedPolicies(d.policies)
+	v.follow(policies)
+	switch id {
+	case "N":
+		p := PolicyInfo{VHost: "/", ApplyTo: "queues"}
+		if len(policies) > 0 {
+			p.VHost = policies[v.cursor].VHost
+		}
+		v.form = d.policyForm("New policy", p)
+		return true
+	case "E":
+		if len(policies) > 0 {
+			v.form = d.policyForm("Edit policy", policies[v.cursor])
+		}
+		return true
+	case "D":
+		if len(policies) > 0 {
+			p := policies[v.cursor]
+			v.confirm = d.confirm("delete_policy", &typedConfirmation{
+				action:   "Delete",
+				name:     p.Name,
+				question: fmt.Sprintf("Delete policy %s on %s, applied to %d queues?", p.Name, p.VHost, len(policyQueues(p, d.queues))),
+				run:      func() { d.startPolicyDelete(p) },
+			})
+		}
+		return true
+	case "j", "<Down>":
+		v.cursor++
+		v.selected = ""
+		return true
+	case "k", "<Up>":
+		if v.cursor > 0 {
+			v.cursor--
+		}
+		v.selected = ""
+		return true
+	}
+	return false
+}
+
+// Capturing reports whether the policy editor or a delete confirmation is
+// open.
+func (v *policiesView) Capturing() bool {
+	return v.form != nil || v.confirm != nil
+}
+
+// policyApplyTo lists the kinds of object a policy can apply to. The
+// queue-type ones need RabbitMQ 3.12 or later.
+var policyApplyTo = []string{"queues", "exchanges", "all", "classic_queues", "quorum_queues", "streams"}
+
+// policyDeclaration is the body of PUT /api/policies/{vhost}/{name}.
+type policyDeclaration struct {
+	Pattern    string                 `json:"pattern"`
+	Definition map[string]interface{} `json:"definition"`
+	Priority   int                    `json:"priority"`
+	ApplyTo    string                 `json:"apply-to"`
+}
+
+func policyPath(p PolicyInfo) string {
+	return "/api/policies/" + url.PathEscape(p.VHost) + "/" + url.PathEscape(p.Name)
+}
+
+// putPolicy creates p, or replaces the policy of the same name.
+func putPolicy(config Config, p PolicyInfo) error {
+	body := policyDeclaration{Pattern: p.Pattern, Definition: p.Definition, Priority: p.Priority, ApplyTo: p.ApplyTo}
+	return apiRequest(config, "PUT", policyPath(p), body, nil)
+}
+
+func deletePolicy(config Config, p PolicyInfo) error {
+	return apiRequest(config, "DELETE", policyPath(p), nil, nil)
+}
+
+// policyApplies reports whether p would apply to q if no other policy did:
+// the vhost and type fit and the pattern matches the queue's name.
+func policyApplies(p PolicyInfo, pattern *regexp.Regexp, q QueueInfo) bool {
+	if q.VHost != p.VHost || !pattern.MatchString(q.Name) {
+		return false
+	}
+	switch p.ApplyTo {
+	case "queues", "all":
+		return true
+	case "classic_queues":
+		return q.Type == "classic"
+	case "quorum_queues":
+		return q.Type == "quorum"
+	case "streams":
+		return q.Type == "stream"
+	}
+	return false
+}
+
+// policyPreview says which queues p would apply to once saved. A queue
+// takes only its highest-priority matching policy, so queues kept by
+// another policy of higher priority are listed apart.
+func policyPreview(p PolicyInfo, policies []PolicyInfo, queues []QueueInfo) string {
+	pattern, err := regexp.Compile(p.Pattern)
+	if err != nil {
+		return "[Pattern: " + err.Error() + "](fg:red)"
+	}
+	if p.ApplyTo == "exchanges" {
+		return "Applies to exchanges only; no queue is affected."
+	}
+	var applies, kept []string
+	for _, q := range queues {
+		if !policyApplies(p, pattern, q) {
+			continue
+		}
+		winner := ""
+		for _, other := range policies {
+			if other.VHost == p.VHost && other.Name == p.Name || other.Priority <= p.Priority {
+				continue
+			}
+			if re, err := regexp.Compile(other.Pattern); err == nil && policyApplies(other, re, q) {
+				winner = other.Name
+			}
+		}
+		if winner != "" {
+			kept = append(kept, fmt.Sprintf("%s (%s)", q.Name, winner))
+		} else {
+			applies = append(applies, q.Name)
+		}
+	}
+	preview := fmt.Sprintf("Would apply to %d queues", len(applies))
+	if len(applies) > 0 {
+		preview += ": " + truncateList(applies, 8)
+	}
+	if len(kept) > 0 {
+		preview += fmt.Sprintf("\n[%d more match but keep a higher-priority policy: %s](fg:yellow)", len(kept), truncateList(kept, 6))
+	}
+	return preview
+}
+
+// truncateList joins the first n items and counts the rest.
+func truncateList(items []string, n int) string {
+	if len(items) <= n {
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(items[:n], ", "), len(items)-n)
+}
+
+// editableDefinition renders a definition as the key=value pairs the
+// editor reads back: strings as they are, other values as JSON.
+func editableDefinition(def map[string]interface{}) string {
+	keys := make([]string, 0, len(def))
+	for k := range def {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		value, ok := def[k].(string)
+		if !ok {
+			data, _ := json.Marshal(def[k])
+			value = string(data)
+		}
+		parts[i] = k + "=" + value
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseDefinition reads key=value pairs separated by commas. Values that
+// are JSON, such as numbers, booleans and lists, are taken as such, and
+// others as strings; commas inside a list don't separate pairs.
+func parseDefinition(s string) (map[string]interface{}, error) {
+	var pairs []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				pairs = append(pairs, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	pairs = append(pairs, s[start:])
+
+	def := make(map[string]interface{})
+	for _, pair := range pairs {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("the definition must be key=value, separated by commas")
+		}
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+			parsed = value
+		}
+		def[key] = parsed
+	}
+	if len(def) == 0 {
+		return nil, fmt.Errorf("a definition is needed, e.g. max-length=10000")
+	}
+	return def, nil
+}
+
+// formPolicy reads the policy typed into a policy form.
+func formPolicy(values map[string]string) (PolicyInfo, error) {
+	p := PolicyInfo{Name: values["Name"], VHost: values["VHost"], Pattern: values["Pattern"], ApplyTo: values["Apply to"]}
+	if p.Name == "" {
+		return p, fmt.Errorf("a name is needed")
+	}
+	if p.VHost == "" {
+		return p, fmt.Errorf("a vhost is needed")
+	}
+	if _, err := regexp.Compile(p.Pattern); err != nil {
+		return p, fmt.Errorf("bad pattern: %w", err)
+	}
+	if values["Priority"] != "" {
+		n, err := strconv.Atoi(values["Priority"])
+		if err != nil {
+			return p, fmt.Errorf("priority must be a whole number")
+		}
+		p.Priority = n
+	}
+	def, err := parseDefinition(values["Definition"])
+	if err != nil {
+		return p, err
+	}
+	p.Definition = def
+	return p, nil
+}
+
+// policyForm edits p, or a new policy when p has no name. Saving under
+// another name creates a policy next to the original. The queues the
+// pattern would apply to are previewed as it is typed.
+func (d *dashboard) policyForm(title string, p PolicyInfo) *form {
+	applyTo := 0
+	for i, kind := range policyApplyTo {
+		if kind == p.ApplyTo {
+			applyTo = i
+		}
+	}
+	f := newForm(title, []*formField{
+		{label: "Name", input: lineInput{value: p.Name}},
+		{label: "VHost", input: lineInput{value: p.VHost}},
+		{label: "Pattern", hint: "regular expression, e.g. ^orders\\.", input: lineInput{value: p.Pattern}},
+		{label: "Apply to", choices: policyApplyTo, choice: applyTo},
+		{label: "Priority", input: lineInput{value: strconv.Itoa(p.Priority)}},
+		{label: "Definition", hint: "key=value, e.g. max-length=10000, overflow=reject-publish", input: lineInput{value: editableDefinition(p.Definition)}},
+	}, func(values map[string]string) error {
+		p, err := formPolicy(values)
+		if err != nil {
+			return err
+		}
+		if err := putPolicy(d.config, p); err != nil {
+			return err
+		}
+		d.verifyAfter(expectPolicy(d, p))
+		return nil
+	})
+	f.preview = func(values map[string]string) string {
+		p := PolicyInfo{Name: values["Name"], VHost: values["VHost"], Pattern: values["Pattern"], ApplyTo: values["Apply to"]}
+		p.Priority, _ = strconv.Atoi(values["Priority"])
+		return policyPreview(p, d.policies, d.queues)
+	}
+	return d.confirmForm(f, "policy", func(values map[string]string) (string, string) {
+		return values["Name"], fmt.Sprintf("Save policy %s on %s for %s?", values["Name"], values["VHost"], values["Pattern"])
+	})
+}
+
+// expectPolicy checks that a saved policy shows up as it was typed.
+// Policies are not part of the queue listing, so it reads them from the
+// dashboard.
+func expectPolicy(d *dashboard, p PolicyInfo) verification {
+	return verification{check: func([]QueueInfo) (string, bool) {
+		for _, x := range d.policies {
+			if x.VHost == p.VHost && x.Name == p.Name && x.Pattern == p.Pattern && x.Priority == p.Priority &&
+				formatDefinition(x.Definition) == formatDefinition(p.Definition) {
+				return fmt.Sprintf("saved policy %s on %s, applied to %d queues", p.Name, p.VHost, len(policyQueues(p, d.queues))), true
+			}
+		}
+		return fmt.Sprintf("waiting for policy %s on %s to show up", p.Name, p.VHost), false
+	}}
+}
+
+// startPolicyDelete deletes a policy and checks the following polls for it
+// to be gone.
+func (d *dashboard) startPolicyDelete(p PolicyInfo) {
+	if err := deletePolicy(d.config, p); err != nil {
+		d.setNotice("[Delete of policy %s failed: %s](fg:red)", p.Name, err)
+		return
+	}
+	d.verifyAfter(verification{check: func([]QueueInfo) (string, bool) {
+		for _, x := range d.policies {
+			if x.VHost == p.VHost && x.Name == p.Name {
+				return fmt.Sprintf("policy %s on %s still exists", p.Name, p.VHost), false
+			}
+		}
+		return fmt.Sprintf("deleted policy %s on %s", p.Name, p.VHost), true
+	}})
+}
@@ -0,0 +1,17 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/pprof"
+)
+
+// addPprof serves the Go runtime profiles under /debug/pprof/ on mux, for
+// profiling instances that keep history for weeks. It is only mounted when
+// asked for, since profiles expose memory contents such as credentials.
+func addPprof(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// publishRequest is the body of POST /api/exchanges/{vhost}/{name}/publish.
+type publishRequest struct {
+	Properties      map[string]interface{} `json:"properties"`
+	RoutingKey      string                 `json:"routing_key"`
+	Payload         string                 `json:"payload"`
+	PayloadEncoding string                 `json:"payload_encoding"`
+}
+
+// publishMessage publishes through the management API and reports whether
+// the message was routed to any queue. The default exchange is published to
+// as amq.default.
+func publishMessage(config Config, vhost, exchange string, m publishRequest) (bool, error) {
+	if exchange == "" {
+		exchange = "amq.default"
+	}
+	var result struct {
+		Routed bool `json:"routed"`
+	}
+	if err := apiRequest(config, "POST", exchangePath(vhost, exchange)+"/publish", m, &result); err != nil {
+		return false, err
+	}
+	return result.Routed, nil
+}
+
+// parseHeaders reads headers typed as "name=value, name=value".
+func parseHeaders(s string) (map[string]interface{}, error) {
+	headers := make(map[string]interface{})
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("headers must be name=value, separated by commas")
+		}
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
+// jsonTemplate is the payload the JSON template publishes when none is
+// typed: enough for a consumer to recognise and drop a test message.
+func jsonTemplate(id string, at time.Time) string {
+	data, _ := json.Marshal(map[string]interface{}{
+		"id":      id,
+		"test":    true,
+		"source":  "rabbitspy",
+		"sent_at": at.UTC().Format(time.RFC3339),
+	})
+	return string(data)
+}
+
+// publishForm asks for a test message to publish to an exchange, exchange
+// being empty for the default one, which routes by queue name.
+func (d *dashboard) publishForm(vhost, exchange, routingKey string) *form {
+	return d.confirmForm(newForm("Publish test message", []*formField{
+		{label: "Exchange", hint: "empty for the default exchange", input: lineInput{value: exchange}},
+		{label: "VHost", input: lineInput{value: vhost}},
+		{label: "Routing key", input: lineInput{value: routingKey}},
+		{label: "Headers", hint: "name=value, ..., optional"},
+		{label: "Content type", hint: "optional"},
+		{label: "Persistent", choices: []string{"yes", "no"}},
+		{label: "Template", choices: []string{"none", "JSON"}},
+		{label: "Payload", hint: "empty with the JSON template for a generated test message"},
+	}, d.publish), "publish", func(values map[string]string) (string, string) {
+		target := values["Exchange"]
+		if target == "" {
+			target = values["Routing key"]
+		}
+		return target, fmt.Sprintf("Publish to %s with routing key %q?", exchangeName(ExchangeInfo{VHost: values["VHost"], Name: values["Exchange"]}), values["Routing key"])
+	})
+}
+
+// publish sends the message described by a publishForm's values. With the
+// JSON template the payload must be JSON, and an empty one is generated.
+func (d *dashboard) publish(values map[string]string) error {
+	exchange, vhost := values["Exchange"], values["VHost"]
+	if vhost == "" {
+		return fmt.Errorf("a vhost is needed")
+	}
+	headers, err := parseHeaders(values["Headers"])
+	if err != nil {
+		return err
+	}
+	m := publishRequest{
+		Properties:      map[string]interface{}{"headers": headers},
+		RoutingKey:      values["Routing key"],
+		Payload:         values["Payload"],
+		PayloadEncoding: "string",
+	}
+	if values["Persistent"] == "yes" {
+		m.Properties["delivery_mode"] = 2
+	}
+	contentType := values["Content type"]
+	if values["Template"] == "JSON" {
+		if m.Payload == "" {
+			now := time.Now()
+			id := fmt.Sprintf("rabbitspy-test-%d", now.UnixNano())
+			m.Payload = jsonTemplate(id, now)
+			m.Properties["message_id"] = id
+		} else if !json.Valid([]byte(m.Payload)) {
+			return fmt.Errorf("the payload is not valid JSON")
+		}
+		if contentType == "" {
+			contentType = "application/json"
+		}
+	}
+	if contentType != "" {
+		m.Properties["content_type"] = contentType
+	}
+
+	name := exchangeName(ExchangeInfo{VHost: vhost, Name: exchange})
+	routed, err := publishMessage(d.config, vhost, exchange, m)
+	if err != nil {
+		return err
+	}
+	if !routed {
+		d.setNotice("[Published to %s with routing key %q, but no binding matched: the message was dropped](fg:yellow)", name, m.RoutingKey)
+		return nil
+	}
+	d.setNotice("Published to %s with routing key %q: routed", name, m.RoutingKey)
+	return nil
+}
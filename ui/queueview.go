@@ -0,0 +1,824 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+type topMode int
+
+const (
+	topOff topMode = iota
+	topBacklog
+	topImbalance
+)
+
+// topQueues returns the n queues ranked highest by the given mode. Imbalance
+// is how much faster messages are published than delivered. Queues of a
+// higher SLA tier rank above all those of lower ones, so the cut to n never
+// drops a tier-1 queue for a batch queue.
+func topQueues(tiers []TierConfig, queues []QueueInfo, mode topMode, n int) []QueueInfo {
+	if mode == topOff {
+		return queues
+	}
+	ranked := make([]QueueInfo, len(queues))
+	copy(ranked, queues)
+	score := func(q QueueInfo) float64 {
+		if mode == topBacklog {
+			return float64(q.Messages)
+		}
+		return q.MessageStats.PublishDetails.Rate - q.MessageStats.DeliverGetDetails.Rate
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if a, b := queueTier(tiers, ranked[i]), queueTier(tiers, ranked[j]); a != b {
+			return a < b
+		}
+		return score(ranked[i]) > score(ranked[j])
+	})
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
+type queueView struct {
+	summary   *widgets.Paragraph
+	table     *widgets.Table
+	status    *statusBar
+	detail    *widgets.Paragraph
+	graph     *widgets.SparklineGroup
+	fullGraph *queueGraph
+
+	showBindings bool
+	showSnoozed  bool
+	top          topMode
+	topN         int
+	split        bool
+	graphMode    bool
+
+	// columns are the configured table columns. The first frozen ones stay
+	// in place while the rest scroll horizontally, starting at colOffset.
+	columns   []queueColumn
+	frozen    int
+	colOffset int
+
+	// legend, while showLegend is set, is the column whose explanation
+	// replaces the cluster summary.
+	showLegend bool
+	legend     int
+
+	tiers []TierConfig
+
+	// sort is the queue order, starting from the configured one.
+	sort       []sortKey
+	configSort []sortKey
+
+	// selected is the key of the highlighted queue; cursor and offset are
+	// its row index and the first visible row after the last render.
+	selected string
+	cursor   int
+	offset   int
+
+	// note is the annotation being typed, if any.
+	note *lineInput
+	// confirm is a destructive action waiting for its target to be typed.
+	confirm *typedConfirmation
+	// form is the dialog being filled in, if any, drawn over the table.
+	form *form
+	// browser shows the selected queue's messages, if open.
+	browser *messageBrowser
+	// trace follows the firehose of the selected queue's vhost, if open.
+	trace *traceViewer
+	// reprocess is the dead-letter reprocessor shown, if open, and
+	// reprocessors are the ones started, by queue, which go on running
+	// while they are not shown.
+	reprocess    *reprocessor
+	reprocessors map[string]*reprocessor
+}
+
+func newQueueView(config Config) *queueView {
+	table := widgets.NewTable()
+	table.TextStyle = termui.NewStyle(termui.ColorWhite)
+	table.TextAlignment = termui.AlignLeft
+	table.BorderStyle = termui.NewStyle(termui.ColorCyan)
+	table.RowSeparator = true
+	table.FillRow = true
+
+	summary := widgets.NewParagraph()
+	summary.Text = "Cluster: N/A"
+	summary.BorderStyle = termui.NewStyle(termui.ColorCyan)
+
+	detail := widgets.NewParagraph()
+	detail.Title = " Details "
+	detail.BorderStyle = termui.NewStyle(termui.ColorCyan)
+
+	ready := widgets.NewSparkline()
+	ready.Title = "Ready"
+	ready.LineColor = termui.ColorYellow
+	publish := widgets.NewSparkline()
+	publish.Title = "Publish/s"
+	publish.LineColor = termui.ColorGreen
+	graph := widgets.NewSparklineGroup(ready, publish)
+	graph.Title = " History "
+	graph.BorderStyle = termui.NewStyle(termui.ColorCyan)
+
+	// LoadConfig has already rejected unknown column names.
+	columns, _ := resolveQueueColumns(config.UI.Columns)
+
+	return &queueView{
+		summary:    summary,
+		table:      table,
+		status:     newStatusBar(),
+		detail:     detail,
+		graph:      graph,
+		fullGraph:  newQueueGraph(),
+		topN:       config.UI.TopN,
+		columns:    columns,
+		frozen:     config.UI.FrozenColumns,
+		tiers:      config.Tiers,
+		sort:       config.queueSort(),
+		configSort: config.queueSort(),
+	}
+}
+
+// visibleRows is how many data rows fit below the header in a table of the
+// given outer height, with a separator line after every row.
+func visibleRows(height int) int {
+	n := (height - 2 - 1) / 2
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// selectQueue resolves the selection against the current list, following
+// the queue by key across refreshes and falling back to the same row index
+// when the selected queue disappeared.
+func (v *queueView) selectQueue(queues []QueueInfo, pageRows int) {
+	found := false
+	for i, q := range queues {
+		if q.Key() == v.selected {
+			// Scroll with the queue so it stays on the same line.
+			v.offset += i - v.cursor
+			v.cursor = i
+			found = true
+			break
+		}
+	}
+	if !found {
+		if v.cursor >= len(queues) {
+			v.cursor = len(queues) - 1
+		}
+		if v.cursor < 0 {
+			v.cursor = 0
+		}
+		v.selected = ""
+		if len(queues) > 0 {
+			v.selected = queues[v.cursor].Key()
+		}
+	}
+
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+pageRows {
+		v.offset = v.cursor - pageRows + 1
+	}
+	if v.offset > len(queues)-pageRows {
+		v.offset = len(queues) - pageRows
+	}
+	if v.offset < 0 {
+		v.offset = 0
+	}
+}
+
+func (v *queueView) Render(d *dashboard, ui uiState) {
+	prompt := ""
+	if v.note != nil {
+		prompt = fmt.Sprintf("Annotate: %s_  [Enter to save, Esc to cancel](fg:white)", v.note.value)
+	}
+	if v.confirm != nil {
+		prompt = v.confirm.Prompt()
+	}
+	if v.graphMode && v.selected != "" {
+		v.fullGraph.prompt = prompt
+		v.fullGraph.Render(d, v.selected, ui)
+		return
+	}
+
+	width, height := termui.TerminalDimensions()
+	table := v.table
+
+	tableWidth := width
+	if v.split {
+		tableWidth = width * 3 / 5
+	}
+	tableHeight := height - 9
+
+	if maxOffset := len(v.columns) - v.frozen - 1; v.colOffset > maxOffset {
+		v.colOffset = maxOffset
+	}
+	if v.colOffset < 0 {
+		v.colOffset = 0
+	}
+	if v.legend >= len(v.columns) {
+		v.legend = len(v.columns) - 1
+	}
+	if v.legend < 0 {
+		v.legend = 0
+	}
+	// Scroll the column being explained into view.
+	if v.showLegend && v.legend >= v.frozen && v.legend < v.frozen+v.colOffset {
+		v.colOffset = v.legend - v.frozen
+	}
+	visible, widths := layoutColumns(v.columns, v.frozen, v.colOffset, tableWidth)
+	for v.showLegend && visible[len(visible)-1] < v.legend && v.colOffset < len(v.columns)-v.frozen-1 {
+		v.colOffset++
+		visible, widths = layoutColumns(v.columns, v.frozen, v.colOffset, tableWidth)
+	}
+	// Don't scroll past the point where the last column is already on screen.
+	for v.colOffset > 0 {
+		prevVisible, prevWidths := layoutColumns(v.columns, v.frozen, v.colOffset-1, tableWidth)
+		if prevVisible[len(prevVisible)-1] != len(v.columns)-1 {
+			break
+		}
+		v.colOffset--
+		visible, widths = prevVisible, prevWidths
+	}
+	hiddenRight := len(v.columns) - 1 - visible[len(visible)-1]
+	table.ColumnWidths = widths
+
+	header := make([]string, len(visible))
+	for k, i := range visible {
+		header[k] = v.columns[i].header
+	}
+	rows := [][]string{header}
+	counts := bindingCounts(d.bindings)
+	lags := streamLags(d.streamConsumers, d.queues)
+
+	queues := d.queues
+	if !v.showSnoozed {
+		queues = d.snoozed.watching(queues)
+	}
+	queues = sortByTier(v.tiers, topQueues(v.tiers, sortQueues(v.sort, queues), v.top, v.topN))
+	pageRows := visibleRows(tableHeight)
+	v.selectQueue(queues, pageRows)
+
+	table.RowStyles = make(map[int]termui.Style)
+	end := v.offset + pageRows
+	if end > len(queues) {
+		end = len(queues)
+	}
+	flash := d.flashing()
+	for i, queue := range queues[v.offset:end] {
+		_, snoozed := d.snoozed[queue.Key()]
+		if v.offset+i == v.cursor {
+			table.RowStyles[i+1] = termui.NewStyle(termui.ColorWhite, termui.ColorBlue, termui.ModifierBold)
+		} else if snoozed {
+			table.RowStyles[i+1] = termui.NewStyle(tierColors["gray"])
+		} else if t := queueTier(v.tiers, queue); t < len(v.tiers) {
+			if style, ok := v.tiers[t].style(); ok {
+				table.RowStyles[i+1] = style
+			}
+		}
+		prev, seen := d.previous[queue.Key()]
+		cell := queueCell{queue: queue, prev: prev, flash: flash && seen, bindings: counts[queue.Key()], lag: lags[queue.Key()]}
+		_, cell.behind = d.behind[queue.Key()]
+		rows = append(rows, queueRow(v.columns, visible, widths, cell))
+	}
+
+	switch v.top {
+	case topOff:
+		table.Title = ""
+	case topBacklog:
+		table.Title = fmt.Sprintf(" Top %d by backlog ", v.topN)
+	case topImbalance:
+		table.Title = fmt.Sprintf(" Top %d by publish/deliver imbalance ", v.topN)
+	}
+	if v.top == topOff && len(v.sort) > 0 {
+		table.Title = fmt.Sprintf(" Sorted by %s ", sortDescription(v.sort))
+	}
+	if len(queues) > pageRows {
+		table.Title += fmt.Sprintf(" %d-%d of %d ", v.offset+1, end, len(queues))
+	}
+	if n := len(d.snoozed); n > 0 && v.showSnoozed {
+		table.Title += fmt.Sprintf(" %d snoozed shown, z wakes · Z hides ", n)
+	} else if n > 0 {
+		table.Title += fmt.Sprintf(" %d snoozed, Z shows ", n)
+	}
+	if v.colOffset > 0 {
+		table.Title = fmt.Sprintf(" < %d columns ", v.colOffset) + table.Title
+	}
+	if hiddenRight > 0 {
+		table.Title += fmt.Sprintf(" %d more columns > ", hiddenRight)
+	}
+	if v.showBindings {
+		reportWidth := (tableWidth - 6) / 4
+		table.Title = fmt.Sprintf(" Unused bindings (last %s) ", d.tracker.window)
+		table.ColumnWidths = []int{reportWidth, reportWidth, reportWidth, reportWidth}
+		table.RowStyles = make(map[int]termui.Style)
+		rows = unusedBindingRows(d.tracker, d.bindings, reportWidth)
+	}
+	table.Rows = rows
+	v.summary.Title = " " + d.name + " "
+	v.summary.Text = clusterSummary(d.queues, d.overview)
+	legend := v.showLegend && !v.showBindings
+	if legend {
+		column := v.columns[v.legend]
+		v.summary.Text = fmt.Sprintf("[%s](mod:bold)  %s", column.header, queueColumnHelp[column.id])
+		table.Title = " Legend · h/l for other columns, ? to close " + table.Title
+	}
+	if prompt != "" {
+		v.summary.Text = prompt
+	}
+
+	for i := range table.Rows[0] {
+		style := "fg:black,bg:yellow"
+		if legend && visible[i] == v.legend {
+			style = "fg:black,bg:cyan"
+		}
+		table.Rows[0][i] = fmt.Sprintf("[%s](%s)", truncateString(table.Rows[0][i], table.ColumnWidths[i]), style)
+	}
+
+	termui.Clear()
+	v.summary.SetRect(0, 0, width, 3)
+	table.SetRect(0, 3, tableWidth, height-statusBarHeight)
+	drawables := append([]termui.Drawable{v.summary, table}, v.status.Layout(d, ui, width, height)...)
+
+	if v.split {
+		middle := 3 + (height-9)/2
+		v.detail.SetRect(tableWidth, 3, width, middle)
+		v.graph.SetRect(tableWidth, middle, width, height-6)
+		v.renderDetail(d, queues, width-tableWidth-2)
+		drawables = append(drawables, v.detail, v.graph)
+	}
+	drawWidgets(drawables...)
+	if v.form != nil {
+		v.form.Render()
+	}
+	if v.browser != nil {
+		v.browser.Render()
+	}
+	if v.trace != nil {
+		v.trace.Render()
+	}
+	if v.reprocess != nil {
+		v.reprocess.Render()
+	}
+}
+
+func (v *queueView) renderDetail(d *dashboard, queues []QueueInfo, graphWidth int) {
+	if v.cursor >= len(queues) {
+		v.detail.Text = "No queue selected."
+		for _, sl := range v.graph.Sparklines {
+			sl.Data = nil
+		}
+		return
+	}
+	q := queues[v.cursor]
+	ready, unacked := colorizeNumber(q.MessagesReady), colorizeNumber(q.MessagesUnack)
+	if q.Type == "stream" {
+		ready, unacked = "-", "-"
+	}
+	v.detail.Text = fmt.Sprintf(
+		"[%s](mod:bold)\n"+
+			"VHost:     %s\n"+
+			"Type:      %s\n"+
+			"State:     %s %s\n"+
+			"Consumers: %d\n"+
+			"Ready:     %s\n"+
+			"Unacked:   %s\n"+
+			"Publish:   %.1f/s\n"+
+			"Deliver:   %.1f/s\n"+
+			"Memory:    %s\n"+
+			"Bindings:  %d",
+		q.Name, q.VHost, q.Type, getStateIndicator(queueState(q)), queueState(q), q.Consumers,
+		ready, unacked,
+		q.MessageStats.PublishDetails.Rate, q.MessageStats.DeliverGetDetails.Rate,
+		formatBytes(q.Memory), bindingCounts(d.bindings)[q.Key()],
+	)
+	if until, ok := d.snoozed[q.Key()]; ok {
+		v.detail.Text += fmt.Sprintf("\nSnoozed:   until %s", until.Format("Jan 2 15:04"))
+	}
+	if s, ok := d.behind[q.Key()]; ok {
+		v.detail.Text += fmt.Sprintf("\n[Falling behind: %s](fg:red)", s)
+	}
+	if text := retryText(q, retryPipelines(d.queues, d.exchanges, d.bindings)); text != "" {
+		v.detail.Text += "\n" + text
+	}
+	if queueState(q) == "flow" {
+		v.detail.Text += "\n[In flow control: the broker is slowing its publishers down so the queue can keep up.](fg:yellow)"
+	}
+	if len(q.SlaveNodes) > 0 {
+		v.detail.Text += fmt.Sprintf("\nMirrors:   %d/%d synchronised", len(q.SynchronisedSlaveNodes), len(q.SlaveNodes))
+	}
+	if q.Type == "stream" {
+		v.detail.Text += streamDetail(q)
+		for _, g := range consumerGroups(d.streamConsumers, d.queues) {
+			if streamKey(g.stream) == q.Key() {
+				v.detail.Text += fmt.Sprintf("\nLag:       %s behind, %s", colorizeNumber(int(g.lag)), g.lagTrend(q.MessageStats.PublishDetails.Rate))
+				break
+			}
+		}
+	}
+	if q.Type == "quorum" && len(q.Members) > 0 {
+		v.detail.Text += quorumDetail(q)
+	} else if q.Leader != "" {
+		v.detail.Text += fmt.Sprintf("\nLeader:    %s (%d/%d members online)", q.Leader, len(q.Online), len(q.Members))
+	}
+	if t := queueTier(v.tiers, q); t < len(v.tiers) {
+		v.detail.Text += "\nTier:      " + v.tiers[t].Name
+	}
+	if badges := queueBadges(q); len(badges) > 0 {
+		styled := make([]string, len(badges))
+		for i, b := range badges {
+			styled[i] = b.styled()
+		}
+		v.detail.Text += "\nArgs:      " + strings.Join(styled, " ")
+		for _, b := range badges {
+			if b.warning != "" {
+				v.detail.Text += fmt.Sprintf("\n[! %s](fg:yellow)", b.warning)
+			}
+		}
+	}
+
+	samples := d.history.Samples(q.Key())
+	picks := []func(queueSample) float64{
+		func(s queueSample) float64 { return float64(s.ready) },
+		func(s queueSample) float64 { return s.publishRate },
+	}
+	for i, sl := range v.graph.Sparklines {
+		sl.Data = lastN(samples, graphWidth, picks[i])
+		sl.MaxVal = 1
+		for _, value := range sl.Data {
+			if value > sl.MaxVal {
+				sl.MaxVal = value
+			}
+		}
+	}
+}
+
+// queueWrites are the queue page's keys whose actions change the broker,
+// with what they do, for read-only mode to refuse.
+var queueWrites = map[string]string{
+	"Y": "synchronising mirrors", "R": "rebalancing", "P": "purging", "E": "purging", "N": "declaring queues",
+	"Q": "requeueing", "C": "reprocessing", "V": "moving messages", "L": "binding", "U": "unbinding", "F": "switching the firehose",
+	"W": "draining", "I": "replaying", "S": "publishing", "D": "deleting",
+}
+
+// HandleKey reacts to view-specific keys and reports whether it consumed
+// the event.
+func (v *queueView) HandleKey(d *dashboard, id string) bool {
+	if v.note != nil {
+		if done, cancelled := v.note.Feed(id); done {
+			if text := strings.TrimSpace(v.note.value); !cancelled && text != "" {
+				d.annotate(text, "manual")
+			}
+			v.note = nil
+		}
+		return true
+	}
+	if v.form != nil {
+		if v.form.Feed(id) {
+			v.form = nil
+		}
+		return true
+	}
+	if v.browser != nil {
+		if v.browser.Feed(d, id) {
+			v.browser = nil
+		}
+		return true
+	}
+	if v.trace != nil {
+		if v.trace.Feed(d, id) {
+			v.trace = nil
+		}
+		return true
+	}
+	if v.reprocess != nil {
+		if v.reprocess.Feed(id) {
+			v.reprocess = nil
+		}
+		return true
+	}
+	if v.confirm != nil {
+		if done, confirmed := v.confirm.Feed(id); done {
+			if !confirmed {
+				d.setNotice("%s of %s cancelled", v.confirm.action, v.confirm.name)
+			}
+			v.confirm = nil
+		}
+		return true
+	}
+	if id == "a" {
+		v.note = &lineInput{}
+		return true
+	}
+	if v.graphMode {
+		if id == "g" || id == "<Escape>" {
+			v.graphMode = false
+			return true
+		}
+		return false
+	}
+	if v.showLegend {
+		switch id {
+		case "?", "<Escape>":
+			v.showLegend = false
+			return true
+		case "h", "<Left>":
+			v.legend--
+			return true
+		case "l", "<Right>":
+			v.legend++
+			return true
+		}
+	}
+	if action, ok := queueWrites[id]; ok && d.readOnly(action) {
+		return true
+	}
+
+	switch id {
+	case "?":
+		// Start from the leftmost column on screen.
+		v.showLegend = true
+		v.legend = v.colOffset
+		if v.frozen > 0 {
+			v.legend = 0
+		}
+		return true
+	case "g":
+		v.graphMode = !v.showBindings
+		return true
+	case "b":
+		v.showBindings = !v.showBindings
+		return true
+	case "t":
+		v.top = (v.top + 1) % 3
+		return true
+	case "s":
+		v.split = !v.split
+		return true
+	case "o":
+		v.sort = nextSortField(v.sort)
+		return true
+	case "O":
+		if len(v.sort) > 0 {
+			v.sort = append([]sortKey(nil), v.sort...)
+			v.sort[0].desc = !v.sort[0].desc
+		}
+		return true
+	case "m":
+		v.sort = pushSortKey(v.sort)
+		return true
+	case "M":
+		v.sort = v.configSort
+		return true
+	case "h", "<Left>":
+		v.colOffset--
+		return true
+	case "l", "<Right>":
+		v.colOffset++
+		return true
+	case "Y":
+		if q, ok := findQueue(d.queues, v.selected); ok {
+			v.confirm = d.confirm("sync", &typedConfirmation{
+				action:   "Sync",
+				name:     q.Name,
+				question: fmt.Sprintf("Synchronise the mirrors of %s? The queue is unavailable until they are.", q.Key()),
+				run:      func() { d.startSync(q) },
+			})
+		}
+		return true
+	case "R":
+		v.confirm = d.rebalanceConfirmation()
+		return true
+	case "P":
+		if q, ok := findQueue(d.queues, v.selected); ok {
+			v.confirm = d.confirm("purge", &typedConfirmation{
+				action:   "Purge",
+				name:     q.Name,
+				question: fmt.Sprintf("Purge %s ready messages from %s?", groupDigits(q.MessagesReady), q.Key()),
+				run:      func() { d.startPurge(q) },
+			})
+		}
+		return true
+	case "E":
+		v.form = d.bulkPurgeForm(func(pattern string, targets []QueueInfo) {
+			name := pattern
+			if name == "" {
+				name = bulkPurgeName
+			}
+			v.confirm = d.confirm("bulk_purge", &typedConfirmation{
+				action:   "Bulk purge",
+				name:     name,
+				question: bulkPurgeSummary(targets) + ".",
+				run:      func() { d.startBulkPurge(targets) },
+			})
+		})
+		return true
+	case "N":
+		vhost := "/"
+		if q, ok := findQueue(d.queues, v.selected); ok {
+			vhost = q.VHost
+		}
+		v.form = d.newQueueForm(vhost)
+		return true
+	case "B":
+		q, ok := findQueue(d.queues, v.selected)
+		if !ok {
+			return true
+		}
+		if mode := d.config.Browser.AckMode; !requeues(mode) {
+			if d.readOnly("browsing with " + mode) {
+				return true
+			}
+			v.confirm = d.confirm("browse", &typedConfirmation{
+				action:   "Browse",
+				name:     q.Name,
+				question: fmt.Sprintf("Browsing with %s removes up to %d messages from %s. Browse it?", mode, d.config.Browser.Count, q.Key()),
+				run:      func() { v.browser = newMessageBrowser(d, q) },
+			})
+			return true
+		}
+		v.browser = newMessageBrowser(d, q)
+		return true
+	case "Q":
+		if q, ok := findQueue(d.queues, v.selected); ok {
+			v.confirm = d.confirm("requeue", &typedConfirmation{
+				action:   "Requeue",
+				name:     q.Name,
+				question: fmt.Sprintf("Republish %s dead-lettered messages from %s to where they came from, %g a second?", groupDigits(q.MessagesReady), q.Key(), d.config.Requeue.RatePerSecond),
+				run:      func() { d.startRequeue(q) },
+			})
+		}
+		return true
+	case "C":
+		q, ok := findQueue(d.queues, v.selected)
+		if !ok {
+			return true
+		}
+		if r := v.reprocessors[q.Key()]; r != nil && !r.finished() {
+			v.reprocess = r
+			return true
+		}
+		if q.MessagesReady == 0 {
+			d.setNotice("%s has no ready messages to reprocess", q.Key())
+			return true
+		}
+		v.form = d.reprocessForm(q, func(r *reprocessor) {
+			d.startReprocess(r)
+			if v.reprocessors == nil {
+				v.reprocessors = make(map[string]*reprocessor)
+			}
+			v.reprocessors[q.Key()], v.reprocess = r, r
+		})
+		return true
+	case "V":
+		if q, ok := findQueue(d.queues, v.selected); ok {
+			v.form = d.moveForm(q)
+		}
+		return true
+	case "L", "U":
+		if q, ok := findQueue(d.queues, v.selected); ok {
+			if id == "L" {
+				v.form = d.bindForm(q.VHost, "", "queue", q.Name)
+			} else {
+				v.form = d.unbindForm(q.VHost, "", "queue", q.Name)
+			}
+		}
+		return true
+	case "F":
+		q, ok := findQueue(d.queues, v.selected)
+		if !ok {
+			return true
+		}
+		if tracing(d, q.VHost) {
+			d.startTracing(q.VHost, false)
+			return true
+		}
+		v.confirm = d.confirm("firehose", &typedConfirmation{
+			action:   "Firehose",
+			name:     q.VHost,
+			question: fmt.Sprintf("Turn on the firehose of vhost %s? Every publish and delivery is copied to amq.rabbitmq.trace until it is turned off.", q.VHost),
+			run:      func() { d.startTracing(q.VHost, true) },
+		})
+		return true
+	case "T":
+		q, ok := findQueue(d.queues, v.selected)
+		if !ok {
+			return true
+		}
+		if !tracing(d, q.VHost) {
+			d.setNotice("[The firehose of %s is off; F turns it on](fg:yellow)", q.VHost)
+			return true
+		}
+		trace, err := newTraceViewer(d, q.VHost)
+		if err != nil {
+			d.setNotice("[Tracing %s failed: %s](fg:red)", q.VHost, err)
+			return true
+		}
+		v.trace = trace
+		return true
+	case "W":
+		q, ok := findQueue(d.queues, v.selected)
+		if !ok {
+			return true
+		}
+		if q.MessagesReady == 0 {
+			d.setNotice("%s has no ready messages to drain", q.Key())
+			return true
+		}
+		v.form = d.drainForm(q, func(path, format string, n int) {
+			v.confirm = d.confirm("drain", &typedConfirmation{
+				action:   "Drain",
+				name:     q.Name,
+				question: fmt.Sprintf("Take %s messages off %s and write them to %s?", groupDigits(n), q.Key(), path),
+				run:      func() { d.startDrain(q, path, format, n) },
+			})
+		})
+		return true
+	case "I":
+		if q, ok := findQueue(d.queues, v.selected); ok {
+			v.form = d.replayForm(q)
+		}
+		return true
+	case "X":
+		d.cancelJobs()
+		return true
+	case "z":
+		q, ok := findQueue(d.queues, v.selected)
+		if !ok {
+			return true
+		}
+		if _, snoozed := d.snoozed[q.Key()]; snoozed {
+			delete(d.snoozed, q.Key())
+			d.setNotice("%s is no longer snoozed", q.Key())
+			return true
+		}
+		v.form = d.snoozeForm(q)
+		return true
+	case "Z":
+		v.showSnoozed = !v.showSnoozed
+		return true
+	case "y":
+		if q, ok := findQueue(d.queues, v.selected); ok {
+			d.copyText("the name of "+q.Key(), []byte(q.Name))
+		}
+		return true
+	case "S":
+		if q, ok := findQueue(d.queues, v.selected); ok {
+			v.form = d.publishForm(q.VHost, "", q.Name)
+		}
+		return true
+	case "D":
+		if q, ok := findQueue(d.queues, v.selected); ok {
+			c := &typedConfirmation{
+				action:   "Delete",
+				name:     q.Name,
+				question: fmt.Sprintf("Delete %s with %s messages and %d consumers?", q.Key(), groupDigits(q.Messages), q.Consumers),
+				toggles:  []confirmToggle{{key: "<C-e>", label: "if empty"}, {key: "<C-u>", label: "if unused"}},
+			}
+			c.run = func() { d.startDelete(q, c.toggled("<C-e>"), c.toggled("<C-u>")) }
+			v.confirm = d.confirm("delete_queue", c)
+		}
+		return true
+	case "j", "<Down>":
+		v.moveCursor(1)
+		return true
+	case "k", "<Up>":
+		v.moveCursor(-1)
+		return true
+	}
+	return false
+}
+
+// Capturing reports whether an annotation, a confirmation or a form is being
+// typed, messages are being browsed or traced, or a reprocessor is shown.
+func (v *queueView) Capturing() bool {
+	return v.note != nil || v.confirm != nil || v.form != nil || v.browser != nil || v.trace != nil || v.reprocess != nil
+}
+
+// focusQueue leaves any sub-mode and selects the queue with the given key in
+// the full list.
+func (v *queueView) focusQueue(key string) {
+	v.graphMode = false
+	v.showBindings = false
+	v.top = topOff
+	v.selected = key
+}
+
+// moveCursor shifts the selection by delta rows. The new key is resolved on
+// the next render, which knows the current queue list.
+func (v *queueView) moveCursor(delta int) {
+	v.cursor += delta
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+	v.selected = ""
+}
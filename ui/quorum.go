@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// quorumTolerance is how many more members of a quorum queue can go offline
+// before it loses its majority. It is negative once it has.
+func quorumTolerance(q QueueInfo) int {
+	return len(q.Online) - (len(q.Members)/2 + 1)
+}
+
+// quorumDetail lists a quorum queue's members, marking the leader and the
+// offline ones, for the detail pane.
+func quorumDetail(q QueueInfo) string {
+	online := make(map[string]bool, len(q.Online))
+	for _, node := range q.Online {
+		online[node] = true
+	}
+	members := make([]string, len(q.Members))
+	for i, node := range q.Members {
+		switch {
+		case !online[node]:
+			members[i] = fmt.Sprintf("[%s ✗](fg:red)", node)
+		case node == q.Leader:
+			members[i] = fmt.Sprintf("[%s ★](fg:green)", node)
+		default:
+			members[i] = node + " ✓"
+		}
+	}
+
+	leader := q.Leader
+	if leader == "" {
+		leader = "[none](fg:red)"
+	}
+	text := fmt.Sprintf("\nLeader:    %s\nMembers:   %s\nOnline:    %d/%d", leader, strings.Join(members, ", "), len(q.Online), len(q.Members))
+	switch tolerance := quorumTolerance(q); {
+	case tolerance < 0:
+		text += "\n[! No quorum: the queue is unavailable until a majority of members is back](fg:magenta,mod:bold)"
+	case len(q.Online) < len(q.Members) && tolerance == 0:
+		text += "\n[! Reduced quorum: one more member going offline makes the queue unavailable](fg:yellow)"
+	case len(q.Online) < len(q.Members):
+		text += fmt.Sprintf("\n[! Reduced quorum: tolerates %d more member failures](fg:yellow)", tolerance)
+	case tolerance == 0:
+		text += "\n[! Cannot lose any member without losing quorum](fg:yellow)"
+	}
+	return text
+}
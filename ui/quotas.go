@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+)
+
+type Quota struct {
+	MaxConnections int `json:"max_connections"`
+	MaxChannels    int `json:"max_channels"`
+}
+
+type QuotaConfig struct {
+	PerUser Quota            `json:"per_user"`
+	PerIP   Quota            `json:"per_ip"`
+	Users   map[string]Quota `json:"users"`
+	IPs     map[string]Quota `json:"ips"`
+}
+
+func (q QuotaConfig) enabled() bool {
+	return q.PerUser != (Quota{}) || q.PerIP != (Quota{}) || len(q.Users) > 0 || len(q.IPs) > 0
+}
+
+type connectionUsage struct {
+	connections int
+	channels    int
+}
+
+// connectionQuotaAlerts compares per-user and per-source-IP connection and
+// channel counts against the configured quotas. A quota of zero means
+// unlimited; entries under users/ips override the per_user/per_ip defaults.
+func connectionQuotaAlerts(quotas QuotaConfig, connections []ConnectionInfo) []Alert {
+	byUser := make(map[string]*connectionUsage)
+	byIP := make(map[string]*connectionUsage)
+	count := func(usage map[string]*connectionUsage, key string, c ConnectionInfo) {
+		if usage[key] == nil {
+			usage[key] = &connectionUsage{}
+		}
+		usage[key].connections++
+		usage[key].channels += c.Channels
+	}
+	for _, c := range connections {
+		count(byUser, c.User, c)
+		count(byIP, c.PeerHost, c)
+	}
+
+	var alerts []Alert
+	alerts = append(alerts, quotaAlerts("user", byUser, quotas.PerUser, quotas.Users)...)
+	alerts = append(alerts, quotaAlerts("ip", byIP, quotas.PerIP, quotas.IPs)...)
+	return alerts
+}
+
+func quotaAlerts(kind string, usage map[string]*connectionUsage, fallback Quota, overrides map[string]Quota) []Alert {
+	names := make([]string, 0, len(usage))
+	for name := range usage {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	label := "User"
+	if kind == "ip" {
+		label = "Client"
+	}
+
+	var alerts []Alert
+	for _, name := range names {
+		quota, ok := overrides[name]
+		if !ok {
+			quota = fallback
+		}
+		u := usage[name]
+		if quota.MaxConnections > 0 && u.connections > quota.MaxConnections {
+			alerts = append(alerts, Alert{
+				Key:      fmt.Sprintf("quota-%s-%s-connections", kind, name),
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s %s has %d connections (quota %d)", label, name, u.connections, quota.MaxConnections),
+			})
+		}
+		if quota.MaxChannels > 0 && u.channels > quota.MaxChannels {
+			alerts = append(alerts, Alert{
+				Key:      fmt.Sprintf("quota-%s-%s-channels", kind, name),
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s %s has %d channels (quota %d)", label, name, u.channels, quota.MaxChannels),
+			})
+		}
+	}
+	return alerts
+}
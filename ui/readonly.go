@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+)
+
+// errReadOnly is returned for requests that would change the broker while
+// rabbitspy runs read-only.
+var errReadOnly = errors.New("rabbitspy is running read-only")
+
+// changesBroker reports whether an API request changes the broker. Getting
+// messages is a POST that, in the ack modes that requeue, only reads them;
+// getMessages refuses the other modes itself.
+func changesBroker(method, path string) bool {
+	return method != "GET" && !(method == "POST" && strings.HasSuffix(path, "/get"))
+}
+
+// readOnly reports whether d runs read-only, and if so tells that action
+// is disabled. Key handlers check it before opening a form or confirmation
+// that would only fail; the API refuses the request anyway.
+func (d *dashboard) readOnly(action string) bool {
+	if !d.config.ReadOnly {
+		return false
+	}
+	d.setNotice("[Read-only: %s is disabled](fg:yellow)", action)
+	return true
+}
+
+// readOnlySummary marks the status line while rabbitspy runs read-only.
+func readOnlySummary(d *dashboard) string {
+	if !d.config.ReadOnly {
+		return ""
+	}
+	return "[ READ-ONLY ](fg:black,bg:cyan,mod:bold)"
+}
@@ -0,0 +1,540 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ReprocessConfig holds the defaults of the dead-letter reprocessor: how
+// many messages it holds unacknowledged at a time, and how often a publish
+// that failed is retried, waiting Backoff seconds the first time and twice
+// as long each time after.
+type ReprocessConfig struct {
+	BatchSize      int     `json:"batch_size"`
+	Retries        int     `json:"retries"`
+	BackoffSeconds float64 `json:"backoff_seconds"`
+}
+
+const (
+	defaultReprocessBatch   = 50
+	defaultReprocessRetries = 3
+	defaultReprocessBackoff = 1
+	// reprocessMaxBackoff caps the wait between retries.
+	reprocessMaxBackoff = 30 * time.Second
+	// reprocessOutcomes is how many per-message outcomes are kept.
+	reprocessOutcomes = 1000
+)
+
+// reprocessOutcome is what became of one message.
+type reprocessOutcome struct {
+	at       time.Time
+	id       string
+	target   string
+	attempts int
+	// result is "republished", "skipped" (it had no x-death to go back
+	// to), "put back" (republishing failed and it went to the tail of the
+	// queue) or "failed" (it went back to the head, stopping the run).
+	result string
+	err    error
+}
+
+// reprocessor republishes the messages of a dead-letter queue as a job,
+// more carefully than a requeue: it consumes over AMQP with a prefetch of a
+// batch, publishes each message with publisher confirms, retries failed
+// publishes with backoff, and acknowledges a batch once all of it has been
+// handled, so a message leaves the queue only after it was confirmed
+// somewhere. It can be paused and resumed.
+type reprocessor struct {
+	queue QueueInfo
+	// exchange and routingKey are where messages go; both empty sends each
+	// to where it was dead-lettered from.
+	exchange    string
+	routingKey  string
+	rate        float64
+	batch       int
+	retries     int
+	backoff     time.Duration
+	stopOnError bool
+	started     time.Time
+	job         *job
+
+	mu       sync.Mutex
+	paused   bool
+	resume   chan struct{}
+	state    string
+	outcomes []reprocessOutcome
+
+	cursor int
+	list   *widgets.Table
+	detail *widgets.Paragraph
+}
+
+// reprocessForm asks where to send the messages of q, how fast, and what
+// to do about those that cannot be republished, and starts the reprocessor.
+func (d *dashboard) reprocessForm(q QueueInfo, start func(r *reprocessor)) *form {
+	config := d.config.Reprocess
+	return d.confirmForm(newForm("Reprocess "+q.Key(), []*formField{
+		{label: "Exchange", hint: "empty with no routing key: where each was dead-lettered from"},
+		{label: "Routing key"},
+		{label: "Rate per second", input: lineInput{value: strconv.FormatFloat(d.config.Requeue.RatePerSecond, 'g', -1, 64)}},
+		{label: "Batch size", input: lineInput{value: strconv.Itoa(config.BatchSize)}},
+		{label: "Retries", input: lineInput{value: strconv.Itoa(config.Retries)}},
+		{label: "On error", choices: []string{"put back and go on", "stop"}},
+	}, func(values map[string]string) error {
+		rate, err := strconv.ParseFloat(values["Rate per second"], 64)
+		if err != nil || rate <= 0 {
+			return fmt.Errorf("the rate must be a positive number")
+		}
+		batch, err := strconv.Atoi(values["Batch size"])
+		if err != nil || batch <= 0 {
+			return fmt.Errorf("the batch size must be a positive whole number")
+		}
+		retries, err := strconv.Atoi(values["Retries"])
+		if err != nil || retries < 0 {
+			return fmt.Errorf("retries must be a whole number")
+		}
+		start(&reprocessor{
+			queue:       q,
+			exchange:    values["Exchange"],
+			routingKey:  values["Routing key"],
+			rate:        rate,
+			batch:       batch,
+			retries:     retries,
+			backoff:     time.Duration(config.BackoffSeconds * float64(time.Second)),
+			stopOnError: values["On error"] == "stop",
+		})
+		return nil
+	}), "reprocess", func(values map[string]string) (string, string) {
+		return q.Name, fmt.Sprintf("Republish %s messages from %s to %s?", groupDigits(q.MessagesReady), q.Key(), reprocessTarget(values["Exchange"], values["Routing key"]))
+	})
+}
+
+func reprocessTarget(exchange, routingKey string) string {
+	if exchange == "" && routingKey == "" {
+		return "where they were dead-lettered from"
+	}
+	if exchange == "" {
+		exchange = "(AMQP default)"
+	}
+	return exchange + " → " + routingKey
+}
+
+// startReprocess runs r as a job over the messages q has ready now; those
+// it puts back at the tail are not handled again.
+func (d *dashboard) startReprocess(r *reprocessor) {
+	config := d.config
+	r.started = time.Now()
+	r.list = widgets.NewTable()
+	r.list.TextStyle = termui.NewStyle(termui.ColorWhite)
+	r.list.BorderStyle = termui.NewStyle(termui.ColorYellow)
+	r.list.RowSeparator = false
+	r.list.FillRow = true
+	r.detail = widgets.NewParagraph()
+	r.detail.BorderStyle = termui.NewStyle(termui.ColorYellow)
+	r.job = d.startJob("Reprocess "+r.queue.Key(), r.queue.MessagesReady, func(ctx context.Context, j *job) error {
+		detail := fmt.Sprintf("to %s, %g/s", reprocessTarget(r.exchange, r.routingKey), r.rate)
+		return audited(config, "reprocess queue", r.queue.Key(), detail, func() error {
+			return r.run(ctx, config, j)
+		})
+	})
+}
+
+// reprocessChannel publishes with confirms, telling messages the broker
+// returned as unroutable.
+type reprocessChannel struct {
+	ch      *amqp.Channel
+	returns chan amqp.Return
+}
+
+func openReprocessChannel(conn *amqp.Connection) (*reprocessChannel, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	return &reprocessChannel{ch: ch, returns: ch.NotifyReturn(make(chan amqp.Return, 1))}, nil
+}
+
+// publish sends msg mandatory and waits for its confirm. The broker sends
+// the return of an unroutable message before its confirm, so it is waiting
+// by then.
+func (rc *reprocessChannel) publish(ctx context.Context, exchange, key string, msg amqp.Publishing) error {
+	select {
+	case <-rc.returns:
+	default:
+	}
+	confirm, err := rc.ch.PublishWithDeferredConfirmWithContext(ctx, exchange, key, true, false, msg)
+	if err != nil {
+		return err
+	}
+	if !confirm.Wait() {
+		return errors.New("not confirmed by the broker")
+	}
+	select {
+	case ret := <-rc.returns:
+		return fmt.Errorf("unroutable: %s", ret.ReplyText)
+	default:
+	}
+	return nil
+}
+
+// deliveryPublishing copies a delivery's properties and body to publish it
+// again, adding the CC keys given.
+func deliveryPublishing(d amqp.Delivery, cc []string) amqp.Publishing {
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	if len(cc) > 0 {
+		keys := make([]interface{}, len(cc))
+		for i, k := range cc {
+			keys[i] = k
+		}
+		headers["CC"] = keys
+	}
+	return amqp.Publishing{
+		Headers:         headers,
+		ContentType:     d.ContentType,
+		ContentEncoding: d.ContentEncoding,
+		DeliveryMode:    d.DeliveryMode,
+		Priority:        d.Priority,
+		CorrelationId:   d.CorrelationId,
+		ReplyTo:         d.ReplyTo,
+		Expiration:      d.Expiration,
+		MessageId:       d.MessageId,
+		Timestamp:       d.Timestamp,
+		Type:            d.Type,
+		UserId:          d.UserId,
+		AppId:           d.AppId,
+		Body:            d.Body,
+	}
+}
+
+func (r *reprocessor) run(ctx context.Context, config Config, j *job) error {
+	if config.ReadOnly {
+		return errReadOnly
+	}
+	conn, err := amqp.Dial(amqpURI(config) + url.PathEscape(r.queue.VHost))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	in, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	if err := in.Qos(r.batch, 0, false); err != nil {
+		return err
+	}
+	deliveries, err := in.ConsumeWithContext(ctx, r.queue.Name, "", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	out, err := openReprocessChannel(conn)
+	if err != nil {
+		return err
+	}
+
+	var last uint64
+	pending := 0
+	ack := func() error {
+		if pending == 0 {
+			return nil
+		}
+		pending = 0
+		return in.Ack(last, true)
+	}
+	// publish retries with backoff, reopening the channel when the broker
+	// closed it, as it does for an exchange that does not exist.
+	publish := func(exchange, key string, msg amqp.Publishing) (attempts int, err error) {
+		wait := r.backoff
+		for attempts = 1; ; attempts++ {
+			if out.ch.IsClosed() {
+				if out, err = openReprocessChannel(conn); err != nil {
+					return attempts, err
+				}
+			}
+			if err = out.publish(ctx, exchange, key, msg); err == nil || attempts > r.retries {
+				return attempts, err
+			}
+			r.setState(fmt.Sprintf("retrying in %s: %s", wait, err))
+			select {
+			case <-ctx.Done():
+				return attempts, err
+			case <-time.After(wait):
+			}
+			wait = min(wait*2, reprocessMaxBackoff)
+			r.setState("")
+		}
+	}
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / r.rate))
+	defer ticker.Stop()
+	for n := 0; n < j.total; n++ {
+		if r.isPaused() {
+			if err := ack(); err != nil {
+				return err
+			}
+			if !r.waitResumed(ctx) {
+				return ack()
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ack()
+		case <-ticker.C:
+		}
+		var d amqp.Delivery
+		select {
+		case <-ctx.Done():
+			return ack()
+		case delivery, ok := <-deliveries:
+			if !ok {
+				if ctx.Err() != nil {
+					return ack()
+				}
+				return fmt.Errorf("the broker cancelled consuming from %s", r.queue.Key())
+			}
+			d = delivery
+		}
+
+		outcome := reprocessOutcome{id: d.MessageId, target: reprocessTarget(r.exchange, r.routingKey)}
+		if outcome.id == "" {
+			outcome.id = "#" + strconv.Itoa(n+1)
+		}
+		exchange, key, cc := r.exchange, r.routingKey, []string(nil)
+		origin := r.exchange == "" && r.routingKey == ""
+		if origin {
+			var keys []string
+			var ok bool
+			exchange, keys, ok = deathOrigin(deliveryMessage(d))
+			if ok {
+				key, cc = keys[0], keys[1:]
+				outcome.target = reprocessTarget(exchange, key)
+			} else {
+				outcome.result, outcome.target = "skipped", "no x-death"
+			}
+		}
+		if outcome.result == "" {
+			outcome.attempts, outcome.err = publish(exchange, key, deliveryPublishing(d, cc))
+			outcome.result = "republished"
+		}
+		if outcome.err != nil && r.stopOnError {
+			// Back to the head of the queue, with the batch before it
+			// acknowledged.
+			outcome.result = "failed"
+			r.record(outcome, j)
+			if err := ack(); err != nil {
+				return err
+			}
+			if err := in.Nack(d.DeliveryTag, false, true); err != nil {
+				return err
+			}
+			return fmt.Errorf("message %s: %w", outcome.id, outcome.err)
+		}
+		if outcome.err != nil || outcome.result == "skipped" {
+			if outcome.err != nil {
+				outcome.result = "put back"
+			}
+			if _, err := publish("", r.queue.Name, deliveryPublishing(d, nil)); err != nil {
+				// Not acknowledged, so it goes back to the queue with
+				// the batch when the connection closes.
+				r.record(outcome, j)
+				return fmt.Errorf("message %s could not be put back: %w", outcome.id, err)
+			}
+		}
+		r.record(outcome, j)
+		last = d.DeliveryTag
+		if pending++; pending == r.batch {
+			if err := ack(); err != nil {
+				return err
+			}
+		}
+	}
+	return ack()
+}
+
+// record keeps outcome and counts it in the job.
+func (r *reprocessor) record(outcome reprocessOutcome, j *job) {
+	outcome.at = time.Now()
+	r.mu.Lock()
+	r.outcomes = append(r.outcomes, outcome)
+	if len(r.outcomes) > reprocessOutcomes {
+		r.outcomes = r.outcomes[len(r.outcomes)-reprocessOutcomes:]
+	}
+	r.mu.Unlock()
+	j.update(func() {
+		j.done++
+		switch outcome.result {
+		case "skipped":
+			j.skipped++
+		case "put back", "failed":
+			j.failed++
+		}
+	})
+}
+
+func (r *reprocessor) setState(state string) {
+	r.mu.Lock()
+	r.state = state
+	r.mu.Unlock()
+	r.job.update(func() {})
+}
+
+func (r *reprocessor) isPaused() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.paused
+}
+
+// setPaused pauses r after the message it is on, acknowledging the ones
+// handled, or resumes it.
+func (r *reprocessor) setPaused(paused bool) {
+	r.mu.Lock()
+	if r.paused == paused {
+		r.mu.Unlock()
+		return
+	}
+	r.paused = paused
+	if paused {
+		r.resume = make(chan struct{})
+	} else {
+		close(r.resume)
+	}
+	r.mu.Unlock()
+	r.job.update(func() { r.job.paused = paused })
+}
+
+// waitResumed waits while r is paused, and reports false if it was
+// cancelled meanwhile.
+func (r *reprocessor) waitResumed(ctx context.Context) bool {
+	r.mu.Lock()
+	paused, resume := r.paused, r.resume
+	r.mu.Unlock()
+	if !paused {
+		return true
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-resume:
+		return true
+	}
+}
+
+// Feed handles a key while the reprocessor is shown, and reports whether
+// the view was closed; the job goes on.
+func (r *reprocessor) Feed(id string) (done bool) {
+	switch id {
+	case "<Escape>", "C":
+		return true
+	case "p":
+		r.setPaused(!r.isPaused())
+	case "X":
+		r.job.cancel()
+	case "j", "<Down>":
+		r.cursor++
+	case "k", "<Up>":
+		if r.cursor > 0 {
+			r.cursor--
+		}
+	}
+	return false
+}
+
+// finished reports whether r's job is over.
+func (r *reprocessor) finished() bool {
+	r.job.mu.Lock()
+	defer r.job.mu.Unlock()
+	return !r.job.finished.IsZero()
+}
+
+func (r *reprocessor) Render() {
+	width, height := termui.TerminalDimensions()
+	height -= statusBarHeight
+	detailHeight := 7
+
+	r.mu.Lock()
+	outcomes := make([]reprocessOutcome, len(r.outcomes))
+	// Newest first.
+	for i, o := range r.outcomes {
+		outcomes[len(r.outcomes)-1-i] = o
+	}
+	paused, state := r.paused, r.state
+	r.mu.Unlock()
+	r.job.mu.Lock()
+	done, total, failed, skipped, jobErr, finished := r.job.done, r.job.total, r.job.failed, r.job.skipped, r.job.err, r.job.finished
+	r.job.mu.Unlock()
+
+	status := "[running](fg:cyan)"
+	switch {
+	case !finished.IsZero() && jobErr != nil:
+		status = "[stopped: " + jobErr.Error() + "](fg:red)"
+	case !finished.IsZero():
+		status = "[done](fg:green)"
+	case paused:
+		status = "[paused](fg:yellow)"
+	case state != "":
+		status = "[" + state + "](fg:yellow)"
+	}
+	onError := "put back at the tail and go on"
+	if r.stopOnError {
+		onError = "stop, leaving it at the head"
+	}
+	r.detail.Title = " Reprocess " + r.queue.Key() + " · p pause/resume · X stop · Esc to close "
+	r.detail.Text = fmt.Sprintf("%s %s %d/%d · %d republished · %d failed · %d skipped\n", status, progressBar(done, total, 10), done, total, done-failed-skipped, failed, skipped) +
+		fmt.Sprintf("To %s at %g a second, started %s ago\n", reprocessTarget(r.exchange, r.routingKey), r.rate, time.Since(r.started).Round(time.Second)) +
+		fmt.Sprintf("Batches of %d acknowledged once handled; %d retries, backing off from %s; on error: %s", r.batch, r.retries, r.backoff, onError)
+
+	r.list.Title = fmt.Sprintf(" Outcomes · %d kept ", len(outcomes))
+	r.list.ColumnWidths = spreadWidths(width, 9, 24, 0, 9, 12)
+	rows := [][]string{{"[Time](fg:black,bg:yellow)", "[Message](fg:black,bg:yellow)", "[Target](fg:black,bg:yellow)", "[Attempts](fg:black,bg:yellow)", "[Outcome](fg:black,bg:yellow)"}}
+	r.list.RowStyles = map[int]termui.Style{}
+	if len(outcomes) == 0 {
+		rows = append(rows, []string{"", "", "No message handled yet.", "", ""})
+	}
+	if r.cursor >= len(outcomes) {
+		r.cursor = max(len(outcomes)-1, 0)
+	}
+	start := 0
+	if pageRows := height - detailHeight - 6; r.cursor >= pageRows {
+		start = r.cursor - pageRows + 1
+	}
+	for i, o := range outcomes[start:] {
+		if start+i == r.cursor {
+			r.list.RowStyles[i+1] = termui.NewStyle(termui.ColorWhite, termui.ColorBlue, termui.ModifierBold)
+		}
+		result := "[" + o.result + "](fg:green)"
+		switch o.result {
+		case "skipped":
+			result = "[skipped](fg:yellow)"
+		case "put back", "failed":
+			result = "[" + o.result + "](fg:red)"
+		}
+		attempts := ""
+		if o.attempts > 0 {
+			attempts = strconv.Itoa(o.attempts)
+		}
+		rows = append(rows, []string{o.at.Format("15:04:05"), o.id, o.target, attempts, result})
+	}
+	r.list.Rows = rows
+	if r.cursor < len(outcomes) && outcomes[r.cursor].err != nil {
+		r.detail.Text += "\n[" + outcomes[r.cursor].id + ": " + outcomes[r.cursor].err.Error() + "](fg:red)"
+	}
+
+	r.detail.SetRect(0, 3, width, 3+detailHeight)
+	r.list.SetRect(0, 3+detailHeight, width, height)
+	drawWidgets(r.detail, r.list)
+}
@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// RequeueConfig throttles republishing dead-lettered messages, so a large
+// dead-letter queue doesn't hit the consumers it is sent back to all at
+// once.
+type RequeueConfig struct {
+	RatePerSecond float64 `json:"rate_per_second"`
+}
+
+const defaultRequeueRate = 20
+
+// deathOrigin reads where a dead-lettered message was published before it
+// was last dead-lettered, from the newest entry of its x-death header: the
+// exchange and the routing keys, the first of them the message's own and
+// the rest its CC keys. Messages read over AMQP have the header's entries as
+// tables, those from the management API as JSON objects.
+func deathOrigin(m MessageInfo) (exchange string, routingKeys []string, ok bool) {
+	deaths, _ := m.Properties.Headers["x-death"].([]interface{})
+	if len(deaths) == 0 {
+		return "", nil, false
+	}
+	death, _ := deaths[0].(map[string]interface{})
+	if table, isTable := deaths[0].(amqp.Table); isTable {
+		death = table
+	}
+	exchange, ok = death["exchange"].(string)
+	keys, _ := death["routing-keys"].([]interface{})
+	for _, k := range keys {
+		if s, isString := k.(string); isString {
+			routingKeys = append(routingKeys, s)
+		}
+	}
+	return exchange, routingKeys, ok && len(routingKeys) > 0
+}
+
+// republishRequest publishes m again with its properties and payload
+// unchanged, to routingKey with the CC keys given.
+func republishRequest(m MessageInfo, routingKey string, cc []string) (publishRequest, error) {
+	data, err := json.Marshal(m.Properties)
+	if err != nil {
+		return publishRequest{}, err
+	}
+	properties := make(map[string]interface{})
+	if err := json.Unmarshal(data, &properties); err != nil {
+		return publishRequest{}, err
+	}
+	if len(cc) > 0 {
+		headers, _ := properties["headers"].(map[string]interface{})
+		if headers == nil {
+			headers = make(map[string]interface{})
+		}
+		headers["CC"] = cc
+		properties["headers"] = headers
+	}
+	encoding := m.PayloadEncoding
+	if encoding == "" {
+		encoding = "string"
+	}
+	return publishRequest{Properties: properties, RoutingKey: routingKey, Payload: m.Payload, PayloadEncoding: encoding}, nil
+}
+
+// requeueDeadLetters takes the ready messages of a dead-letter queue one at
+// a time and republishes each to the exchange and routing keys it was
+// dead-lettered from. A message without x-death, or that its origin no
+// longer routes anywhere, is put back at the tail of the queue; only when
+// that fails too does the job stop, naming the message it dropped. Only the
+// messages there at the start are handled, so put-back ones aren't retried.
+func requeueDeadLetters(ctx context.Context, config Config, q QueueInfo, j *job) error {
+	interval := time.Duration(float64(time.Second) / config.Requeue.RatePerSecond)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	putBack := func(m MessageInfo) error {
+		back, err := republishRequest(m, q.Name, nil)
+		if err == nil {
+			_, err = publishMessage(config, q.VHost, "", back)
+		}
+		if err != nil {
+			return fmt.Errorf("message %q was taken off the queue and could not be put back: %w", m.Properties.MessageID, err)
+		}
+		return nil
+	}
+
+	for n := 0; n < j.total; n++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		messages, err := getMessages(config, q, 1, "ack_requeue_false")
+		if err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+		m := messages[0]
+		exchange, keys, ok := deathOrigin(m)
+		if !ok {
+			if err := putBack(m); err != nil {
+				return err
+			}
+			j.update(func() { j.done++; j.skipped++ })
+			continue
+		}
+		req, err := republishRequest(m, keys[0], keys[1:])
+		routed := false
+		if err == nil {
+			routed, err = publishMessage(config, q.VHost, exchange, req)
+		}
+		if err != nil || !routed {
+			if err := putBack(m); err != nil {
+				return err
+			}
+			j.update(func() { j.done++; j.failed++ })
+			continue
+		}
+		j.update(func() { j.done++ })
+	}
+	return nil
+}
+
+// startRequeue republishes the ready messages of a dead-letter queue to
+// where they came from, as a job.
+func (d *dashboard) startRequeue(q QueueInfo) {
+	if q.MessagesReady == 0 {
+		d.setNotice("%s has no ready messages to requeue", q.Key())
+		return
+	}
+	config := d.config
+	d.startJob("Requeue "+q.Key(), q.MessagesReady, func(ctx context.Context, j *job) error {
+		return requeueDeadLetters(ctx, config, q, j)
+	})
+}
@@ -0,0 +1,181 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// retryTier is a delayed-retry queue: nothing consumes it, and its messages
+// wait out the TTL and are dead-lettered back to the queue they failed on.
+type retryTier struct {
+	queue QueueInfo
+	ttl   int64
+}
+
+// retryPipeline is a work queue with the retry queues that feed back into
+// it, shortest delay first. parking lists where the work queue's own dead
+// letters go when that is not one of its retry queues, such as a parking
+// lot for messages that ran out of retries.
+type retryPipeline struct {
+	queue   QueueInfo
+	tiers   []retryTier
+	parking []string
+}
+
+// retrying is how many messages are waiting in the retry queues.
+func (p retryPipeline) retrying() int {
+	n := 0
+	for _, t := range p.tiers {
+		n += t.queue.Messages
+	}
+	return n
+}
+
+// delays lists the tiers' TTLs, e.g. "5s → 30s → 5m".
+func (p retryPipeline) delays() string {
+	delays := make([]string, len(p.tiers))
+	for i, t := range p.tiers {
+		delays[i] = formatUptime(t.ttl)
+	}
+	return strings.Join(delays, " → ")
+}
+
+// retryPipelines finds TTL and dead-letter retry chains, such as
+// orders.retry.5s, .30s and .5m all expiring back into orders, and groups
+// them by the queue they retry into. A retry queue that expires into another
+// retry queue is followed to the end of the chain.
+func retryPipelines(queues []QueueInfo, exchanges []ExchangeInfo, bindings []BindingInfo) []retryPipeline {
+	routes := deadLetterRoutes(queues, exchanges, bindings)
+	byQueue := make(map[string]QueueInfo, len(queues))
+	for _, q := range queues {
+		byQueue[q.Key()] = q
+	}
+
+	// next is where each retry queue expires to.
+	next := make(map[string]string)
+	ttls := make(map[string]int64)
+	for _, r := range routes {
+		q := r.queue
+		ttl, _ := queueSetting(q, "message-ttl")
+		ms, ok := settingNumber(ttl)
+		if !ok || q.Consumers > 0 || r.problem != "" || len(r.targets) != 1 {
+			continue
+		}
+		target, ok := byQueue[q.VHost+"/"+r.targets[0]]
+		if !ok || target.Key() == q.Key() {
+			continue
+		}
+		next[q.Key()] = target.Key()
+		ttls[q.Key()] = int64(ms)
+	}
+
+	pipelines := make(map[string]*retryPipeline)
+	for key, target := range next {
+		seen := map[string]bool{key: true}
+		for {
+			further, ok := next[target]
+			if !ok || seen[target] {
+				break
+			}
+			seen[target] = true
+			target = further
+		}
+		if _, ok := next[target]; ok {
+			// A cycle of retry queues with no work queue.
+			continue
+		}
+		p, ok := pipelines[target]
+		if !ok {
+			p = &retryPipeline{queue: byQueue[target]}
+			pipelines[target] = p
+		}
+		p.tiers = append(p.tiers, retryTier{queue: byQueue[key], ttl: ttls[key]})
+	}
+
+	for _, r := range routes {
+		p, ok := pipelines[r.queue.Key()]
+		if !ok {
+			continue
+		}
+		for _, target := range r.targets {
+			if _, isTier := next[r.queue.VHost+"/"+target]; !isTier {
+				p.parking = append(p.parking, target)
+			}
+		}
+	}
+
+	result := make([]retryPipeline, 0, len(pipelines))
+	for _, p := range pipelines {
+		sort.Slice(p.tiers, func(i, j int) bool {
+			if p.tiers[i].ttl != p.tiers[j].ttl {
+				return p.tiers[i].ttl < p.tiers[j].ttl
+			}
+			return p.tiers[i].queue.Name < p.tiers[j].queue.Name
+		})
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if a, b := result[i].retrying(), result[j].retrying(); a != b {
+			return a > b
+		}
+		return result[i].queue.Key() < result[j].queue.Key()
+	})
+	return result
+}
+
+// retryText is the queue detail pane's line for a work queue with retry
+// queues, or "" when it has none.
+func retryText(q QueueInfo, pipelines []retryPipeline) string {
+	for _, p := range pipelines {
+		if p.queue.Key() != q.Key() {
+			continue
+		}
+		return fmt.Sprintf("Retrying: %s more in %d retry queues (%s), %s in all",
+			groupDigits(p.retrying()), len(p.tiers), p.delays(), groupDigits(q.Messages+p.retrying()))
+	}
+	return ""
+}
+
+// retriesView shows each work queue's retry pipeline with the messages
+// waiting in it, which the queue's own depth leaves out.
+type retriesView struct {
+	*listView
+}
+
+func newRetriesView() *retriesView {
+	v := &retriesView{}
+	v.listView = newListView("Retries",
+		[]string{"Queue", "Retry delays", "Queued", "Retrying", "Total", "Parking"},
+		func(width int) []int { return spreadWidths(width, width/4, 0, 9, 9, 9, width/5) },
+		v.rows)
+	return v
+}
+
+func (v *retriesView) rows(d *dashboard) ([]listRow, string) {
+	var rows []listRow
+	for _, p := range retryPipelines(d.queues, d.exchanges, d.bindings) {
+		retrying := p.retrying()
+		detail := p.queue.Name
+		for _, t := range p.tiers {
+			detail += fmt.Sprintf(" ⇄ %s (%s, %s)", t.queue.Name, formatUptime(t.ttl), groupDigits(t.queue.Messages))
+		}
+		if len(p.parking) > 0 {
+			detail += " → " + strings.Join(p.parking, ", ")
+		}
+		if retrying > p.queue.Messages {
+			detail += fmt.Sprintf("\n[Most of the backlog is waiting to be retried: %s of %s messages.](fg:yellow)",
+				groupDigits(retrying), groupDigits(p.queue.Messages+retrying))
+		}
+		rows = append(rows, listRow{
+			cells: []string{
+				p.queue.Key(), p.delays(),
+				colorizeNumber(p.queue.Messages), colorizeNumber(retrying), colorizeNumber(p.queue.Messages + retrying),
+				strings.Join(p.parking, ", "),
+			},
+			detail: detail,
+			key:    p.queue.Key(),
+		})
+	}
+	return rows, "No retry queues found: queues without consumers whose message TTL dead-letters them back to another queue."
+}
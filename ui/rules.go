@@ -0,0 +1,268 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// AlertRule raises an alert for every queue whose metric crosses a
+// threshold. Queue and VHost are globs matched against the queue name and
+// vhost; empty matches all queues. With Aggregate set the metric is summed
+// over the matching queues instead, once for the whole cluster ("cluster")
+// or once per vhost ("vhost"), so capacity problems spread over many queues
+// are caught too.
+type AlertRule struct {
+	Name      string   `json:"name"`
+	Queue     string   `json:"queue"`
+	VHost     string   `json:"vhost"`
+	Metric    string   `json:"metric"`
+	Aggregate string   `json:"aggregate"`
+	Above     *float64 `json:"above"`
+	Below     *float64 `json:"below"`
+	Severity  string   `json:"severity"`
+	Runbook   string   `json:"runbook"`
+}
+
+var ruleMetrics = map[string]func(q QueueInfo) float64{
+	"ready":        func(q QueueInfo) float64 { return float64(q.MessagesReady) },
+	"unacked":      func(q QueueInfo) float64 { return float64(q.MessagesUnack) },
+	"messages":     func(q QueueInfo) float64 { return float64(q.Messages) },
+	"consumers":    func(q QueueInfo) float64 { return float64(q.Consumers) },
+	"memory":       func(q QueueInfo) float64 { return float64(q.Memory) },
+	"publish_rate": func(q QueueInfo) float64 { return q.MessageStats.PublishDetails.Rate },
+	"deliver_rate": func(q QueueInfo) float64 { return q.MessageStats.DeliverGetDetails.Rate },
+	"ack_rate":     func(q QueueInfo) float64 { return q.MessageStats.AckDetails.Rate },
+}
+
+// aggregateMetrics are not properties of a queue, so they can only be used
+// in aggregate rules. Each returns the vhost of every object it counts.
+var aggregateMetrics = map[string]func(connections []ConnectionInfo, channels []ChannelInfo) []string{
+	"connections": func(connections []ConnectionInfo, _ []ChannelInfo) []string {
+		vhosts := make([]string, len(connections))
+		for i, c := range connections {
+			vhosts[i] = c.VHost
+		}
+		return vhosts
+	},
+	"channels": func(_ []ConnectionInfo, channels []ChannelInfo) []string {
+		vhosts := make([]string, len(channels))
+		for i, c := range channels {
+			vhosts[i] = c.VHost
+		}
+		return vhosts
+	},
+}
+
+// ruleVariable matches ${NAME} and ${NAME:-default}.
+var ruleVariable = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandRuleVariables substitutes variables in a rules file before it is
+// parsed, so thresholds can be written as bare ${VAR} numbers.
+func expandRuleVariables(text string, lookup func(string) (string, bool)) (string, error) {
+	var missing []string
+	expanded := ruleVariable.ReplaceAllStringFunc(text, func(m string) string {
+		parts := ruleVariable.FindStringSubmatch(m)
+		if value, ok := lookup(parts[1]); ok {
+			return value
+		}
+		if parts[2] != "" {
+			return parts[3]
+		}
+		missing = append(missing, parts[1])
+		return m
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("undefined variables: %s", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+func loadAlertRules(filename string, lookup func(string) (string, bool)) ([]AlertRule, error) {
+	if filename == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	text, err := expandRuleVariables(string(data), lookup)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	var rules []AlertRule
+	if err := json.Unmarshal([]byte(text), &rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("%s: rule %d has no name", filename, i+1)
+		}
+		switch rule.Aggregate {
+		case "", "cluster", "vhost":
+		default:
+			return nil, fmt.Errorf("%s: rule %q: unknown aggregate %q (known: cluster, vhost)", filename, rule.Name, rule.Aggregate)
+		}
+		if _, ok := aggregateMetrics[rule.Metric]; ok {
+			if rule.Aggregate == "" {
+				return nil, fmt.Errorf("%s: rule %q: metric %s needs an aggregate", filename, rule.Name, rule.Metric)
+			}
+			if rule.Queue != "" {
+				return nil, fmt.Errorf("%s: rule %q: metric %s does not count queues, so it takes no queue pattern", filename, rule.Name, rule.Metric)
+			}
+		} else if _, ok := ruleMetrics[rule.Metric]; !ok {
+			return nil, fmt.Errorf("%s: rule %q: unknown metric %q", filename, rule.Name, rule.Metric)
+		}
+		if rule.Above == nil && rule.Below == nil {
+			return nil, fmt.Errorf("%s: rule %q needs above or below", filename, rule.Name)
+		}
+		if _, err := path.Match(rule.Queue, ""); err != nil {
+			return nil, fmt.Errorf("%s: rule %q: bad queue pattern: %w", filename, rule.Name, err)
+		}
+		if _, err := path.Match(rule.VHost, ""); err != nil {
+			return nil, fmt.Errorf("%s: rule %q: bad vhost pattern: %w", filename, rule.Name, err)
+		}
+		switch rule.Severity {
+		case "", "warning", "critical":
+		default:
+			return nil, fmt.Errorf("%s: rule %q: unknown severity %q", filename, rule.Name, rule.Severity)
+		}
+	}
+	return rules, nil
+}
+
+// variableLookup resolves rule variables for one cluster: its own variables
+// first, then the environment, then alerts.variables as shared defaults.
+func (c Config) variableLookup(cluster ClusterConfig) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		if v, ok := cluster.Variables[name]; ok {
+			return v, true
+		}
+		if v, ok := os.LookupEnv(name); ok {
+			return v, true
+		}
+		v, ok := c.Alerts.Variables[name]
+		return v, ok
+	}
+}
+
+// crossed reports whether value is past one of the rule's thresholds, with
+// the threshold and which way it was crossed.
+func (rule AlertRule) crossed(value float64) (float64, string, bool) {
+	switch {
+	case rule.Above != nil && value > *rule.Above:
+		return *rule.Above, "above", true
+	case rule.Below != nil && value < *rule.Below:
+		return *rule.Below, "below", true
+	}
+	return 0, "", false
+}
+
+// matches reports whether the rule's patterns select an object of the given
+// vhost and name.
+func (rule AlertRule) matches(vhost, name string) bool {
+	if ok, _ := path.Match(rule.Queue, name); rule.Queue != "" && !ok {
+		return false
+	}
+	if ok, _ := path.Match(rule.VHost, vhost); rule.VHost != "" && !ok {
+		return false
+	}
+	return true
+}
+
+func (rule AlertRule) severity() AlertSeverity {
+	if rule.Severity == "critical" {
+		return SeverityCritical
+	}
+	return SeverityWarning
+}
+
+func ruleAlerts(rules []AlertRule, queues []QueueInfo, connections []ConnectionInfo, channels []ChannelInfo) []Alert {
+	var alerts []Alert
+	for _, rule := range rules {
+		if rule.Aggregate != "" {
+			alerts = append(alerts, aggregateAlerts(rule, queues, connections, channels)...)
+			continue
+		}
+		metric := ruleMetrics[rule.Metric]
+		for _, q := range queues {
+			if !rule.matches(q.VHost, q.Name) {
+				continue
+			}
+			value := metric(q)
+			threshold, direction, ok := rule.crossed(value)
+			if !ok {
+				continue
+			}
+			alerts = append(alerts, Alert{
+				Key:       "rule:" + rule.Name + ":" + q.Key(),
+				Severity:  rule.severity(),
+				Message:   fmt.Sprintf("%s: %s %s is %g, %s %g", rule.Name, q.Key(), rule.Metric, value, direction, threshold),
+				VHost:     q.VHost,
+				Queue:     q.Name,
+				Value:     value,
+				Threshold: threshold,
+				Runbook:   rule.Runbook,
+			})
+		}
+	}
+	return alerts
+}
+
+// aggregateAlerts evaluates an aggregate rule. A cluster total is always
+// checked, even with nothing to count, so below rules fire at zero; vhost
+// totals only for the vhosts that have something matching.
+func aggregateAlerts(rule AlertRule, queues []QueueInfo, connections []ConnectionInfo, channels []ChannelInfo) []Alert {
+	totals := make(map[string]float64)
+	group := func(vhost string) string {
+		if rule.Aggregate == "vhost" {
+			return vhost
+		}
+		return ""
+	}
+	if rule.Aggregate == "cluster" {
+		totals[""] = 0
+	}
+	if count, ok := aggregateMetrics[rule.Metric]; ok {
+		for _, vhost := range count(connections, channels) {
+			if rule.matches(vhost, "") {
+				totals[group(vhost)]++
+			}
+		}
+	} else {
+		metric := ruleMetrics[rule.Metric]
+		for _, q := range queues {
+			if rule.matches(q.VHost, q.Name) {
+				totals[group(q.VHost)] += metric(q)
+			}
+		}
+	}
+
+	var alerts []Alert
+	for vhost, value := range totals {
+		threshold, direction, ok := rule.crossed(value)
+		if !ok {
+			continue
+		}
+		key, where := "rule:"+rule.Name, ""
+		if rule.Aggregate == "vhost" {
+			key += ":" + vhost
+			where = " in vhost " + vhost
+		}
+		alerts = append(alerts, Alert{
+			Key:       key,
+			Severity:  rule.severity(),
+			Message:   fmt.Sprintf("%s: total %s%s is %g, %s %g", rule.Name, rule.Metric, where, value, direction, threshold),
+			VHost:     vhost,
+			Value:     value,
+			Threshold: threshold,
+			Runbook:   rule.Runbook,
+		})
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].Key < alerts[j].Key })
+	return alerts
+}
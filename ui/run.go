@@ -0,0 +1,263 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Options selects how Run presents the dashboard.
+type Options struct {
+	// ConfigFile is the JSON configuration to load. When empty, it is looked
+	// for in $XDG_CONFIG_HOME/rabbitspy and then the current directory.
+	ConfigFile string
+	// Overrides are the settings given on the command line, applied over
+	// the configuration.
+	Overrides Overrides
+	// Plain prints a plain-text summary every interval instead of the
+	// interactive UI.
+	Plain bool
+	// Wallboard shows the large-type, auto-cycling display for wall screens
+	// and reconnects forever.
+	Wallboard bool
+	// Renderer picks the interactive UI: "termui" (the default) or
+	// "bubbletea".
+	Renderer string
+	// Headless runs without a UI and serves the status, Prometheus metrics
+	// and a health check over HTTP at Listen instead, for containers.
+	Headless bool
+	Listen   string
+	// PprofListen serves the Go pprof profiles at this address when set. It
+	// shares the annotation webhook's server if both use the same address.
+	PprofListen string
+	// ASCII draws the termui pages without box-drawing, block, braille or
+	// arrow characters; see LegacyConsole.
+	ASCII bool
+	// ReadOnly disables every action that changes the broker, whatever the
+	// configuration says.
+	ReadOnly bool
+}
+
+// session is what every frontend works from: the polled dashboards and the
+// annotations arriving from the webhook.
+type session struct {
+	config     Config
+	dashboards []*dashboard
+	annotated  chan clusterAnnotation
+	// redraw is signalled by background jobs when their progress changes.
+	redraw    chan struct{}
+	wallboard bool
+	// ascii draws the termui pages in ASCII; see Options.ASCII.
+	ascii bool
+}
+
+// fetched is a dashboard's poll, fetched but not applied yet.
+type fetched struct {
+	d *dashboard
+	r pollResult
+}
+
+// fetchAll fetches every dashboard at once and sends each poll as soon as it
+// is done. The receiver applies them, so the dashboards are only ever
+// changed from the UI's goroutine.
+func (s *session) fetchAll() <-chan fetched {
+	out := make(chan fetched, len(s.dashboards))
+	for _, d := range s.dashboards {
+		go func() { out <- fetched{d, d.fetch()} }()
+	}
+	return out
+}
+
+// pollAll polls every dashboard, fetching them concurrently.
+func (s *session) pollAll() {
+	results := s.fetchAll()
+	for range s.dashboards {
+		f := <-results
+		f.d.apply(f.r)
+	}
+}
+
+// annotate records an annotation received by the webhook on the clusters it
+// names.
+func (s *session) annotate(a clusterAnnotation) {
+	for _, d := range s.dashboards {
+		if a.cluster == "" || a.cluster == d.name {
+			d.annotations.Add(a.annotation)
+		}
+	}
+}
+
+// frontend is an interactive UI over a session. Frontends only draw and
+// handle keys; polling, alerting and the data model are shared.
+type frontend interface {
+	Run(ctx context.Context, s *session) error
+}
+
+var frontends = map[string]frontend{
+	"termui":    termuiFrontend{},
+	"bubbletea": bubbleteaFrontend{},
+}
+
+// Run starts the dashboard as the rabbitspy command does and blocks until
+// the user quits or ctx is done. It owns the terminal while the interactive
+// UI is running.
+func Run(ctx context.Context, opts Options) error {
+	if opts.Plain && opts.Wallboard {
+		return errors.New("plain and wallboard mode cannot be combined")
+	}
+	if opts.Headless && (opts.Plain || opts.Wallboard) {
+		return errors.New("headless mode cannot be combined with plain or wallboard mode")
+	}
+	if opts.Renderer == "" {
+		opts.Renderer = "termui"
+	}
+	renderer, ok := frontends[opts.Renderer]
+	if !ok {
+		return fmt.Errorf("unknown renderer %q (known: termui, bubbletea)", opts.Renderer)
+	}
+	if opts.Wallboard && opts.Renderer != "termui" {
+		return errors.New("wallboard mode needs the termui renderer")
+	}
+
+	config, err := LoadConfigOverrides(opts.ConfigFile, opts.Overrides)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration file: %w", err)
+	}
+	config.ReadOnly = config.ReadOnly || opts.ReadOnly
+
+	var baselines *baselineStore
+	if config.Alerts.Baselines.File != "" {
+		if baselines, err = loadBaselines(config.Alerts.Baselines); err != nil {
+			return fmt.Errorf("failed to load baselines: %w", err)
+		}
+	}
+
+	var dashboards []*dashboard
+	for _, cluster := range config.ClusterConfigs() {
+		webhooks, err := newWebhookNotifiers(config, cluster)
+		if err != nil {
+			return err
+		}
+		notifiers := webhooks
+		if !opts.Headless {
+			notifiers = append([]Notifier{soundNotifier{}}, webhooks...)
+		}
+		d := newDashboard(cluster.Name, config.forCluster(cluster), notifiers...)
+		d.baselines = baselines
+		if d.rules, err = loadAlertRules(config.Alerts.RulesFile, config.variableLookup(cluster)); err != nil {
+			return fmt.Errorf("failed to load alert rules for cluster %s: %w", cluster.Name, err)
+		}
+		if opts.Wallboard || opts.Headless {
+			go d.amqp.KeepConnected(ctx, 5*time.Second)
+		} else if err := d.amqp.Dial(); err != nil {
+			return fmt.Errorf("failed to connect to RabbitMQ cluster %s: %w", cluster.Name, err)
+		}
+		defer d.amqp.Close()
+		defer d.alerts.Close()
+		dashboards = append(dashboards, d)
+	}
+
+	// The HTTP endpoints by listen address, so the webhook and the profiles
+	// can share one server.
+	servers := make(map[string]*http.ServeMux)
+	serverFor := func(addr string) *http.ServeMux {
+		if servers[addr] == nil {
+			servers[addr] = http.NewServeMux()
+		}
+		return servers[addr]
+	}
+	if opts.PprofListen != "" {
+		addPprof(serverFor(opts.PprofListen))
+	}
+
+	if opts.Plain {
+		stop, err := serve(servers)
+		if err != nil {
+			return err
+		}
+		defer stop()
+		runPlain(ctx, os.Stdout, dashboards, time.Duration(config.UI.RefreshSeconds)*time.Second)
+		return nil
+	}
+
+	s := &session{config: config, dashboards: dashboards, annotated: make(chan clusterAnnotation), redraw: make(chan struct{}, 1), wallboard: opts.Wallboard, ascii: opts.ASCII}
+	for _, d := range dashboards {
+		d.redraw = s.redraw
+	}
+	if config.Annotations.Listen != "" {
+		names := make([]string, len(dashboards))
+		for i, d := range dashboards {
+			names[i] = d.name
+		}
+		serverFor(config.Annotations.Listen).Handle("/annotations", annotationHandler(names, config.Annotations.Token, s.annotated))
+	}
+
+	if opts.Headless {
+		h := &headless{dashboards: dashboards}
+		if opts.Listen == "" {
+			opts.Listen = defaultHeadlessListen
+		}
+		h.routes(serverFor(opts.Listen))
+		stop, err := serve(servers)
+		if err != nil {
+			return err
+		}
+		defer stop()
+		log.Printf("Running headless, serving /api/status, /metrics and /healthz on %s", opts.Listen)
+		h.run(ctx, s, time.Duration(config.UI.RefreshSeconds)*time.Second)
+		return nil
+	}
+	stop, err := serve(servers)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	return renderer.Run(ctx, s)
+}
+
+// serverShutdownTimeout bounds how long the HTTP servers wait for requests
+// in flight when Run returns.
+const serverShutdownTimeout = 5 * time.Second
+
+// serve binds every listen address, then serves each in the background and
+// returns a function shutting them down, so a Run that returns leaves no
+// listener behind and the next one can bind the same addresses. An address
+// that cannot be bound, such as a port already taken, is an error rather
+// than a server that silently never answers.
+func serve(servers map[string]*http.ServeMux) (stop func(), err error) {
+	listeners := make(map[string]net.Listener, len(servers))
+	for addr := range servers {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("cannot listen on %s: %w", addr, err)
+		}
+		listeners[addr] = l
+	}
+
+	var running []*http.Server
+	for addr, l := range listeners {
+		srv := &http.Server{Addr: addr, Handler: servers[addr]}
+		running = append(running, srv)
+		go func() {
+			if err := srv.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("HTTP server on %s stopped: %s", addr, err)
+			}
+		}()
+	}
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+		defer cancel()
+		for _, srv := range running {
+			srv.Shutdown(ctx)
+		}
+	}, nil
+}
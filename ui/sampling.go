@@ -0,0 +1,304 @@
+package ui
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SamplingConfig sets how many messages a sample takes and how they are
+// checked. Every rule whose queue pattern matches is applied.
+type SamplingConfig struct {
+	Count int          `json:"count"`
+	Rules []SampleRule `json:"rules"`
+}
+
+// SampleRule is a set of checks for messages of the queues matching Queue,
+// a glob; empty matches every queue. JSONSchema names a schema file the
+// payloads must satisfy.
+type SampleRule struct {
+	Queue           string   `json:"queue"`
+	ContentType     string   `json:"content_type"`
+	RequiredHeaders []string `json:"required_headers"`
+	JSONSchema      string   `json:"json_schema"`
+}
+
+const defaultSampleCount = 20
+
+// getMessages fetches count messages from the head of q in ackMode, such as
+// ack_requeue_true to put them back. Requeued messages are marked
+// redelivered, and count as a delivery attempt towards a quorum queue's
+// delivery limit.
+func getMessages(config Config, q QueueInfo, count int, ackMode string) ([]MessageInfo, error) {
+	if config.ReadOnly && !requeues(ackMode) {
+		return nil, errReadOnly
+	}
+	body := map[string]interface{}{"count": count, "ackmode": ackMode, "encoding": "auto"}
+	var messages []MessageInfo
+	get := func() error { return apiRequest(config, "POST", queuePath(q)+"/get", body, &messages) }
+	if requeues(ackMode) {
+		return messages, get()
+	}
+	// The messages leave the queue.
+	if err := audited(config, "get messages", q.Key(), fmt.Sprintf("%d, %s", count, ackMode), get); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// messagePayload decodes a message's payload from the encoding the
+// management API sent it in.
+func messagePayload(m MessageInfo) ([]byte, error) {
+	if m.PayloadEncoding == "base64" {
+		return base64.StdEncoding.DecodeString(m.Payload)
+	}
+	return []byte(m.Payload), nil
+}
+
+// sampleCheck is a rule ready to be applied, with its schema loaded.
+type sampleCheck struct {
+	rule   SampleRule
+	schema interface{}
+}
+
+func loadSampleChecks(rules []SampleRule, queue string) ([]sampleCheck, error) {
+	var checks []sampleCheck
+	for _, rule := range rules {
+		if ok, err := path.Match(rule.Queue, queue); err != nil {
+			return nil, fmt.Errorf("sampling rule %q: bad queue pattern: %w", rule.Queue, err)
+		} else if rule.Queue != "" && !ok {
+			continue
+		}
+		check := sampleCheck{rule: rule}
+		if rule.JSONSchema != "" {
+			data, err := os.ReadFile(rule.JSONSchema)
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(data, &check.schema); err != nil {
+				return nil, fmt.Errorf("%s: %w", rule.JSONSchema, err)
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// problems lists what is wrong with one message under the check. Values
+// quoted from the payload are taken from its masked copy.
+func (c sampleCheck) problems(m MessageInfo, masker *payloadMasker) []string {
+	var problems []string
+	if c.rule.ContentType != "" && m.Properties.ContentType != c.rule.ContentType {
+		problems = append(problems, fmt.Sprintf("content type %q, want %q", m.Properties.ContentType, c.rule.ContentType))
+	}
+	for _, h := range c.rule.RequiredHeaders {
+		if _, ok := m.Properties.Headers[h]; !ok {
+			problems = append(problems, "missing header "+h)
+		}
+	}
+	if c.schema != nil {
+		payload, err := messagePayload(m)
+		if err != nil {
+			return append(problems, "undecodable payload: "+err.Error())
+		}
+		var value interface{}
+		if err := json.Unmarshal(payload, &value); err != nil {
+			return append(problems, "payload is not JSON: "+err.Error())
+		}
+		var shown interface{}
+		if err := json.Unmarshal(masker.Mask(payload), &shown); err != nil {
+			shown = value
+		}
+		problems = append(problems, schemaProblems(c.schema, value, shown, "$")...)
+	}
+	return problems
+}
+
+// schemaProblems checks value against the part of JSON Schema that catches
+// malformed messages: type, enum, required, properties,
+// additionalProperties: false, items, minLength/maxLength, pattern and
+// minimum/maximum. Other keywords are ignored. shown is value as it may be
+// displayed, with masked fields replaced, and is what problems quote.
+func schemaProblems(schema interface{}, value, shown interface{}, at string) []string {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if want, ok := s["type"]; ok && !schemaTypeMatches(want, value) {
+		return []string{fmt.Sprintf("%s: %s, want %v", at, jsonType(value), want)}
+	}
+	var problems []string
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if fmt.Sprint(e) == fmt.Sprint(value) {
+				found = true
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s: %v is not one of %v", at, shown, enum))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if required, ok := s["required"].([]interface{}); ok {
+			for _, name := range required {
+				if _, ok := v[fmt.Sprint(name)]; !ok {
+					problems = append(problems, fmt.Sprintf("%s: missing %s", at, name))
+				}
+			}
+		}
+		properties, _ := s["properties"].(map[string]interface{})
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if sub, ok := properties[name]; ok {
+				problems = append(problems, schemaProblems(sub, v[name], shownField(shown, name), at+"."+name)...)
+			} else if s["additionalProperties"] == false {
+				problems = append(problems, fmt.Sprintf("%s: unexpected %s", at, name))
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			problems = append(problems, schemaProblems(s["items"], item, shownItem(shown, i), fmt.Sprintf("%s[%d]", at, i))...)
+		}
+	case string:
+		if n, ok := s["minLength"].(float64); ok && float64(len([]rune(v))) < n {
+			problems = append(problems, fmt.Sprintf("%s: shorter than %g", at, n))
+		}
+		if n, ok := s["maxLength"].(float64); ok && float64(len([]rune(v))) > n {
+			problems = append(problems, fmt.Sprintf("%s: longer than %g", at, n))
+		}
+		if p, ok := s["pattern"].(string); ok {
+			if re, err := regexp.Compile(p); err == nil && !re.MatchString(v) {
+				problems = append(problems, fmt.Sprintf("%s: does not match %s", at, p))
+			}
+		}
+	case float64:
+		if n, ok := s["minimum"].(float64); ok && v < n {
+			problems = append(problems, fmt.Sprintf("%s: %v is below %g", at, shown, n))
+		}
+		if n, ok := s["maximum"].(float64); ok && v > n {
+			problems = append(problems, fmt.Sprintf("%s: %v is above %g", at, shown, n))
+		}
+	}
+	return problems
+}
+
+// shownField and shownItem follow the masked copy of a payload down with
+// the original. A masked subtree is a single value, which stands for
+// everything below it.
+func shownField(shown interface{}, name string) interface{} {
+	if m, ok := shown.(map[string]interface{}); ok {
+		return m[name]
+	}
+	return shown
+}
+
+func shownItem(shown interface{}, i int) interface{} {
+	if items, ok := shown.([]interface{}); ok && i < len(items) {
+		return items[i]
+	}
+	return shown
+}
+
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// schemaTypeMatches reports whether value has the type, or one of the
+// types, a schema asks for. Integers are numbers too.
+func schemaTypeMatches(want interface{}, value interface{}) bool {
+	types, ok := want.([]interface{})
+	if !ok {
+		types = []interface{}{want}
+	}
+	got := jsonType(value)
+	for _, t := range types {
+		if t == got || (t == "number" && got == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// SampleQueue takes a sample of the queue vhost/name on the named cluster,
+// or the only one, checks it against the sampling rules and writes a
+// report to out. It fails when any sampled message has a problem, so it can
+// gate a deployment.
+func SampleQueue(config Config, cluster, vhost, name string, count int, out io.Writer) error {
+	config, err := pickCluster(config, cluster)
+	if err != nil {
+		return err
+	}
+	if count <= 0 {
+		count = config.Sampling.Count
+	}
+
+	checks, err := loadSampleChecks(config.Sampling.Rules, name)
+	if err != nil {
+		return err
+	}
+	if len(checks) == 0 {
+		return fmt.Errorf("no sampling rule matches queue %s", name)
+	}
+	q := QueueInfo{VHost: vhost, Name: name}
+	messages, err := getMessages(config, q, count, "ack_requeue_true")
+	if err != nil {
+		return fmt.Errorf("sampling %s: %w", q.Key(), err)
+	}
+	if len(messages) == 0 {
+		fmt.Fprintf(out, "%s is empty, nothing to check\n", q.Key())
+		return nil
+	}
+
+	masker, _ := newPayloadMasker(config.Privacy.MaskPaths)
+	failed := 0
+	for i, m := range messages {
+		var problems []string
+		for _, c := range checks {
+			problems = append(problems, c.problems(m, masker)...)
+		}
+		if len(problems) == 0 {
+			continue
+		}
+		failed++
+		payload, _ := messagePayload(m)
+		excerpt := []rune(string(masker.Mask(payload)))
+		if len(excerpt) > 200 {
+			excerpt = append(excerpt[:197], []rune("...")...)
+		}
+		fmt.Fprintf(out, "message %d (routing key %q, id %q): %s\n  payload: %s\n",
+			i+1, m.RoutingKey, m.Properties.MessageID, strings.Join(problems, "; "), string(excerpt))
+	}
+	fmt.Fprintf(out, "%s: %d of %d sampled messages failed (%.0f%%)\n", q.Key(), failed, len(messages), 100*float64(failed)/float64(len(messages)))
+	if failed > 0 {
+		return fmt.Errorf("%d malformed messages in %s", failed, q.Key())
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+type searchHit struct {
+	kind   string
+	name   string
+	detail string
+	// queue is the key of the queue to jump to, if the hit has one.
+	queue string
+	// consumer is set on consumer hits, for x.
+	consumer *ConsumerInfo
+}
+
+// searchDashboard matches term case-insensitively against queues,
+// exchanges, connections, channels and consumer tags. Queues come from the
+// last poll; the rest are fetched on demand since most of them are not
+// polled.
+func searchDashboard(d *dashboard, term string) []searchHit {
+	term = strings.ToLower(strings.TrimSpace(term))
+	if term == "" {
+		return nil
+	}
+	match := func(fields ...string) bool {
+		for _, f := range fields {
+			if strings.Contains(strings.ToLower(f), term) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var hits []searchHit
+	for _, q := range d.queues {
+		if match(q.Name, q.Node) {
+			hits = append(hits, searchHit{kind: "queue", name: q.Key(), detail: fmt.Sprintf("%d ready, %d consumers", q.MessagesReady, q.Consumers), queue: q.Key()})
+		}
+	}
+
+	if exchanges, err := getExchanges(d.config); err != nil {
+		log.Printf("Error listing exchanges: %s", err)
+	} else {
+		for _, e := range exchanges {
+			if match(e.Name) {
+				hits = append(hits, searchHit{kind: "exchange", name: e.Key(), detail: e.Type})
+			}
+		}
+	}
+
+	if connections, err := getConnections(d.config); err != nil {
+		log.Printf("Error listing connections: %s", err)
+	} else {
+		for _, c := range connections {
+			if match(c.Name, c.User, c.PeerHost) {
+				detail := fmt.Sprintf("user %s, %d channels", c.User, c.Channels)
+				if c.State == "flow" {
+					detail = flowIndicator + " " + detail
+				}
+				hits = append(hits, searchHit{kind: "connection", name: c.Name, detail: detail})
+			}
+		}
+	}
+
+	if channels, err := getChannels(d.config); err != nil {
+		log.Printf("Error listing channels: %s", err)
+	} else {
+		for _, c := range channels {
+			if match(c.Name, c.User, c.ConnectionDetails.PeerHost) {
+				detail := fmt.Sprintf("user %s, %d consumers", c.User, c.Consumers)
+				if c.State == "flow" {
+					detail = flowIndicator + " " + detail
+				}
+				hits = append(hits, searchHit{kind: "channel", name: c.Name, detail: detail})
+			}
+		}
+	}
+
+	if consumers, err := getConsumers(d.config); err != nil {
+		log.Printf("Error listing consumers: %s", err)
+	} else {
+		for i, c := range consumers {
+			if match(c.ConsumerTag, c.ChannelDetails.PeerHost, c.ChannelDetails.User) {
+				queue := c.Queue.VHost + "/" + c.Queue.Name
+				hits = append(hits, searchHit{kind: "consumer", name: c.ConsumerTag, detail: "on " + queue + " from " + c.ChannelDetails.PeerHost, queue: queue, consumer: &consumers[i]})
+			}
+		}
+	}
+	return hits
+}
+
+// searchView prompts for a term, lists matches from every object type and
+// jumps to the queue table for hits that belong to a queue.
+type searchView struct {
+	prompt *widgets.Paragraph
+	table  *widgets.Table
+	status *statusBar
+
+	input   lineInput
+	last    string
+	typing  bool
+	pending bool
+	hits    []searchHit
+	cursor  int
+	offset  int
+	// form is the dialog closing a consumer's connection, if open.
+	form *form
+
+	// leave is called with a queue key to jump to it, or with "" to go back
+	// to the previous page.
+	leave func(queue string)
+}
+
+func newSearchView(leave func(queue string)) *searchView {
+	prompt := widgets.NewParagraph()
+	prompt.Title = " Search "
+	prompt.BorderStyle = termui.NewStyle(termui.ColorCyan)
+
+	table := widgets.NewTable()
+	table.TextStyle = termui.NewStyle(termui.ColorWhite)
+	table.TextAlignment = termui.AlignLeft
+	table.BorderStyle = termui.NewStyle(termui.ColorCyan)
+	table.RowSeparator = false
+	table.FillRow = true
+
+	return &searchView{prompt: prompt, table: table, status: newStatusBar(), leave: leave}
+}
+
+// Start clears the previous term and begins reading a new one.
+func (v *searchView) Start() {
+	v.last = v.input.value
+	v.input = lineInput{}
+	v.typing = true
+}
+
+func (v *searchView) Capturing() bool {
+	return v.typing || v.form != nil
+}
+
+func (v *searchView) Render(d *dashboard, ui uiState) {
+	if v.pending {
+		v.hits = searchDashboard(d, v.input.value)
+		v.cursor, v.offset = 0, 0
+		v.pending = false
+	}
+
+	width, height := termui.TerminalDimensions()
+
+	if v.typing {
+		v.prompt.Text = fmt.Sprintf("/%s_", v.input.value)
+	} else {
+		v.prompt.Text = fmt.Sprintf("/%s  [%d matches · Enter to jump, x to close a consumer's connection, / to search again, Esc to go back](fg:white)", v.input.value, len(v.hits))
+	}
+
+	nameWidth := width / 2
+	v.table.ColumnWidths = []int{12, nameWidth, width - nameWidth - 16}
+	rows := [][]string{{"[Kind](fg:black,bg:yellow)", "[Name](fg:black,bg:yellow)", "[Details](fg:black,bg:yellow)"}}
+	v.table.RowStyles = make(map[int]termui.Style)
+
+	// One line per row here, below the header and the borders.
+	pageRows := height - statusBarHeight - 3 - 3
+	if pageRows < 1 {
+		pageRows = 1
+	}
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+pageRows {
+		v.offset = v.cursor - pageRows + 1
+	}
+	end := v.offset + pageRows
+	if end > len(v.hits) {
+		end = len(v.hits)
+	}
+	for i, hit := range v.hits[v.offset:end] {
+		if v.offset+i == v.cursor && !v.typing {
+			v.table.RowStyles[i+1] = termui.NewStyle(termui.ColorWhite, termui.ColorBlue, termui.ModifierBold)
+		}
+		rows = append(rows, []string{hit.kind, hit.name, hit.detail})
+	}
+	if len(v.hits) == 0 && !v.typing {
+		rows = append(rows, []string{"", "No matches.", ""})
+	}
+	v.table.Rows = rows
+	v.table.Title = " " + d.name + " "
+
+	termui.Clear()
+	v.prompt.SetRect(0, 0, width, 3)
+	v.table.SetRect(0, 3, width, height-statusBarHeight)
+	drawWidgets(append([]termui.Drawable{v.prompt, v.table}, v.status.Layout(d, ui, width, height)...)...)
+	if v.form != nil {
+		v.form.Render()
+	}
+}
+
+func (v *searchView) HandleKey(d *dashboard, id string) bool {
+	if v.form != nil {
+		if v.form.Feed(id) {
+			v.form = nil
+		}
+		return true
+	}
+	if v.typing {
+		done, cancelled := v.input.Feed(id)
+		if done {
+			v.typing = false
+			switch {
+			case !cancelled:
+				v.pending = true
+			case len(v.hits) == 0:
+				v.leave("")
+			default:
+				v.input.value = v.last
+			}
+		}
+		return true
+	}
+
+	switch id {
+	case "<Escape>":
+		v.leave("")
+	case "j", "<Down>":
+		if v.cursor < len(v.hits)-1 {
+			v.cursor++
+		}
+	case "k", "<Up>":
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case "<Enter>":
+		if v.cursor < len(v.hits) && v.hits[v.cursor].queue != "" {
+			v.leave(v.hits[v.cursor].queue)
+		}
+	case "x":
+		if v.cursor < len(v.hits) && v.hits[v.cursor].consumer != nil && !d.readOnly("closing connections") {
+			v.form = d.closeConsumerForm(*v.hits[v.cursor].consumer)
+		}
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+)
+
+// SlowConsumerConfig sets when a queue is tagged as falling behind: its
+// deliver rate stayed below Ratio times its publish rate in every sample of
+// the last WindowSeconds.
+type SlowConsumerConfig struct {
+	WindowSeconds int     `json:"window_seconds"`
+	Ratio         float64 `json:"ratio"`
+}
+
+const (
+	defaultSlowConsumerWindow = 300
+	defaultSlowConsumerRatio  = 0.5
+)
+
+// slowConsumer is a queue whose consumers have been falling behind since
+// since, at the given average rates over the window.
+type slowConsumer struct {
+	publishRate float64
+	deliverRate float64
+	since       time.Time
+}
+
+func (s slowConsumer) String() string {
+	return fmt.Sprintf("deliver %.1f/s vs publish %.1f/s for %s", s.deliverRate, s.publishRate, time.Since(s.since).Round(time.Second))
+}
+
+// fallingBehind reports whether deliveries kept up with less than ratio of
+// the publish rate over the whole window. A queue needs history covering
+// the window before it can be judged, so a single slow sample never tags it.
+func fallingBehind(samples []queueSample, window time.Duration, ratio float64) (slowConsumer, bool) {
+	if len(samples) == 0 {
+		return slowConsumer{}, false
+	}
+	start := samples[len(samples)-1].at.Add(-window)
+	if samples[0].at.After(start) {
+		return slowConsumer{}, false
+	}
+	slow := func(s queueSample) bool {
+		return s.publishRate > 0 && s.deliverRate < ratio*s.publishRate
+	}
+
+	var result slowConsumer
+	n := 0
+	i := len(samples) - 1
+	for ; i >= 0 && !samples[i].at.Before(start); i-- {
+		if !slow(samples[i]) {
+			return slowConsumer{}, false
+		}
+		result.publishRate += samples[i].publishRate
+		result.deliverRate += samples[i].deliverRate
+		n++
+	}
+	result.publishRate /= float64(n)
+	result.deliverRate /= float64(n)
+	// Look further back for when it started.
+	for i >= 0 && slow(samples[i]) {
+		i--
+	}
+	result.since = samples[i+1].at
+	return result, true
+}
+
+// slowConsumers finds the queues falling behind, keyed like QueueInfo.Key.
+// Streams are left out: their messages stay after delivery, so consumers
+// are judged by their offset lag instead.
+func slowConsumers(history *queueHistory, queues []QueueInfo, config SlowConsumerConfig) map[string]slowConsumer {
+	window := time.Duration(config.WindowSeconds) * time.Second
+	behind := make(map[string]slowConsumer)
+	for _, q := range queues {
+		if q.Type == "stream" {
+			continue
+		}
+		if s, ok := fallingBehind(history.Samples(q.Key()), window, config.Ratio); ok {
+			behind[q.Key()] = s
+		}
+	}
+	return behind
+}
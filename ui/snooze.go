@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// SnoozeConfig sets how long z snoozes a queue for when no other duration
+// is typed.
+type SnoozeConfig struct {
+	DefaultMinutes int `json:"default_minutes"`
+}
+
+const defaultSnoozeMinutes = 60
+
+// snoozes are the queues, by key, hidden from the queue table and left out
+// of alerting until the time each wakes up.
+type snoozes map[string]time.Time
+
+// expire forgets the snoozes that are over at now and returns their keys.
+func (s snoozes) expire(now time.Time) []string {
+	var woken []string
+	for key, until := range s {
+		if !now.Before(until) {
+			delete(s, key)
+			woken = append(woken, key)
+		}
+	}
+	return woken
+}
+
+// watching leaves the snoozed queues out.
+func (s snoozes) watching(queues []QueueInfo) []QueueInfo {
+	if len(s) == 0 {
+		return queues
+	}
+	watched := make([]QueueInfo, 0, len(queues))
+	for _, q := range queues {
+		if _, ok := s[q.Key()]; !ok {
+			watched = append(watched, q)
+		}
+	}
+	return watched
+}
+
+// quiet leaves out the alerts about snoozed queues.
+func (s snoozes) quiet(alerts []Alert) []Alert {
+	if len(s) == 0 {
+		return alerts
+	}
+	kept := alerts[:0]
+	for _, alert := range alerts {
+		if _, ok := s[alert.VHost+"/"+alert.Queue]; alert.Queue != "" && ok {
+			continue
+		}
+		kept = append(kept, alert)
+	}
+	return kept
+}
+
+// snoozeForm asks how long to snooze a queue for.
+func (d *dashboard) snoozeForm(q QueueInfo) *form {
+	return newForm("Snooze "+q.Key(), []*formField{
+		{label: "For", hint: "such as 30m or 4h", input: lineInput{value: strconv.Itoa(d.config.Snooze.DefaultMinutes) + "m"}},
+	}, func(values map[string]string) error {
+		length, err := time.ParseDuration(values["For"])
+		if err != nil || length <= 0 {
+			return fmt.Errorf("the duration must be positive, such as 30m or 4h")
+		}
+		until := time.Now().Add(length)
+		d.snoozed[q.Key()] = until
+		d.setNotice("Snoozed %s until %s", q.Key(), until.Format("Jan 2 15:04"))
+		return nil
+	})
+}
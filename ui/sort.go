@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// sortKey orders queues by one field, descending when desc is set.
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+func (k sortKey) String() string {
+	if k.desc {
+		return k.field + " ↓"
+	}
+	return k.field + " ↑"
+}
+
+// queueSortFields compare two queues by one field, ascending. The order is
+// the one o cycles through.
+var queueSortFields = []struct {
+	id      string
+	compare func(a, b QueueInfo) int
+}{
+	{"name", func(a, b QueueInfo) int { return strings.Compare(a.Name, b.Name) }},
+	{"vhost", func(a, b QueueInfo) int { return strings.Compare(a.VHost, b.VHost) }},
+	{"type", func(a, b QueueInfo) int { return strings.Compare(a.Type, b.Type) }},
+	{"state", func(a, b QueueInfo) int { return strings.Compare(queueState(a), queueState(b)) }},
+	{"node", func(a, b QueueInfo) int { return strings.Compare(a.Node, b.Node) }},
+	{"ready", func(a, b QueueInfo) int { return cmp.Compare(a.MessagesReady, b.MessagesReady) }},
+	{"unacked", func(a, b QueueInfo) int { return cmp.Compare(a.MessagesUnack, b.MessagesUnack) }},
+	{"total", func(a, b QueueInfo) int { return cmp.Compare(a.Messages, b.Messages) }},
+	{"consumers", func(a, b QueueInfo) int { return cmp.Compare(a.Consumers, b.Consumers) }},
+	{"memory", func(a, b QueueInfo) int { return cmp.Compare(a.Memory, b.Memory) }},
+	{"publish_rate", func(a, b QueueInfo) int {
+		return cmp.Compare(a.MessageStats.PublishDetails.Rate, b.MessageStats.PublishDetails.Rate)
+	}},
+	{"deliver_rate", func(a, b QueueInfo) int {
+		return cmp.Compare(a.MessageStats.DeliverGetDetails.Rate, b.MessageStats.DeliverGetDetails.Rate)
+	}},
+	{"ack_rate", func(a, b QueueInfo) int {
+		return cmp.Compare(a.MessageStats.AckDetails.Rate, b.MessageStats.AckDetails.Rate)
+	}},
+}
+
+func sortFieldIndex(id string) int {
+	for i, f := range queueSortFields {
+		if f.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseSortKeys reads ui.sort entries such as "vhost" or "-ready"; a leading
+// "-" sorts descending.
+func parseSortKeys(specs []string) ([]sortKey, error) {
+	keys := make([]sortKey, 0, len(specs))
+	for _, spec := range specs {
+		key := sortKey{field: strings.TrimPrefix(spec, "-"), desc: strings.HasPrefix(spec, "-")}
+		if sortFieldIndex(key.field) < 0 {
+			ids := make([]string, len(queueSortFields))
+			for i, f := range queueSortFields {
+				ids[i] = f.id
+			}
+			return nil, fmt.Errorf("unknown sort field %q (known: %s)", key.field, strings.Join(ids, ", "))
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// sortQueues orders queues by keys, then by vhost and name, so that queues
+// that compare equal keep the same order from one refresh to the next.
+func sortQueues(keys []sortKey, queues []QueueInfo) []QueueInfo {
+	sorted := append([]QueueInfo(nil), queues...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		for _, key := range keys {
+			c := queueSortFields[sortFieldIndex(key.field)].compare(sorted[i], sorted[j])
+			if key.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return sorted[i].Key() < sorted[j].Key()
+	})
+	return sorted
+}
+
+// sortDescription lists keys for a table title.
+func sortDescription(keys []sortKey) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// maxSortKeys is how many keys m can stack up.
+const maxSortKeys = 3
+
+// nextSortField makes the primary key sort by the next field, skipping the
+// ones already used by secondary keys.
+func nextSortField(keys []sortKey) []sortKey {
+	if len(keys) == 0 {
+		return []sortKey{{field: queueSortFields[0].id}}
+	}
+	used := make(map[string]bool, len(keys))
+	for _, key := range keys[1:] {
+		used[key.field] = true
+	}
+	i := sortFieldIndex(keys[0].field)
+	for n := 0; n < len(queueSortFields); n++ {
+		i = (i + 1) % len(queueSortFields)
+		if !used[queueSortFields[i].id] {
+			break
+		}
+	}
+	keys = append([]sortKey(nil), keys...)
+	keys[0] = sortKey{field: queueSortFields[i].id}
+	return keys
+}
+
+// pushSortKey keeps the current keys as secondary ones and adds a new
+// primary key in front of them.
+func pushSortKey(keys []sortKey) []sortKey {
+	if len(keys) >= maxSortKeys {
+		return keys
+	}
+	keys = append([]sortKey{{}}, keys...)
+	if len(keys) > 1 {
+		keys[0] = keys[1]
+	}
+	return nextSortField(keys)
+}
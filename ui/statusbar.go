@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// statusBar is the last-updated line and alert line shown at the bottom of
+// every interactive view.
+type statusBar struct {
+	updateTime  *widgets.Paragraph
+	alertWidget *widgets.Paragraph
+}
+
+const statusBarHeight = 6
+
+func newStatusBar() *statusBar {
+	updateTime := widgets.NewParagraph()
+	updateTime.Text = "Last updated: N/A"
+	updateTime.BorderStyle = termui.NewStyle(termui.ColorYellow)
+
+	alertWidget := widgets.NewParagraph()
+	alertWidget.Text = ""
+	alertWidget.BorderStyle = termui.NewStyle(termui.ColorRed)
+
+	return &statusBar{updateTime: updateTime, alertWidget: alertWidget}
+}
+
+// Layout fills the bar for d and places it along the bottom of a
+// width x height screen.
+func (b *statusBar) Layout(d *dashboard, ui uiState, width, height int) []termui.Drawable {
+	b.updateTime.Text = "Last updated: N/A"
+	if d.lastUpdate.IsZero() && d.lastErr == nil {
+		b.updateTime.Text = "Loading..."
+	}
+	if !d.lastUpdate.IsZero() {
+		b.updateTime.Text = fmt.Sprintf("Last updated: %s", d.lastUpdate.Format("2006-01-02 15:04:05"))
+	}
+	b.updateTime.Text += fmt.Sprintf(" (every %s)", ui.interval)
+	if ui.paused {
+		b.updateTime.Text += "  [ PAUSED ](fg:black,bg:red,mod:bold)"
+	}
+	if readOnly := readOnlySummary(d); readOnly != "" {
+		b.updateTime.Text += "  " + readOnly
+	}
+	if flow := flowSummary(d); flow != "" {
+		b.updateTime.Text += "  " + flow
+	}
+	if firehose := tracingSummary(d); firehose != "" {
+		b.updateTime.Text += "  " + firehose
+	}
+	if jobs := jobSummary(d); jobs != "" {
+		b.updateTime.Text += "  " + jobs
+	}
+	if d.notice != "" && time.Now().Before(d.noticeUntil) {
+		b.updateTime.Text += "  " + d.notice
+	}
+	if d.lastErr != nil {
+		b.updateTime.Text += fmt.Sprintf("  [%s](fg:red)", d.lastErr)
+	}
+
+	renderAlerts(b.alertWidget, d.activeAlerts)
+
+	b.updateTime.SetRect(0, height-6, width, height-3)
+	b.alertWidget.SetRect(0, height-3, width, height)
+	return []termui.Drawable{b.updateTime, b.alertWidget}
+}
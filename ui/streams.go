@@ -0,0 +1,292 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/genc-murat/rabbitspy/management"
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// latencySamples caps each publisher's latency series; at the default
+// refresh interval that is ten minutes.
+const latencySamples = 120
+
+// confirmLatency estimates how long a stream publisher currently waits for
+// confirms: its outstanding messages divided by its confirm rate. The API
+// reports no latencies, so this is the best available proxy. It is unknown
+// while messages are outstanding but nothing is being confirmed.
+func confirmLatency(p StreamPublisher) (time.Duration, bool) {
+	outstanding := p.Published - p.Confirmed - p.Errored
+	if outstanding <= 0 {
+		return 0, true
+	}
+	if p.ConfirmedDetails.Rate <= 0 {
+		return 0, false
+	}
+	return time.Duration(float64(outstanding) / p.ConfirmedDetails.Rate * float64(time.Second)), true
+}
+
+func publisherKey(p StreamPublisher) string {
+	return fmt.Sprintf("%s#%d", p.ConnectionDetails.Name, p.PublisherID)
+}
+
+// latencyTracker keeps recent confirm latency estimates per publisher so
+// percentiles can be shown rather than a single noisy value.
+type latencyTracker struct {
+	samples map[string][]time.Duration
+}
+
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{samples: make(map[string][]time.Duration)}
+}
+
+func (t *latencyTracker) Record(publishers []StreamPublisher) {
+	seen := make(map[string]bool, len(publishers))
+	for _, p := range publishers {
+		key := publisherKey(p)
+		seen[key] = true
+		latency, ok := confirmLatency(p)
+		if !ok {
+			continue
+		}
+		samples := append(t.samples[key], latency)
+		if len(samples) > latencySamples {
+			samples = samples[len(samples)-latencySamples:]
+		}
+		t.samples[key] = samples
+	}
+	for key := range t.samples {
+		if !seen[key] {
+			delete(t.samples, key)
+		}
+	}
+}
+
+// percentile returns the nearest-rank p-th percentile (0-100) of samples.
+func percentile(samples []time.Duration, p float64) time.Duration {
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+func formatLatency(d time.Duration) string {
+	if d >= time.Second {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+}
+
+// streamName leaves out the default vhost, which most streams live in.
+func streamName(q management.QueueRef) string {
+	if q.VHost == "/" {
+		return q.Name
+	}
+	return q.VHost + "/" + q.Name
+}
+
+func hasStreams(queues []QueueInfo) bool {
+	for _, q := range queues {
+		if q.Type == "stream" {
+			return true
+		}
+	}
+	return false
+}
+
+// streamRetention describes how long a stream keeps messages: its maximum
+// age, its maximum size and the size of its segments, which is how much it
+// is truncated by at a time.
+func streamRetention(q QueueInfo) string {
+	var parts []string
+	if v, ok := queueSetting(q, "max-age"); ok {
+		parts = append(parts, "max age "+fmt.Sprint(v))
+	}
+	if v, ok := queueSetting(q, "max-length-bytes"); ok {
+		if n, ok := settingNumber(v); ok {
+			parts = append(parts, "max "+formatBytes(int64(n)))
+		}
+	}
+	if v, ok := queueSetting(q, "stream-max-segment-size-bytes"); ok {
+		if n, ok := settingNumber(v); ok {
+			parts = append(parts, formatBytes(int64(n))+" segments")
+		}
+	}
+	if len(parts) == 0 {
+		return "unlimited"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// streamOffset is a stream's committed offset, or "-" before the broker
+// reports one.
+func streamOffset(q QueueInfo) string {
+	if q.CommittedOffset == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d", *q.CommittedOffset)
+}
+
+// streamDetail is the detail pane's stream section. Streams keep messages
+// after they are consumed, so ready and unacked say nothing about them.
+func streamDetail(q QueueInfo) string {
+	return fmt.Sprintf("\nOffset:    %s committed\nSegments:  %d\nRetention: %s\nSize:      %s",
+		streamOffset(q), q.Segments, streamRetention(q), formatBytes(q.MessageBytes))
+}
+
+// streamsView shows the streams with their committed offset and segments,
+// stream publishers with confirm latency percentiles and stream consumer
+// groups with their offset lag, most lagging first.
+type streamsView struct {
+	summary    *widgets.Paragraph
+	streams    *widgets.Table
+	publishers *widgets.Table
+	consumers  *widgets.Table
+	status     *statusBar
+}
+
+func newStreamsView() *streamsView {
+	summary := widgets.NewParagraph()
+	summary.BorderStyle = termui.NewStyle(termui.ColorCyan)
+	table := func() *widgets.Table {
+		t := widgets.NewTable()
+		t.TextStyle = termui.NewStyle(termui.ColorWhite)
+		t.TextAlignment = termui.AlignLeft
+		t.BorderStyle = termui.NewStyle(termui.ColorCyan)
+		t.RowSeparator = false
+		return t
+	}
+	return &streamsView{
+		summary:    summary,
+		streams:    table(),
+		publishers: table(),
+		consumers:  table(),
+		status:     newStatusBar(),
+	}
+}
+
+func yellowHeader(names ...string) []string {
+	header := make([]string, len(names))
+	for i, name := range names {
+		header[i] = fmt.Sprintf("[%s](fg:black,bg:yellow)", name)
+	}
+	return header
+}
+
+func (v *streamsView) Render(d *dashboard, ui uiState) {
+	width, height := termui.TerminalDimensions()
+	v.summary.Title = " " + d.name + " · Streams "
+	v.summary.Text = clusterSummary(d.queues, d.overview)
+
+	// The management API reports segments but not chunks, the unit a
+	// stream is written, replicated and read in, so segments are as fine
+	// as the storage view gets.
+	v.streams.ColumnWidths = spreadWidths(width, 0, 0, 9, 12, 9, 10, 9, 0)
+	streamRows := [][]string{yellowHeader("Stream", "Leader", "Online", "Offset", "Segments", "Size", "Pub/s", "Retention")}
+	var streams int
+	for _, q := range d.queues {
+		if q.Type != "stream" {
+			continue
+		}
+		streams++
+		online := fmt.Sprintf("%d/%d", len(q.Online), len(q.Members))
+		if len(q.Online) < len(q.Members) {
+			online = fmt.Sprintf("[%s](fg:red)", online)
+		}
+		streamRows = append(streamRows, []string{
+			streamName(management.QueueRef{Name: q.Name, VHost: q.VHost}), q.Leader, online, streamOffset(q), fmt.Sprintf("%d", q.Segments),
+			formatBytes(q.MessageBytes), fmt.Sprintf("%.1f", q.MessageStats.PublishDetails.Rate), streamRetention(q),
+		})
+	}
+
+	v.publishers.ColumnWidths = spreadWidths(width, 0, 0, 0, 9, 9, 11, 9, 9, 9)
+	pubRows := [][]string{yellowHeader("Stream", "Publisher", "Connection", "Pub/s", "Conf/s", "Outstanding", "p50", "p95", "p99")}
+	for _, p := range d.streamPublishers {
+		name := p.Reference
+		if name == "" {
+			name = fmt.Sprintf("#%d", p.PublisherID)
+		}
+		p50, p95, p99 := "-", "-", "-"
+		if samples := d.latencies.samples[publisherKey(p)]; len(samples) > 0 {
+			p50 = formatLatency(percentile(samples, 50))
+			p95 = formatLatency(percentile(samples, 95))
+			p99 = formatLatency(percentile(samples, 99))
+		}
+		pubRows = append(pubRows, []string{
+			streamName(p.Queue), name, p.ConnectionDetails.PeerHost,
+			fmt.Sprintf("%.1f", p.PublishedDetails.Rate), fmt.Sprintf("%.1f", p.ConfirmedDetails.Rate),
+			fmt.Sprintf("%d", p.Published-p.Confirmed-p.Errored), p50, p95, p99,
+		})
+	}
+
+	publishRates := make(map[string]float64)
+	for _, q := range d.queues {
+		publishRates[q.Key()] = q.MessageStats.PublishDetails.Rate
+	}
+	groups := consumerGroups(d.streamConsumers, d.queues)
+	v.consumers.ColumnWidths = spreadWidths(width, 0, 0, 9, 0, 12, 12, 11, 0)
+	conRows := [][]string{yellowHeader("Stream", "Group", "Active", "Connection", "Offset", "Lag", "Consumed/s", "Trend")}
+	for _, g := range groups {
+		name, connection := g.name, g.members[0].ConnectionDetails.PeerHost
+		if name == "" {
+			name = fmt.Sprintf("#%d", g.members[0].SubscriptionID)
+		}
+		for _, c := range g.members {
+			if c.Active {
+				connection = c.ConnectionDetails.PeerHost
+				break
+			}
+		}
+		conRows = append(conRows, []string{
+			streamName(g.stream), name, fmt.Sprintf("%d/%d", g.active, len(g.members)), connection,
+			fmt.Sprintf("%d", g.offset), colorizeNumber(int(g.lag)), fmt.Sprintf("%.1f", g.rate),
+			g.lagTrend(publishRates[streamKey(g.stream)]),
+		})
+	}
+
+	// The tables are too narrow per column for a message row, so empty
+	// states go in the titles.
+	v.streams.Title = " Streams · committed offset and segment files "
+	v.publishers.Title = " Publishers · confirm latency estimated from outstanding / confirm rate "
+	v.consumers.Title = " Consumer groups · lag of the active members "
+	switch {
+	case d.streamsMissing:
+		v.streams.Title = ""
+		v.publishers.Title = " The stream plugin is not enabled. "
+		v.consumers.Title = ""
+	case len(d.streamPublishers) == 0:
+		v.publishers.Title = " No stream publishers. "
+	}
+	if streams == 0 && !d.streamsMissing {
+		v.streams.Title = " No streams. "
+	}
+	if len(groups) == 0 && !d.streamsMissing {
+		v.consumers.Title = " No stream consumers. "
+	}
+	v.streams.Rows = streamRows
+	v.publishers.Rows = pubRows
+	v.consumers.Rows = conRows
+
+	termui.Clear()
+	third := (height - statusBarHeight - 3) / 3
+	v.summary.SetRect(0, 0, width, 3)
+	v.streams.SetRect(0, 3, width, 3+third)
+	v.publishers.SetRect(0, 3+third, width, 3+2*third)
+	v.consumers.SetRect(0, 3+2*third, width, height-statusBarHeight)
+	drawables := []termui.Drawable{v.summary, v.streams, v.publishers, v.consumers}
+	drawWidgets(append(drawables, v.status.Layout(d, ui, width, height)...)...)
+}
+
+func (v *streamsView) HandleKey(d *dashboard, id string) bool {
+	return false
+}
@@ -0,0 +1,185 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/gizak/termui/v3"
+)
+
+// termuiFrontend is the original interactive UI, with every page.
+type termuiFrontend struct{}
+
+func (termuiFrontend) Run(ctx context.Context, s *session) error {
+	dashboards := s.dashboards
+	queues := newQueueView(s.config)
+	pages := []view{
+		queues, lazy(newOverviewView), lazy(newPoliciesView), lazy(newShovelsView), lazy(newFederationView),
+		lazy(newTopologyView), lazy(newStreamsView), lazy(newDeadLetterView), lazy(newBaselineView),
+		lazy(newDistributionView), lazy(newExchangesView), lazy(newUnroutableView), lazy(newBlockedView),
+		lazy(newGlobalCountersView), lazy(newRetriesView), lazy(newConnectionsView), lazy(newChannelsView),
+		lazy(newUsersView),
+	}
+	var current, previous view
+	search := newSearchView(func(queue string) {
+		current = previous
+		if queue != "" {
+			queues.focusQueue(queue)
+			current = queues
+		}
+	})
+	var rotate <-chan time.Time
+	if s.wallboard {
+		wv, err := newWallboardView(s.config, dashboards)
+		if err != nil {
+			return fmt.Errorf("invalid wallboard rotation: %w", err)
+		}
+		pages = []view{wv}
+		rotateTicker := time.NewTicker(time.Second)
+		defer rotateTicker.Stop()
+		rotate = rotateTicker.C
+	}
+
+	if err := termui.Init(); err != nil {
+		return fmt.Errorf("failed to initialize termui: %w", err)
+	}
+	defer termui.Close()
+	asciiMode = s.ascii
+	defer func() { asciiMode = false }()
+
+	current = pages[0]
+	focused := 0
+	ui := uiState{interval: time.Duration(s.config.UI.RefreshSeconds) * time.Second}
+	pollAll := s.pollAll
+	banner := newWarningBanner()
+	render := func() {
+		current.Render(dashboards[focused], ui)
+		banner.Render(dashboards[focused])
+	}
+	// Draw straight away and fill in each cluster as its first poll
+	// arrives, instead of waiting for the slowest one.
+	render()
+	firstPoll, pending := s.fetchAll(), len(dashboards)
+
+	uiEvents := termui.PollEvents()
+	ticker := time.NewTicker(ui.interval)
+	defer ticker.Stop()
+	flashOff := time.NewTimer(flashDuration)
+	defer flashOff.Stop()
+	// The Windows console reports its buffer size, not the window's, and
+	// only when the buffer changes, so the window is measured there instead.
+	var resizeCheck <-chan time.Time
+	width, height := termui.TerminalDimensions()
+	if runtime.GOOS == "windows" {
+		resizeTicker := time.NewTicker(resizeCheckInterval)
+		defer resizeTicker.Stop()
+		resizeCheck = resizeTicker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e := <-uiEvents:
+			if c, ok := current.(inputCapturer); ok && c.Capturing() {
+				if current.HandleKey(dashboards[focused], e.ID) {
+					render()
+				}
+				continue
+			}
+			switch e.ID {
+			case "q", "<C-c>":
+				return nil
+			case "p":
+				ui.paused = !ui.paused
+				render()
+			case "r":
+				if firstPoll != nil {
+					continue
+				}
+				pollAll()
+				render()
+				flashOff.Reset(flashDuration)
+			case "+", "-":
+				dir := 1
+				if e.ID == "-" {
+					dir = -1
+				}
+				ui.interval = stepRefresh(ui.interval, dir)
+				ticker.Reset(ui.interval)
+				render()
+			case "c":
+				focused = (focused + 1) % len(dashboards)
+				render()
+			case "<Tab>":
+				page := 0
+				for i, p := range pages {
+					if p == current {
+						page = (i + 1) % len(pages)
+					}
+				}
+				current = pages[page]
+				render()
+			case "/":
+				if s.wallboard {
+					continue
+				}
+				if current != search {
+					previous = current
+					current = search
+				}
+				search.Start()
+				render()
+			case "<Resize>":
+				render()
+			case "1", "2", "3", "4", "5", "6", "7", "8", "9", "0":
+				// 0 is the tenth page, as on the keyboard row.
+				n := int(e.ID[0] - '1')
+				if e.ID == "0" {
+					n = 9
+				}
+				if n < len(pages) {
+					current = pages[n]
+					render()
+				}
+			default:
+				if current.HandleKey(dashboards[focused], e.ID) {
+					render()
+				}
+			}
+		case f := <-firstPoll:
+			f.d.apply(f.r)
+			if pending--; pending == 0 {
+				firstPoll = nil
+			}
+			render()
+		case <-ticker.C:
+			if ui.paused || firstPoll != nil {
+				continue
+			}
+			pollAll()
+			render()
+			flashOff.Reset(flashDuration)
+		case <-flashOff.C:
+			if !ui.paused {
+				render()
+			}
+		case a := <-s.annotated:
+			s.annotate(a)
+			render()
+		case <-s.redraw:
+			render()
+		case <-resizeCheck:
+			if w, h := termui.TerminalDimensions(); w != width || h != height {
+				width, height = w, h
+				render()
+			}
+		case <-rotate:
+			if !ui.paused {
+				render()
+			}
+		}
+	}
+}
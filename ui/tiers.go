@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/gizak/termui/v3"
+)
+
+// TierConfig assigns queues whose name matches one of Patterns (path.Match
+// globs) to an SLA tier. Tiers are listed most important first: queues are
+// shown in tier order, and a queue belongs to the first tier it matches.
+type TierConfig struct {
+	Name     string   `json:"name"`
+	Patterns []string `json:"patterns"`
+	Color    string   `json:"color"`
+	Bold     bool     `json:"bold"`
+}
+
+// tierColors are the colors a tier can be drawn in.
+var tierColors = map[string]termui.Color{
+	"red":     termui.ColorRed,
+	"green":   termui.ColorGreen,
+	"yellow":  termui.ColorYellow,
+	"blue":    termui.ColorBlue,
+	"magenta": termui.ColorMagenta,
+	"cyan":    termui.ColorCyan,
+	"white":   termui.ColorWhite,
+	"gray":    termui.Color(8),
+}
+
+func validateTiers(tiers []TierConfig) error {
+	for i, tier := range tiers {
+		if tier.Name == "" {
+			return fmt.Errorf("tier %d has no name", i+1)
+		}
+		if len(tier.Patterns) == 0 {
+			return fmt.Errorf("tier %s has no patterns", tier.Name)
+		}
+		for _, pattern := range tier.Patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("tier %s: invalid pattern %q", tier.Name, pattern)
+			}
+		}
+		if _, ok := tierColors[tier.Color]; tier.Color != "" && !ok {
+			return fmt.Errorf("tier %s: unknown color %q", tier.Name, tier.Color)
+		}
+	}
+	return nil
+}
+
+// queueTier returns the index of the tier q belongs to, or len(tiers) when
+// it matches none.
+func queueTier(tiers []TierConfig, q QueueInfo) int {
+	for i, tier := range tiers {
+		for _, pattern := range tier.Patterns {
+			if ok, _ := path.Match(pattern, q.Name); ok {
+				return i
+			}
+		}
+	}
+	return len(tiers)
+}
+
+// sortByTier orders queues by tier, keeping their order within a tier.
+func sortByTier(tiers []TierConfig, queues []QueueInfo) []QueueInfo {
+	if len(tiers) == 0 {
+		return queues
+	}
+	sorted := append([]QueueInfo(nil), queues...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return queueTier(tiers, sorted[i]) < queueTier(tiers, sorted[j])
+	})
+	return sorted
+}
+
+// style is how rows of the tier are drawn. ok is false when the tier has
+// neither a color nor bold set and rows keep the table's style.
+func (t TierConfig) style() (termui.Style, bool) {
+	if t.Color == "" && !t.Bold {
+		return termui.Style{}, false
+	}
+	style := termui.NewStyle(termui.ColorWhite)
+	if t.Color != "" {
+		style.Fg = tierColors[t.Color]
+	}
+	if t.Bold {
+		style.Modifier = termui.ModifierBold
+	}
+	return style, true
+}
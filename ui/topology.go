@@ -0,0 +1,241 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/genc-murat/rabbitspy/management"
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+type treeLabel string
+
+func (l treeLabel) String() string { return string(l) }
+
+func queueDefinitionLabel(q management.QueueDefinition) string {
+	label := "queue " + q.Name
+	if q.Durable {
+		label += " · durable"
+	}
+	if q.AutoDelete {
+		label += " · auto-delete"
+	}
+	if len(q.Arguments) > 0 {
+		label += " · " + formatDefinition(q.Arguments)
+	}
+	return label
+}
+
+// definitionsTree arranges the exported topology as vhosts → exchanges →
+// bindings → destinations. Queues that nothing is bound to are grouped at
+// the end of their vhost. Definitions leave out the default exchange and
+// the amq.* ones, so those are added for the bindings that come from them.
+func definitionsTree(defs Definitions) []*widgets.TreeNode {
+	queues := make(map[string]management.QueueDefinition)
+	for _, q := range defs.Queues {
+		queues[q.VHost+"/"+q.Name] = q
+	}
+	bound := make(map[string]bool)
+
+	vhosts := append([]management.VHost(nil), defs.VHosts...)
+	sort.Slice(vhosts, func(i, j int) bool { return vhosts[i].Name < vhosts[j].Name })
+	exchanges := append([]management.ExchangeDefinition(nil), defs.Exchanges...)
+	defined := make(map[string]bool)
+	for _, e := range exchanges {
+		defined[e.VHost+"/"+e.Name] = true
+	}
+	for _, b := range defs.Bindings {
+		if key := b.VHost + "/" + b.Source; !defined[key] {
+			defined[key] = true
+			exchanges = append(exchanges, management.ExchangeDefinition{Name: b.Source, VHost: b.VHost, Type: "built in"})
+		}
+	}
+	sort.Slice(exchanges, func(i, j int) bool { return exchanges[i].Name < exchanges[j].Name })
+
+	var roots []*widgets.TreeNode
+	for _, vhost := range vhosts {
+		root := &widgets.TreeNode{Value: treeLabel("vhost " + vhost.Name), Expanded: true}
+		for _, e := range exchanges {
+			if e.VHost != vhost.Name {
+				continue
+			}
+			name := e.Name
+			if name == "" {
+				name = "(AMQP default)"
+			}
+			exchange := &widgets.TreeNode{Value: treeLabel(fmt.Sprintf("exchange %s (%s)", name, e.Type))}
+			for _, b := range defs.Bindings {
+				if b.VHost != vhost.Name || b.Source != e.Name {
+					continue
+				}
+				binding := &widgets.TreeNode{Value: treeLabel(fmt.Sprintf("binding [%s] → %s %s", b.RoutingKey, b.DestinationType, b.Destination))}
+				if b.DestinationType == "queue" {
+					key := b.VHost + "/" + b.Destination
+					bound[key] = true
+					if q, ok := queues[key]; ok {
+						binding.Nodes = append(binding.Nodes, &widgets.TreeNode{Value: treeLabel(queueDefinitionLabel(q))})
+					}
+				}
+				exchange.Nodes = append(exchange.Nodes, binding)
+			}
+			root.Nodes = append(root.Nodes, exchange)
+		}
+
+		unbound := &widgets.TreeNode{Value: treeLabel("queues without bindings")}
+		for _, q := range defs.Queues {
+			if q.VHost == vhost.Name && !bound[q.VHost+"/"+q.Name] {
+				unbound.Nodes = append(unbound.Nodes, &widgets.TreeNode{Value: treeLabel(queueDefinitionLabel(q))})
+			}
+		}
+		if len(unbound.Nodes) > 0 {
+			root.Nodes = append(root.Nodes, unbound)
+		}
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+// topologyView is a navigable tree of the broker's definitions. They are
+// fetched when the page is first shown for a cluster and on request, not on
+// every poll, since exports of large brokers are big.
+type topologyView struct {
+	summary *widgets.Paragraph
+	tree    *widgets.Tree
+	status  *statusBar
+
+	loadedFor *dashboard
+	// form is the export or import dialog, if open, and confirm an import
+	// waiting for its file name while its changes are shown in the tree.
+	form    *form
+	confirm *typedConfirmation
+}
+
+func newTopologyView() *topologyView {
+	summary := widgets.NewParagraph()
+	summary.BorderStyle = termui.NewStyle(termui.ColorCyan)
+
+	tree := widgets.NewTree()
+	tree.TextStyle = termui.NewStyle(termui.ColorWhite)
+	tree.SelectedRowStyle = termui.NewStyle(termui.ColorWhite, termui.ColorBlue, termui.ModifierBold)
+	tree.BorderStyle = termui.NewStyle(termui.ColorCyan)
+	tree.WrapText = false
+
+	return &topologyView{summary: summary, tree: tree, status: newStatusBar()}
+}
+
+func (v *topologyView) load(d *dashboard) {
+	v.loadedFor = d
+	defs, err := getDefinitions(d.config)
+	if err != nil {
+		v.tree.Title = ""
+		v.tree.SetNodes([]*widgets.TreeNode{{Value: treeLabel("Could not fetch definitions: " + err.Error())}})
+		return
+	}
+	v.tree.Title = fmt.Sprintf(" %d vhosts, %d exchanges, %d queues, %d bindings ",
+		len(defs.VHosts), len(defs.Exchanges), len(defs.Queues), len(defs.Bindings))
+	v.tree.SetNodes(definitionsTree(defs))
+}
+
+func (v *topologyView) Render(d *dashboard, ui uiState) {
+	if v.loadedFor != d {
+		v.load(d)
+	}
+	width, height := termui.TerminalDimensions()
+	termui.Clear()
+
+	v.summary.Title = " " + d.name + " · Topology "
+	v.summary.Text = "Enter/o expand or collapse · E expand all · C collapse all · u reload · W export to a file · I import a file"
+	if v.confirm != nil {
+		v.summary.Text = v.confirm.Prompt()
+	}
+	v.summary.SetRect(0, 0, width, 3)
+	v.tree.SetRect(0, 3, width, height-statusBarHeight)
+
+	drawables := []termui.Drawable{v.summary, v.tree}
+	drawWidgets(append(drawables, v.status.Layout(d, ui, width, height)...)...)
+	if v.form != nil {
+		v.form.Render()
+	}
+}
+
+func (v *topologyView) HandleKey(d *dashboard, id string) bool {
+	if v.form != nil {
+		if v.form.Feed(id) {
+			v.form = nil
+		}
+		return true
+	}
+	if v.confirm != nil {
+		if done, confirmed := v.confirm.Feed(id); done {
+			if !confirmed {
+				d.setNotice("%s of %s cancelled", v.confirm.action, v.confirm.name)
+			}
+			v.confirm = nil
+			v.load(d)
+		}
+		return true
+	}
+	switch id {
+	case "j", "<Down>":
+		v.tree.ScrollDown()
+	case "k", "<Up>":
+		v.tree.ScrollUp()
+	case "<Enter>", "o":
+		v.tree.ToggleExpand()
+	case "E":
+		v.tree.ExpandAll()
+	case "C":
+		v.tree.CollapseAll()
+	case "u":
+		v.load(d)
+	case "W":
+		v.form = d.exportForm()
+	case "I":
+		v.form = d.importForm(func(path, vhost string, data []byte, changes []definitionChange) {
+			v.showChanges(path, changes)
+			if d.readOnly("importing") {
+				return
+			}
+			v.confirm = d.confirm("import", &typedConfirmation{
+				action:   "Import",
+				name:     filepath.Base(path),
+				question: fmt.Sprintf("Import %s into %s: %s?", path, d.name, changeCounts(changes)),
+				run:      func() { d.startImport(path, vhost, data, changes) },
+			})
+			if v.confirm == nil {
+				v.load(d)
+			}
+		})
+	default:
+		return false
+	}
+	return true
+}
+
+// showChanges replaces the tree with what an import would do, by kind of
+// object, until it is confirmed or cancelled.
+func (v *topologyView) showChanges(path string, changes []definitionChange) {
+	var nodes []*widgets.TreeNode
+	byKind := make(map[string]*widgets.TreeNode)
+	for _, c := range changes {
+		node, ok := byKind[c.kind]
+		if !ok {
+			node = &widgets.TreeNode{}
+			byKind[c.kind] = node
+			nodes = append(nodes, node)
+		}
+		node.Nodes = append(node.Nodes, &widgets.TreeNode{Value: treeLabel(c.String())})
+		node.Value = treeLabel(fmt.Sprintf("%s (%d)", c.kind, len(node.Nodes)))
+	}
+	v.tree.Title = fmt.Sprintf(" Importing %s: %s; nothing is deleted ", path, changeCounts(changes))
+	v.tree.SetNodes(nodes)
+	v.tree.ExpandAll()
+}
+
+// Capturing reports whether a dialog is open or an import is waiting for
+// confirmation.
+func (v *topologyView) Capturing() bool {
+	return v.form != nil || v.confirm != nil
+}
@@ -0,0 +1,383 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// traceExchange is where the broker copies every publish and delivery of a
+// vhost whose firehose is on.
+const traceExchange = "amq.rabbitmq.trace"
+
+// traceBuffer is how many trace events the trace view keeps, and how many
+// its queue holds if the view falls behind, so a busy vhost cannot fill the
+// broker's memory with copies.
+const traceBuffer = 500
+
+// traceEvent is one message the firehose saw being published to an
+// exchange or delivered from a queue.
+type traceEvent struct {
+	at       time.Time
+	kind     string // "publish" or "deliver"
+	exchange string
+	keys     []string
+	queue    string
+	user     string
+	message  MessageInfo
+	payload  []byte
+}
+
+// traceEventOf reads a firehose message. Its routing key is publish.<exchange>
+// or deliver.<queue>, the original exchange, routing keys and properties are
+// in its headers and its body is the original payload.
+func traceEventOf(d amqp.Delivery) traceEvent {
+	e := traceEvent{at: time.Now(), payload: d.Body}
+	e.kind, e.queue, _ = strings.Cut(d.RoutingKey, ".")
+	if e.kind == "publish" {
+		e.queue = ""
+	}
+	e.exchange, _ = d.Headers["exchange_name"].(string)
+	if keys, ok := d.Headers["routing_keys"].([]interface{}); ok {
+		for _, k := range keys {
+			e.keys = append(e.keys, fmt.Sprint(k))
+		}
+	}
+	e.user, _ = d.Headers["user"].(string)
+
+	original := amqp.Delivery{Body: d.Body, Exchange: e.exchange, RoutingKey: strings.Join(e.keys, ", ")}
+	if props, ok := d.Headers["properties"].(amqp.Table); ok {
+		str := func(name string) string { s, _ := props[name].(string); return s }
+		original.ContentType, original.ContentEncoding = str("content_type"), str("content_encoding")
+		original.CorrelationId, original.ReplyTo, original.Expiration, original.MessageId = str("correlation_id"), str("reply_to"), str("expiration"), str("message_id")
+		original.Type, original.UserId, original.AppId = str("type"), str("user_id"), str("app_id")
+		original.DeliveryMode, original.Priority = uint8(tableInt(props["delivery_mode"])), uint8(tableInt(props["priority"]))
+		if headers, ok := props["headers"].(amqp.Table); ok {
+			original.Headers = headers
+		}
+	}
+	e.message = deliveryMessage(original)
+	return e
+}
+
+// tableInt reads an integer from an AMQP table, which keeps the width it
+// was encoded with.
+func tableInt(v interface{}) int {
+	switch n := v.(type) {
+	case int8:
+		return int(n)
+	case int16:
+		return int(n)
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	case uint8:
+		return int(n)
+	}
+	return 0
+}
+
+// traceViewer follows the firehose of a vhost, drawn over the page: a
+// scrolling list of publishes and deliveries and an inspector of the
+// selected one. It consumes from a queue of its own bound to
+// amq.rabbitmq.trace, which the broker removes when the view is closed.
+type traceViewer struct {
+	vhost     string
+	conn      *amqp.Connection
+	redraw    chan<- struct{}
+	inspector *messageInspector
+
+	mu     sync.Mutex
+	events []traceEvent
+	err    error
+
+	// filter keeps the events whose exchange, routing keys, queue or
+	// payload it matches; editing is the pattern being typed.
+	filter  *regexp.Regexp
+	editing *lineInput
+	// cursor is the selected event among the shown ones. follow keeps it
+	// on the newest until it is moved.
+	cursor int
+	follow bool
+	paused bool
+
+	list *widgets.Table
+}
+
+// newTraceViewer binds a new exclusive queue to the trace exchange of
+// vhost and starts consuming from it.
+func newTraceViewer(d *dashboard, vhost string) (*traceViewer, error) {
+	conn, err := amqp.Dial(amqpURI(d.config) + url.PathEscape(vhost))
+	if err != nil {
+		return nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	q, err := ch.QueueDeclare("", false, true, true, false, amqp.Table{"x-max-length": int32(traceBuffer)})
+	if err == nil {
+		err = ch.QueueBind(q.Name, "#", traceExchange, false, nil)
+	}
+	var deliveries <-chan amqp.Delivery
+	if err == nil {
+		deliveries, err = ch.Consume(q.Name, "rabbitspy-trace", true, true, false, false, nil)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	list := widgets.NewTable()
+	list.TextStyle = termui.NewStyle(termui.ColorWhite)
+	list.BorderStyle = termui.NewStyle(termui.ColorYellow)
+	list.RowSeparator = false
+	list.FillRow = true
+
+	masker, _ := newPayloadMasker(d.config.Privacy.MaskPaths)
+	t := &traceViewer{
+		vhost:     vhost,
+		conn:      conn,
+		redraw:    d.redraw,
+		inspector: newMessageInspector(d.config.Browser, masker),
+		follow:    true,
+		list:      list,
+	}
+	go t.consume(ch, deliveries)
+	return t, nil
+}
+
+// consume keeps the latest traceBuffer events until the connection closes.
+func (t *traceViewer) consume(ch *amqp.Channel, deliveries <-chan amqp.Delivery) {
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+	for d := range deliveries {
+		t.mu.Lock()
+		if !t.paused {
+			t.events = append(t.events, traceEventOf(d))
+			if len(t.events) > traceBuffer {
+				t.events = t.events[len(t.events)-traceBuffer:]
+			}
+		}
+		t.mu.Unlock()
+		t.signal()
+	}
+	if err, ok := <-closed; ok && err != nil {
+		t.mu.Lock()
+		t.err = err
+		t.mu.Unlock()
+		t.signal()
+	}
+}
+
+func (t *traceViewer) signal() {
+	if t.redraw == nil {
+		return
+	}
+	select {
+	case t.redraw <- struct{}{}:
+	default:
+	}
+}
+
+// close stops consuming; the broker deletes the exclusive queue with the
+// connection.
+func (t *traceViewer) close() {
+	t.conn.Close()
+}
+
+// matches reports whether the event passes the filter.
+func (t *traceViewer) matches(e traceEvent) bool {
+	if t.filter == nil {
+		return true
+	}
+	return t.filter.MatchString(e.exchange) || t.filter.MatchString(e.queue) ||
+		t.filter.MatchString(strings.Join(e.keys, " ")) || t.filter.Match(e.payload)
+}
+
+// shown are the events that pass the filter, oldest first.
+func (t *traceViewer) shown() []traceEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var events []traceEvent
+	for _, e := range t.events {
+		if t.matches(e) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// Feed applies one key event and reports whether the viewer was closed.
+// / edits the filter, a regular expression; p pauses, c clears and f
+// follows the newest event again.
+func (t *traceViewer) Feed(d *dashboard, id string) (done bool) {
+	if t.editing != nil {
+		done, cancelled := t.editing.Feed(id)
+		if !done {
+			return false
+		}
+		pattern := t.editing.value
+		t.editing = nil
+		if cancelled {
+			return false
+		}
+		if pattern == "" {
+			t.filter = nil
+			return false
+		}
+		filter, err := regexp.Compile(pattern)
+		if err != nil {
+			d.setNotice("[Invalid filter %q: %s](fg:red)", pattern, err)
+			return false
+		}
+		t.filter, t.follow = filter, true
+		return false
+	}
+	if t.inspector.Feed(id) {
+		return false
+	}
+	switch id {
+	case "<Escape>", "T":
+		t.close()
+		return true
+	case "/":
+		t.editing = &lineInput{}
+		if t.filter != nil {
+			t.editing.value = t.filter.String()
+		}
+	case "p":
+		t.mu.Lock()
+		t.paused = !t.paused
+		t.mu.Unlock()
+	case "c":
+		t.mu.Lock()
+		t.events = nil
+		t.mu.Unlock()
+		t.cursor, t.follow = 0, true
+	case "f":
+		t.follow = true
+	case "y":
+		if t.inspector.text != nil {
+			d.copyText("the payload of the selected event", t.inspector.text)
+		}
+	case "j", "<Down>":
+		t.cursor++
+		t.follow = false
+		t.inspector.reset()
+	case "k", "<Up>":
+		if t.cursor > 0 {
+			t.cursor--
+		}
+		t.follow = false
+		t.inspector.reset()
+	}
+	return false
+}
+
+// snippet is the start of a payload on one line, decoded and masked.
+func (t *traceViewer) snippet(e traceEvent) string {
+	payload, _, _ := decodePayload(e.payload, e.message.Properties.ContentEncoding, false)
+	payload = t.inspector.masker.Mask(payload)
+	if !isText(payload) {
+		return fmt.Sprintf("(binary, %s)", formatBytes(int64(len(e.payload))))
+	}
+	s := strings.Join(strings.Fields(string(payload)), " ")
+	if r := []rune(s); len(r) > 80 {
+		s = string(r[:80]) + "…"
+	}
+	return s
+}
+
+func (t *traceViewer) Render() {
+	width, height := termui.TerminalDimensions()
+	height -= statusBarHeight
+	listHeight := height / 2
+	if listHeight < 5 {
+		listHeight = 5
+	}
+
+	events := t.shown()
+	if t.follow || t.cursor >= len(events) {
+		t.cursor = max(len(events)-1, 0)
+	}
+	t.mu.Lock()
+	paused, err, total := t.paused, t.err, len(t.events)
+	t.mu.Unlock()
+
+	state := "live"
+	if paused {
+		state = "paused"
+	}
+	t.list.Title = fmt.Sprintf(" Firehose of %s · %s · %d events ", t.vhost, state, total)
+	if t.filter != nil {
+		t.list.Title = fmt.Sprintf(" Firehose of %s · %s · %d of %d events matching /%s/ ", t.vhost, state, len(events), total, t.filter)
+	}
+	t.list.ColumnWidths = spreadWidths(width, 9, 8, 16, 16, 16, 8, 0)
+	rows := [][]string{{"[Time](fg:black,bg:yellow)", "[Event](fg:black,bg:yellow)", "[Exchange](fg:black,bg:yellow)", "[Routing key](fg:black,bg:yellow)", "[Queue](fg:black,bg:yellow)", "[Size](fg:black,bg:yellow)", "[Payload](fg:black,bg:yellow)"}}
+	t.list.RowStyles = map[int]termui.Style{}
+	switch {
+	case err != nil:
+		rows = append(rows, []string{"", "", "[Trace connection closed](fg:red)", "", "", "", ""})
+	case len(events) == 0 && total > 0:
+		rows = append(rows, []string{"", "", "No events match the filter.", "", "", "", ""})
+	case len(events) == 0:
+		rows = append(rows, []string{"", "", "Waiting for messages to be published or delivered.", "", "", "", ""})
+	}
+	start := 0
+	if pageRows := listHeight - 3; t.cursor >= pageRows {
+		start = t.cursor - pageRows + 1
+	}
+	for i, e := range events[start:] {
+		if start+i == t.cursor {
+			t.list.RowStyles[i+1] = termui.NewStyle(termui.ColorWhite, termui.ColorBlue, termui.ModifierBold)
+		}
+		exchange := e.exchange
+		if exchange == "" {
+			exchange = "(AMQP default)"
+		}
+		kind := "[publish](fg:cyan)"
+		if e.kind == "deliver" {
+			kind = "[deliver](fg:green)"
+		}
+		rows = append(rows, []string{e.at.Format("15:04:05"), kind, exchange, strings.Join(e.keys, ", "), e.queue, formatBytes(int64(len(e.payload))), t.snippet(e)})
+	}
+	t.list.Rows = rows
+
+	t.list.Title += "· j/k select · y copy · f follow · / filter · p pause · c clear · Esc to close "
+	if t.editing != nil {
+		t.list.Title = fmt.Sprintf(" Filter (regular expression): %s_  Enter to apply, Esc to cancel ", t.editing.value)
+	}
+	t.inspector.clear()
+	if t.cursor < len(events) {
+		e := events[t.cursor]
+		exchange := e.exchange
+		if exchange == "" {
+			exchange = "(AMQP default)"
+		}
+		intro := fmt.Sprintf("[Published](fg:cyan,mod:bold) to %s with routing key %s", exchange, strings.Join(e.keys, ", "))
+		if e.kind == "deliver" {
+			intro = fmt.Sprintf("[Delivered](fg:green,mod:bold) from %s, published to %s with routing key %s", e.queue, exchange, strings.Join(e.keys, ", "))
+		}
+		intro += " at " + e.at.Format("15:04:05.000")
+		if e.user != "" {
+			intro += " by " + e.user
+		}
+		t.inspector.show(intro, e.message)
+	}
+	if err != nil {
+		t.inspector.error(err)
+	}
+
+	t.list.SetRect(0, 3, width, 3+listHeight)
+	t.inspector.SetRect(0, 3+listHeight, width, height)
+	drawWidgets(t.list, t.inspector.properties, t.inspector.payload)
+}
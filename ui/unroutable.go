@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/genc-murat/rabbitspy/management"
+)
+
+// unroutableSource is an exchange or channel that has had messages it could
+// not route: returned to a publisher that set mandatory, or dropped.
+type unroutableSource struct {
+	kind  string
+	name  string
+	stats management.MessageStats
+}
+
+func (s unroutableSource) active() bool {
+	return s.stats.DropUnroutableDetails.Rate > 0 || s.stats.ReturnUnroutableDetails.Rate > 0
+}
+
+// unroutableSources lists the exchanges and channels with unroutable
+// messages, those still producing them first and then by how many they
+// dropped.
+func unroutableSources(exchanges []ExchangeInfo, channels []ChannelInfo) []unroutableSource {
+	var sources []unroutableSource
+	for _, e := range exchanges {
+		if e.MessageStats.DropUnroutable > 0 || e.MessageStats.ReturnUnroutable > 0 {
+			sources = append(sources, unroutableSource{"exchange", exchangeName(e), e.MessageStats})
+		}
+	}
+	for _, c := range channels {
+		if c.MessageStats.DropUnroutable > 0 || c.MessageStats.ReturnUnroutable > 0 {
+			sources = append(sources, unroutableSource{"channel", c.Name, c.MessageStats})
+		}
+	}
+	sort.SliceStable(sources, func(i, j int) bool {
+		if a, b := sources[i].active(), sources[j].active(); a != b {
+			return a
+		}
+		if a, b := sources[i].stats.DropUnroutable, sources[j].stats.DropUnroutable; a != b {
+			return a > b
+		}
+		return sources[i].stats.ReturnUnroutable > sources[j].stats.ReturnUnroutable
+	})
+	return sources
+}
+
+// unroutableAlert is raised while any exchange is dropping or returning
+// messages. Dropped messages are lost without the publisher knowing, so they
+// are critical; returned ones have at least reached a publisher that can act
+// on them.
+func unroutableAlert(exchanges []ExchangeInfo) (Alert, bool) {
+	var dropped, returned float64
+	var names []string
+	for _, e := range exchanges {
+		drop, ret := e.MessageStats.DropUnroutableDetails.Rate, e.MessageStats.ReturnUnroutableDetails.Rate
+		if drop <= 0 && ret <= 0 {
+			continue
+		}
+		dropped += drop
+		returned += ret
+		names = append(names, exchangeName(e))
+	}
+	if len(names) == 0 {
+		return Alert{}, false
+	}
+	severity := SeverityWarning
+	if dropped > 0 {
+		severity = SeverityCritical
+	}
+	return Alert{
+		Key:      "unroutable-messages",
+		Severity: severity,
+		Message:  fmt.Sprintf("Unroutable messages: %.1f/s dropped, %.1f/s returned on %s", dropped, returned, strings.Join(names, ", ")),
+		Value:    dropped + returned,
+	}, true
+}
+
+// unroutableView lists exchanges and channels with dropped or returned
+// messages. Sources still producing them are shown in red.
+type unroutableView struct {
+	*listView
+}
+
+func newUnroutableView() *unroutableView {
+	v := &unroutableView{}
+	v.listView = newListView("Unroutable messages",
+		[]string{"Exchange / Channel", "Kind", "Dropped/s", "Returned/s", "Dropped", "Returned"},
+		func(width int) []int { return spreadWidths(width, 0, 9, 10, 11, 10, 10) },
+		v.rows)
+	return v
+}
+
+func (v *unroutableView) rows(d *dashboard) ([]listRow, string) {
+	var rows []listRow
+	for _, s := range unroutableSources(d.exchanges, d.channels) {
+		stats := s.stats
+		detail := fmt.Sprintf("The %s %s has dropped %s and returned %s unroutable messages.",
+			s.kind, s.name, groupDigits(stats.DropUnroutable), groupDigits(stats.ReturnUnroutable))
+		if stats.DropUnroutableDetails.Rate > 0 {
+			detail += "\n[Messages are being dropped: no binding matches and the publisher did not set mandatory, so nobody is told. Add the missing binding or an alternate-exchange.](fg:red)"
+		}
+		if stats.ReturnUnroutableDetails.Rate > 0 {
+			detail += "\n[Messages are being returned to a publisher that set mandatory: check the routing keys it uses against the bindings.](fg:yellow)"
+		}
+		rows = append(rows, listRow{
+			cells: []string{
+				s.name, s.kind,
+				rateCell(stats.DropUnroutableDetails.Rate), rateCell(stats.ReturnUnroutableDetails.Rate),
+				groupDigits(stats.DropUnroutable), groupDigits(stats.ReturnUnroutable),
+			},
+			broken: s.active(),
+			detail: detail,
+			key:    s.kind + ":" + s.name,
+		})
+	}
+	return rows, "[No unroutable messages.](fg:green)"
+}
@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// usersView lists the broker's users with their tags and, for every vhost
+// they have permissions in, the patterns of the resources they may
+// configure, write to and read from. Like the topology, they are fetched
+// when the page is first shown for a cluster and on request, not polled.
+type usersView struct {
+	*listView
+
+	loadedFor   *dashboard
+	users       []UserInfo
+	permissions []PermissionInfo
+	err         error
+}
+
+func newUsersView() *usersView {
+	v := &usersView{}
+	v.listView = newListView("Users",
+		[]string{"User", "Tags", "VHost", "Configure", "Write", "Read"},
+		func(width int) []int { return spreadWidths(width, 16, 0, 12, 0, 0, 0) },
+		v.rows)
+	return v
+}
+
+func (v *usersView) load(d *dashboard) {
+	v.loadedFor = d
+	v.permissions = nil
+	v.users, v.err = getUsers(d.config)
+	if v.err == nil {
+		v.permissions, v.err = getPermissions(d.config)
+	}
+	v.prompt = fmt.Sprintf("%d users, %d vhost permissions · u reload", len(v.users), len(v.permissions))
+}
+
+// permissionPattern shows an empty pattern, which matches nothing, as such.
+func permissionPattern(pattern string) string {
+	if pattern == "" {
+		return "(none)"
+	}
+	return pattern
+}
+
+func (v *usersView) rows(d *dashboard) ([]listRow, string) {
+	if v.err != nil {
+		if isAuthError(v.err) {
+			return nil, "Listing users and permissions needs the administrator tag: " + v.err.Error()
+		}
+		return nil, "Could not fetch users: " + v.err.Error()
+	}
+
+	byUser := make(map[string][]PermissionInfo)
+	for _, p := range v.permissions {
+		byUser[p.User] = append(byUser[p.User], p)
+	}
+	users := append([]UserInfo(nil), v.users...)
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+
+	var rows []listRow
+	for _, u := range users {
+		tags := strings.Join(u.Tags, ", ")
+		detail := fmt.Sprintf("User %s", u.Name)
+		if tags != "" {
+			detail += ", tagged " + tags
+		}
+		for _, tag := range u.Tags {
+			if tag == "administrator" {
+				detail += "\n[Administrators can manage users, vhosts and permissions, and every object on the broker.](fg:yellow)"
+			}
+		}
+
+		permissions := byUser[u.Name]
+		sort.Slice(permissions, func(i, j int) bool { return permissions[i].VHost < permissions[j].VHost })
+		if len(permissions) == 0 {
+			rows = append(rows, listRow{
+				cells:  []string{u.Name, tags, "—", "", "", ""},
+				detail: detail + ".\nNo permissions in any vhost, so it cannot open a connection.",
+				key:    u.Name,
+			})
+			continue
+		}
+		vhosts := make([]string, len(permissions))
+		for i, p := range permissions {
+			vhosts[i] = p.VHost
+		}
+		for _, p := range permissions {
+			rows = append(rows, listRow{
+				cells: []string{u.Name, tags, p.VHost, permissionPattern(p.Configure), permissionPattern(p.Write), permissionPattern(p.Read)},
+				detail: fmt.Sprintf("%s, with permissions in %s.\nIn %s it may configure (declare and delete) resources matching %s, write (publish) to %s and read (consume) from %s.",
+					detail, strings.Join(vhosts, ", "), p.VHost,
+					permissionPattern(p.Configure), permissionPattern(p.Write), permissionPattern(p.Read)),
+				key: u.Name + "\x00" + p.VHost,
+			})
+		}
+	}
+	return rows, "No users."
+}
+
+func (v *usersView) Render(d *dashboard, ui uiState) {
+	if v.loadedFor != d {
+		v.load(d)
+	}
+	v.listView.Render(d, ui)
+}
+
+func (v *usersView) HandleKey(d *dashboard, id string) bool {
+	if id == "u" {
+		v.load(d)
+		return true
+	}
+	return v.listView.HandleKey(d, id)
+}
@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+)
+
+// verifyTimeout is how long the effect of a destructive action may take to
+// show up in polls before it is reported as a discrepancy.
+const verifyTimeout = 30 * time.Second
+
+// verification is the effect an action should have on the queues. check
+// compares a poll against it and describes what it found; it is run on
+// every poll until it reports ok or until passes.
+type verification struct {
+	check func(queues []QueueInfo) (report string, ok bool)
+	until time.Time
+}
+
+// verifyAfter polls again straight away and then keeps checking v, so the
+// outcome of an action is confirmed from the broker's own counts rather than
+// assumed from a successful API call.
+func (d *dashboard) verifyAfter(v verification) {
+	v.until = time.Now().Add(verifyTimeout)
+	d.verifications = append(d.verifications, v)
+	d.poll()
+}
+
+// checkVerifications reports verifications that passed or timed out and
+// keeps the others for the next poll. Nothing is checked against queues
+// left over from a failed poll.
+func (d *dashboard) checkVerifications() {
+	if d.lastErr != nil {
+		return
+	}
+	pending := d.verifications[:0]
+	for _, v := range d.verifications {
+		report, ok := v.check(d.queues)
+		switch {
+		case ok:
+			d.setNotice("[✓ %s](fg:green)", report)
+		case time.Now().After(v.until):
+			d.annotations.Add(annotation{at: time.Now(), text: "Discrepancy: " + report, source: "verification"})
+			d.setNotice("[✗ Discrepancy: %s](fg:red,mod:bold)", report)
+		default:
+			d.setNotice("Verifying: %s", report)
+			pending = append(pending, v)
+		}
+	}
+	d.verifications = pending
+}
+
+// groupDigits formats n with thousands separators, e.g. 12,401.
+func groupDigits(n int) string {
+	if n < 0 {
+		return "-" + groupDigits(-n)
+	}
+	s := fmt.Sprintf("%d", n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// publishedSince is how many messages were published to q after before was
+// taken, which a purge cannot have removed.
+func publishedSince(before, q QueueInfo) int {
+	if n := q.MessageStats.Publish - before.MessageStats.Publish; n > 0 {
+		return n
+	}
+	return 0
+}
+
+// expectPurged checks that a purge left no ready messages other than those
+// published since. Unacknowledged messages are not purged.
+func expectPurged(before QueueInfo) verification {
+	return verification{check: func(queues []QueueInfo) (string, bool) {
+		q, ok := findQueue(queues, before.Key())
+		if !ok {
+			return fmt.Sprintf("%s disappeared after the purge", before.Key()), false
+		}
+		purged := before.MessagesReady - q.MessagesReady
+		if purged < 0 {
+			purged = 0
+		}
+		report := fmt.Sprintf("purged %s messages from %s, queue now %s", groupDigits(purged), before.Key(), groupDigits(q.MessagesReady))
+		if published := publishedSince(before, q); published > 0 {
+			report += fmt.Sprintf(" (%s published since)", groupDigits(published))
+		}
+		return report, q.MessagesReady <= publishedSince(before, q)
+	}}
+}
+
+// expectCreated checks that a declared queue shows up.
+func expectCreated(vhost, name string) verification {
+	key := vhost + "/" + name
+	return verification{check: func(queues []QueueInfo) (string, bool) {
+		if _, ok := findQueue(queues, key); !ok {
+			return fmt.Sprintf("waiting for %s to show up", key), false
+		}
+		return fmt.Sprintf("created %s", key), true
+	}}
+}
+
+// expectDeleted checks that a deleted queue is gone.
+func expectDeleted(before QueueInfo) verification {
+	return verification{check: func(queues []QueueInfo) (string, bool) {
+		if q, ok := findQueue(queues, before.Key()); ok {
+			return fmt.Sprintf("%s still exists with %s messages", before.Key(), groupDigits(q.Messages)), false
+		}
+		return fmt.Sprintf("deleted %s, which held %s messages", before.Key(), groupDigits(before.Messages)), true
+	}}
+}
+
+// expectMoved checks that n messages left from and arrived in to. Traffic
+// on either queue during the move can make the counts drift, which is
+// reported rather than hidden.
+func expectMoved(from, to QueueInfo, n int) verification {
+	return verification{check: func(queues []QueueInfo) (string, bool) {
+		src, srcOK := findQueue(queues, from.Key())
+		dst, dstOK := findQueue(queues, to.Key())
+		if !srcOK || !dstOK {
+			return fmt.Sprintf("moving %s messages: %s or %s disappeared", groupDigits(n), from.Key(), to.Key()), false
+		}
+		left := from.Messages - src.Messages + publishedSince(from, src)
+		arrived := dst.Messages - to.Messages
+		report := fmt.Sprintf("moved %s messages from %s to %s: %s left, %s arrived; now %s and %s",
+			groupDigits(n), from.Key(), to.Key(), groupDigits(left), groupDigits(arrived),
+			groupDigits(src.Messages), groupDigits(dst.Messages))
+		return report, left >= n && arrived >= n
+	}}
+}
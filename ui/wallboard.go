@@ -0,0 +1,252 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+const bigTextHeight = 5
+
+// bigFont is a small figlet-style block font covering what the wallboard
+// needs to print: numbers, units and rates.
+var bigFont = map[rune][bigTextHeight]string{
+	'0': {"███", "█ █", "█ █", "█ █", "███"},
+	'1': {" █ ", "██ ", " █ ", " █ ", "███"},
+	'2': {"███", "  █", "███", "█  ", "███"},
+	'3': {"███", "  █", "███", "  █", "███"},
+	'4': {"█ █", "█ █", "███", "  █", "  █"},
+	'5': {"███", "█  ", "███", "  █", "███"},
+	'6': {"███", "█  ", "███", "█ █", "███"},
+	'7': {"███", "  █", "  █", "  █", "  █"},
+	'8': {"███", "█ █", "███", "█ █", "███"},
+	'9': {"███", "█ █", "███", "  █", "███"},
+	'.': {" ", " ", " ", " ", "█"},
+	'/': {"  █", "  █", " █ ", "█  ", "█  "},
+	'-': {"   ", "   ", "███", "   ", "   "},
+	'k': {"█  ", "█ █", "██ ", "█ █", "█ █"},
+	'M': {"█   █", "██ ██", "█ █ █", "█   █", "█   █"},
+	's': {"   ", " ██", "█  ", "  █", "██ "},
+	' ': {"  ", "  ", "  ", "  ", "  "},
+}
+
+func renderBigText(s string) []string {
+	lines := make([]string, bigTextHeight)
+	for _, r := range s {
+		glyph, ok := bigFont[r]
+		if !ok {
+			glyph = bigFont[' ']
+		}
+		for i := range lines {
+			lines[i] += glyph[i] + " "
+		}
+	}
+	return lines
+}
+
+func compactNumber(f float64) string {
+	switch {
+	case f >= 1e6:
+		return fmt.Sprintf("%.1fM", f/1e6)
+	case f >= 1e4:
+		return fmt.Sprintf("%.1fk", f/1e3)
+	case f != float64(int(f)):
+		return fmt.Sprintf("%.1f", f)
+	default:
+		return fmt.Sprintf("%d", int(f))
+	}
+}
+
+type wallboardMetric struct {
+	label string
+	value string
+	color string
+}
+
+type wallboardPage struct {
+	title   string
+	metrics func(d *dashboard) []wallboardMetric
+}
+
+var wallboardPages = []wallboardPage{
+	{"Backlog", func(d *dashboard) []wallboardMetric {
+		var ready, unacked int
+		for _, q := range d.queues {
+			// As in the summary line, streams keep consumed messages.
+			if q.Type != "stream" {
+				ready += q.MessagesReady
+				unacked += q.MessagesUnack
+			}
+		}
+		return []wallboardMetric{
+			{"READY", compactNumber(float64(ready)), countColor(ready)},
+			{"UNACKED", compactNumber(float64(unacked)), countColor(unacked)},
+		}
+	}},
+	{"Throughput", func(d *dashboard) []wallboardMetric {
+		var publish, deliver float64
+		for _, q := range d.queues {
+			publish += q.MessageStats.PublishDetails.Rate
+			deliver += q.MessageStats.DeliverGetDetails.Rate
+		}
+		return []wallboardMetric{
+			{"PUBLISH", compactNumber(publish) + "/s", "cyan"},
+			{"DELIVER", compactNumber(deliver) + "/s", "cyan"},
+		}
+	}},
+	{"Cluster", func(d *dashboard) []wallboardMetric {
+		var consumers int
+		for _, q := range d.queues {
+			consumers += q.Consumers
+		}
+		connections := "-"
+		if d.overview != nil {
+			connections = compactNumber(float64(d.overview.ObjectTotals.Connections))
+		}
+		return []wallboardMetric{
+			{"QUEUES", compactNumber(float64(len(d.queues))), "white"},
+			{"CONSUMERS", compactNumber(float64(consumers)), "white"},
+			{"CONNECTIONS", connections, "white"},
+		}
+	}},
+}
+
+func countColor(n int) string {
+	if n == 0 {
+		return "green"
+	} else if n < 100 {
+		return "yellow"
+	}
+	return "red"
+}
+
+type wallboardSlide struct {
+	dashboard *dashboard
+	page      wallboardPage
+	dwell     time.Duration
+}
+
+// wallboardView renders a few key numbers in large type and cycles through
+// clusters and pages on its own. It has no borders, hints or interactive keys
+// besides quitting, so it can be left running on an operations room TV.
+// Rotation holds on a slide while its cluster has a critical alert.
+type wallboardView struct {
+	slides     []wallboardSlide
+	current    int
+	lastSwitch time.Time
+}
+
+func findWallboardPage(name string) (wallboardPage, bool) {
+	for _, page := range wallboardPages {
+		if strings.EqualFold(page.title, name) {
+			return page, true
+		}
+	}
+	return wallboardPage{}, false
+}
+
+func newWallboardView(config Config, dashboards []*dashboard) (*wallboardView, error) {
+	defaultDwell := time.Duration(config.Wallboard.PageSeconds) * time.Second
+	v := &wallboardView{lastSwitch: time.Now()}
+
+	if len(config.Wallboard.Rotation) == 0 {
+		for _, d := range dashboards {
+			for _, page := range wallboardPages {
+				v.slides = append(v.slides, wallboardSlide{dashboard: d, page: page, dwell: defaultDwell})
+			}
+		}
+		return v, nil
+	}
+
+	for _, slide := range config.Wallboard.Rotation {
+		var target *dashboard
+		for _, d := range dashboards {
+			if d.name == slide.Cluster || (slide.Cluster == "" && len(dashboards) == 1) {
+				target = d
+				break
+			}
+		}
+		if target == nil {
+			return nil, fmt.Errorf("unknown cluster %q", slide.Cluster)
+		}
+		page, ok := findWallboardPage(slide.Page)
+		if !ok {
+			return nil, fmt.Errorf("unknown page %q", slide.Page)
+		}
+		dwell := defaultDwell
+		if slide.Seconds > 0 {
+			dwell = time.Duration(slide.Seconds) * time.Second
+		}
+		v.slides = append(v.slides, wallboardSlide{dashboard: target, page: page, dwell: dwell})
+	}
+	return v, nil
+}
+
+// Render ignores the focused dashboard and draws whichever cluster the
+// rotation is on.
+func (v *wallboardView) Render(_ *dashboard, _ uiState) {
+	slide := v.slides[v.current]
+	paused := slide.dashboard.hasCritical()
+	if !paused && time.Since(v.lastSwitch) >= slide.dwell {
+		v.current = (v.current + 1) % len(v.slides)
+		v.lastSwitch = time.Now()
+		slide = v.slides[v.current]
+	}
+	d := slide.dashboard
+	metrics := slide.page.metrics(d)
+
+	width, height := termui.TerminalDimensions()
+	termui.Clear()
+
+	title := widgets.NewParagraph()
+	title.Border = false
+	title.Text = fmt.Sprintf("[%s · %s](fg:yellow,mod:bold)  %d/%d",
+		strings.ToUpper(d.name), strings.ToUpper(slide.page.title), v.current+1, len(v.slides))
+	if paused && len(v.slides) > 1 {
+		title.Text += "  [ROTATION PAUSED](fg:red,mod:bold)"
+	}
+	title.SetRect(0, 0, width, 1)
+	drawables := []termui.Drawable{title}
+
+	tileWidth := width / len(metrics)
+	top := (height - bigTextHeight - 3) / 2
+	for i, m := range metrics {
+		tile := widgets.NewParagraph()
+		tile.Border = false
+		tile.WrapText = false
+		text := fmt.Sprintf("[%s](fg:white,mod:bold)\n\n", m.label)
+		for _, line := range renderBigText(m.value) {
+			text += fmt.Sprintf("[%s](fg:%s)\n", line, m.color)
+		}
+		tile.Text = text
+		tile.SetRect(i*tileWidth+2, top, (i+1)*tileWidth, top+bigTextHeight+3)
+		drawables = append(drawables, tile)
+	}
+
+	status := widgets.NewParagraph()
+	status.Border = false
+	status.WrapText = false
+	status.Text = wallboardStatus(d)
+	status.SetRect(0, height-2, width, height)
+	drawables = append(drawables, status)
+
+	drawWidgets(drawables...)
+}
+
+func (v *wallboardView) HandleKey(d *dashboard, id string) bool {
+	return false
+}
+
+func wallboardStatus(d *dashboard) string {
+	if len(d.activeAlerts) == 0 {
+		return fmt.Sprintf("[ALL CLEAR](fg:green,mod:bold)  updated %s", d.lastUpdate.Format("15:04:05"))
+	}
+	var parts []string
+	for _, alert := range d.activeAlerts {
+		parts = append(parts, alert.Message)
+	}
+	return fmt.Sprintf("[%s](fg:red,mod:bold)", strings.Join(parts, "  |  "))
+}